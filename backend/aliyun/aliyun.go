@@ -0,0 +1,209 @@
+package aliyun
+
+import (
+	"CMDB/config"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk"
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/auth"
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/auth/credentials"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/rds"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/sts"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/tag"
+)
+
+// 扮演角色时使用的会话名称
+const roleSessionName = "cmdb-sync"
+
+// AliyunHelper 封装阿里云认证和各地域的客户端
+type AliyunHelper struct {
+	config config.AliyunConfig
+
+	mu         sync.Mutex
+	credential auth.Credential
+	accountID  string
+}
+
+// NewAliyunHelper 创建新的AliyunHelper实例
+func NewAliyunHelper(cfg config.AliyunConfig) *AliyunHelper {
+	return &AliyunHelper{config: cfg}
+}
+
+// AccountName 账号名称
+func (h *AliyunHelper) AccountName() string {
+	return h.config.Name
+}
+
+// Regions 需要同步的地域
+func (h *AliyunHelper) Regions() []string {
+	return h.config.Regions
+}
+
+// getCredential 按配置创建凭证：ECS实例RAM角色、扮演RAM角色或访问密钥
+func (h *AliyunHelper) getCredential() auth.Credential {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.credential != nil {
+		return h.credential
+	}
+
+	cfg := h.config
+	switch {
+	case cfg.ECSRAMRole != "":
+		h.credential = credentials.NewEcsRamRoleCredential(cfg.ECSRAMRole)
+	case cfg.RoleARN != "":
+		h.credential = credentials.NewRamRoleArnWithPolicyAndExternalIdCredential(
+			cfg.AccessKeyID, cfg.AccessKeySecret, cfg.RoleARN, roleSessionName, "", cfg.ExternalID, 3600,
+		)
+	default:
+		h.credential = credentials.NewAccessKeyCredential(cfg.AccessKeyID, cfg.AccessKeySecret)
+	}
+	return h.credential
+}
+
+// sdkConfig 客户端配置，统一使用HTTPS
+func sdkConfig() *sdk.Config {
+	return sdk.NewConfig().WithScheme("HTTPS")
+}
+
+// GetAccountID 获取凭证所属的阿里云账号ID
+func (h *AliyunHelper) GetAccountID() (string, error) {
+	h.mu.Lock()
+	accountID := h.accountID
+	h.mu.Unlock()
+	if accountID != "" {
+		return accountID, nil
+	}
+
+	client, err := sts.NewClientWithOptions(h.config.Regions[0], sdkConfig(), h.getCredential())
+	if err != nil {
+		return "", fmt.Errorf("创建STS客户端失败: %v", err)
+	}
+	response, err := client.GetCallerIdentity(sts.CreateGetCallerIdentityRequest())
+	if err != nil {
+		return "", fmt.Errorf("获取调用者身份失败: %v", err)
+	}
+
+	h.mu.Lock()
+	h.accountID = response.AccountId
+	h.mu.Unlock()
+	return response.AccountId, nil
+}
+
+// GetInstances 列出地域内的全部ECS实例
+func (h *AliyunHelper) GetInstances(region string) ([]ecs.Instance, error) {
+	client, err := ecs.NewClientWithOptions(region, sdkConfig(), h.getCredential())
+	if err != nil {
+		return nil, fmt.Errorf("创建ECS客户端失败: %v", err)
+	}
+
+	var instances []ecs.Instance
+	request := ecs.CreateDescribeInstancesRequest()
+	request.MaxResults = "100"
+	for {
+		response, err := client.DescribeInstances(request)
+		if err != nil {
+			return nil, fmt.Errorf("列举ECS实例失败 (%s): %v", region, err)
+		}
+		instances = append(instances, response.Instances.Instance...)
+		if response.NextToken == "" {
+			break
+		}
+		request.NextToken = response.NextToken
+	}
+	return instances, nil
+}
+
+// GetDBInstances 列出地域内的全部RDS实例
+func (h *AliyunHelper) GetDBInstances(region string) ([]rds.DBInstance, error) {
+	client, err := rds.NewClientWithOptions(region, sdkConfig(), h.getCredential())
+	if err != nil {
+		return nil, fmt.Errorf("创建RDS客户端失败: %v", err)
+	}
+
+	var instances []rds.DBInstance
+	request := rds.CreateDescribeDBInstancesRequest()
+	request.MaxResults = "100"
+	for {
+		response, err := client.DescribeDBInstances(request)
+		if err != nil {
+			return nil, fmt.Errorf("列举RDS实例失败 (%s): %v", region, err)
+		}
+		instances = append(instances, response.Items.DBInstance...)
+		if response.NextToken == "" {
+			break
+		}
+		request.NextToken = response.NextToken
+	}
+	return instances, nil
+}
+
+// GetTaggedResources 通过标签服务列出地域内带自定义标签的资源，返回 ARN -> 标签
+func (h *AliyunHelper) GetTaggedResources(region string) (map[string]map[string]string, error) {
+	client, err := tag.NewClientWithOptions(region, sdkConfig(), h.getCredential())
+	if err != nil {
+		return nil, fmt.Errorf("创建标签服务客户端失败: %v", err)
+	}
+
+	resources := make(map[string]map[string]string)
+	request := tag.CreateListTagResourcesRequest()
+	request.Category = "Custom"
+	request.PageSize = "1000"
+	for {
+		response, err := client.ListTagResources(request)
+		if err != nil {
+			return nil, fmt.Errorf("列举标签资源失败 (%s): %v", region, err)
+		}
+		for _, item := range response.TagResources {
+			arn := normalizeARN(item.ResourceARN)
+			tags := resources[arn]
+			if tags == nil {
+				tags = make(map[string]string)
+				resources[arn] = tags
+			}
+			for _, t := range item.Tags {
+				tags[t.Key] = t.Value
+			}
+		}
+		if response.NextToken == "" {
+			break
+		}
+		request.NextToken = response.NextToken
+	}
+	return resources, nil
+}
+
+// buildARN 构造阿里云资源ARN，格式为 acs:service:region:account:type/id
+func buildARN(service, region, accountID, resourceType, resourceID string) string {
+	return fmt.Sprintf("acs:%s:%s:%s:%s/%s", service, region, accountID, resourceType, resourceID)
+}
+
+// normalizeARN 标签服务返回的ARN带有 arn: 前缀，统一去掉以便与实例ARN匹配
+func normalizeARN(arn string) string {
+	return strings.TrimPrefix(arn, "arn:")
+}
+
+// resourceTypeFromARN 从ARN中解析资源类型，如 ecs:instance、rds:dbinstance
+func resourceTypeFromARN(arn string) string {
+	parts := strings.SplitN(arn, ":", 5)
+	if len(parts) < 5 {
+		return ""
+	}
+	service, resource := parts[1], parts[4]
+	if i := strings.Index(resource, "/"); i > 0 {
+		return service + ":" + resource[:i]
+	}
+	return service
+}
+
+// resourceNameFromARN 从ARN中解析资源ID（最后一段）
+func resourceNameFromARN(arn string) string {
+	if i := strings.LastIndexAny(arn, "/:"); i >= 0 {
+		return arn[i+1:]
+	}
+	return arn
+}
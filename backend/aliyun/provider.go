@@ -0,0 +1,267 @@
+package aliyun
+
+import (
+	"CMDB/config"
+	"CMDB/model"
+	"CMDB/provider"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/rds"
+)
+
+// ProviderName 阿里云平台名称
+const ProviderName = "aliyun"
+
+func init() {
+	provider.RegisterFactory(ProviderName, newProviders)
+}
+
+// newProviders 为每个配置的阿里云账号创建Provider
+func newProviders(cfg *config.Config) ([]provider.Provider, error) {
+	var providers []provider.Provider
+	for _, account := range cfg.AliyunAccounts {
+		providers = append(providers, NewAliyunProvider(NewAliyunHelper(account)))
+	}
+	return providers, nil
+}
+
+// AliyunProvider 阿里云资源发现，同步ECS、RDS以及标签服务可见的资源
+// 同一范围同步期间，ECS、RDS实例列表和标签服务的结果在发现资源、虚拟机、数据库之间共享
+type AliyunProvider struct {
+	provider.ScopeCache
+	aliyunHelper *AliyunHelper
+}
+
+// NewAliyunProvider 创建新的阿里云Provider
+func NewAliyunProvider(aliyunHelper *AliyunHelper) *AliyunProvider {
+	return &AliyunProvider{aliyunHelper: aliyunHelper}
+}
+
+// Name 平台名称
+func (p *AliyunProvider) Name() string {
+	return ProviderName
+}
+
+// Account 账号名称
+func (p *AliyunProvider) Account() string {
+	return p.aliyunHelper.AccountName()
+}
+
+// Scopes 凭证所属的阿里云账号，账号内再遍历配置的地域
+func (p *AliyunProvider) Scopes() ([]provider.Scope, error) {
+	accountID, err := p.aliyunHelper.GetAccountID()
+	if err != nil {
+		return nil, err
+	}
+	return []provider.Scope{{ID: accountID}}, nil
+}
+
+// DiscoverVMs 发现账号下各地域的ECS实例
+func (p *AliyunProvider) DiscoverVMs(scope provider.Scope) ([]*model.VM, error) {
+	var vms []*model.VM
+	for _, region := range p.aliyunHelper.Regions() {
+		regionVMs, err := p.discoverRegionVMs(scope, region)
+		if err != nil {
+			return nil, err
+		}
+		vms = append(vms, regionVMs...)
+	}
+	return vms, nil
+}
+
+// discoverRegionVMs 发现地域内的ECS实例
+func (p *AliyunProvider) discoverRegionVMs(scope provider.Scope, region string) ([]*model.VM, error) {
+	instances, err := p.getInstances(scope.ID, region)
+	if err != nil {
+		return nil, err
+	}
+
+	var vms []*model.VM
+	for _, instance := range instances {
+		tags := convertECSTags(instance.Tags.Tag)
+		arn := buildARN("ecs", region, scope.ID, "instance", instance.InstanceId)
+
+		name := instance.InstanceName
+		if name == "" {
+			name = instance.InstanceId
+		}
+
+		vms = append(vms, &model.VM{
+			Provider:       ProviderName,
+			VMID:           arn,
+			ResourceID:     arn,
+			Name:           name,
+			Location:       region,
+			Type:           instance.InstanceType,
+			Status:         instance.Status,
+			Owner:          tags["owner"],
+			SubscriptionID: scope.ID,
+			Tags:           tags,
+		})
+	}
+	return vms, nil
+}
+
+// DiscoverDatabases 发现账号下各地域的RDS实例
+// RDS实例列表不返回标签，标签从标签服务中按ARN获取
+func (p *AliyunProvider) DiscoverDatabases(scope provider.Scope) ([]*model.Database, error) {
+	var databases []*model.Database
+	for _, region := range p.aliyunHelper.Regions() {
+		regionDatabases, err := p.discoverRegionDatabases(scope, region)
+		if err != nil {
+			return nil, err
+		}
+		databases = append(databases, regionDatabases...)
+	}
+	return databases, nil
+}
+
+// discoverRegionDatabases 发现地域内的RDS实例，有RDS实例时才查询标签服务
+func (p *AliyunProvider) discoverRegionDatabases(scope provider.Scope, region string) ([]*model.Database, error) {
+	instances, err := p.getDBInstances(scope.ID, region)
+	if err != nil {
+		return nil, err
+	}
+	if len(instances) == 0 {
+		return nil, nil
+	}
+
+	taggedResources, err := p.getTaggedResources(scope.ID, region)
+	if err != nil {
+		return nil, err
+	}
+
+	var databases []*model.Database
+	for _, instance := range instances {
+		arn := buildARN("rds", region, scope.ID, "dbinstance", instance.DBInstanceId)
+		tags := taggedResources[arn]
+		if tags == nil {
+			tags = make(map[string]string)
+		}
+
+		name := instance.DBInstanceDescription
+		if name == "" {
+			name = instance.DBInstanceId
+		}
+
+		databases = append(databases, &model.Database{
+			Provider:       ProviderName,
+			DatabaseID:     arn,
+			ResourceID:     arn,
+			Name:           name,
+			Location:       region,
+			Server:         instance.ConnectionString,
+			DBType:         "RDS " + instance.Engine,
+			Version:        instance.EngineVersion,
+			Status:         instance.DBInstanceStatus,
+			Owner:          tags["owner"],
+			SubscriptionID: scope.ID,
+			Tags:           tags,
+		})
+	}
+	return databases, nil
+}
+
+// DiscoverResources 通过标签服务发现账号下各地域的资源
+// 标签服务只返回打过标签的资源，因此额外合并ECS和RDS实例，保证虚拟机和数据库都有对应的资源记录
+// ECS和RDS实例先于标签服务的资源加入，使资源名称与虚拟机、数据库记录一致
+func (p *AliyunProvider) DiscoverResources(scope provider.Scope) ([]*model.Resource, error) {
+	var resources []*model.Resource
+	seen := make(map[string]bool)
+	add := func(resource *model.Resource) {
+		if seen[resource.ResourceID] {
+			return
+		}
+		seen[resource.ResourceID] = true
+		resources = append(resources, resource)
+	}
+
+	for _, region := range p.aliyunHelper.Regions() {
+		taggedResources, err := p.getTaggedResources(scope.ID, region)
+		if err != nil {
+			return nil, err
+		}
+
+		vms, err := p.discoverRegionVMs(scope, region)
+		if err != nil {
+			return nil, err
+		}
+		for _, vm := range vms {
+			add(newResource(vm.ResourceID, vm.Name, vm.Location, scope.ID, vm.Tags))
+		}
+
+		databases, err := p.discoverRegionDatabases(scope, region)
+		if err != nil {
+			return nil, err
+		}
+		for _, database := range databases {
+			add(newResource(database.ResourceID, database.Name, database.Location, scope.ID, database.Tags))
+		}
+
+		for arn, tags := range taggedResources {
+			add(newResource(arn, "", region, scope.ID, tags))
+		}
+	}
+
+	return resources, nil
+}
+
+// getInstances 列出地域内的ECS实例；范围同步期间结果被缓存
+func (p *AliyunProvider) getInstances(accountID, region string) ([]ecs.Instance, error) {
+	value, err := p.Load(accountID, "ecs:"+region, func() (interface{}, error) {
+		return p.aliyunHelper.GetInstances(region)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]ecs.Instance), nil
+}
+
+// getDBInstances 列出地域内的RDS实例；范围同步期间结果被缓存
+func (p *AliyunProvider) getDBInstances(accountID, region string) ([]rds.DBInstance, error) {
+	value, err := p.Load(accountID, "rds:"+region, func() (interface{}, error) {
+		return p.aliyunHelper.GetDBInstances(region)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]rds.DBInstance), nil
+}
+
+// getTaggedResources 列出地域内标签服务可见的资源标签，键为ARN；范围同步期间结果被缓存
+func (p *AliyunProvider) getTaggedResources(accountID, region string) (map[string]map[string]string, error) {
+	value, err := p.Load(accountID, "tag:"+region, func() (interface{}, error) {
+		return p.aliyunHelper.GetTaggedResources(region)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(map[string]map[string]string), nil
+}
+
+// newResource 根据ARN构造通用资源，name为空时使用ARN中的资源ID
+func newResource(arn, name, region, accountID string, tags map[string]string) *model.Resource {
+	if name == "" {
+		name = resourceNameFromARN(arn)
+	}
+
+	return &model.Resource{
+		Provider:       ProviderName,
+		ResourceID:     arn,
+		Name:           name,
+		Location:       region,
+		ResourceType:   resourceTypeFromARN(arn),
+		Owner:          tags["owner"],
+		SubscriptionID: accountID,
+		Tags:           tags,
+	}
+}
+
+// 辅助函数：转换ECS标签
+func convertECSTags(tags []ecs.Tag) map[string]string {
+	result := make(map[string]string)
+	for _, tag := range tags {
+		result[tag.TagKey] = tag.TagValue
+	}
+	return result
+}
@@ -0,0 +1,189 @@
+package aws
+
+import (
+	"CMDB/config"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// 扮演角色时使用的会话名称
+const roleSessionName = "cmdb-sync"
+
+// AWSHelper 封装AWS认证、角色扮演和区域配置
+type AWSHelper struct {
+	config config.AWSConfig
+
+	mu           sync.Mutex
+	base         *awssdk.Config
+	accountRoles map[string]string                   // 账号ID -> 扮演的角色ARN，空字符串表示凭证所属账号
+	roleCreds    map[string]*awssdk.CredentialsCache // 账号ID -> 扮演角色得到的凭证，各区域共用
+}
+
+// NewAWSHelper 创建新的AWSHelper实例
+func NewAWSHelper(cfg config.AWSConfig) *AWSHelper {
+	return &AWSHelper{
+		config:       cfg,
+		accountRoles: make(map[string]string),
+		roleCreds:    make(map[string]*awssdk.CredentialsCache),
+	}
+}
+
+// AccountName 账号名称
+func (h *AWSHelper) AccountName() string {
+	return h.config.Name
+}
+
+// Regions 需要同步的区域
+func (h *AWSHelper) Regions() []string {
+	return h.config.Regions
+}
+
+// baseConfig 加载基础配置，凭证来自静态密钥、profile或默认凭证链
+func (h *AWSHelper) baseConfig(ctx context.Context) (awssdk.Config, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.base != nil {
+		return *h.base, nil
+	}
+
+	var opts []func(*awsconfig.LoadOptions) error
+	if len(h.config.Regions) > 0 {
+		opts = append(opts, awsconfig.WithRegion(h.config.Regions[0]))
+	}
+	if h.config.Profile != "" {
+		opts = append(opts, awsconfig.WithSharedConfigProfile(h.config.Profile))
+	}
+	if h.config.AccessKeyID != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			h.config.AccessKeyID, h.config.SecretAccessKey, h.config.SessionToken,
+		)))
+	}
+	if h.config.Endpoint != "" {
+		opts = append(opts, awsconfig.WithBaseEndpoint(h.config.Endpoint))
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return awssdk.Config{}, fmt.Errorf("加载AWS配置失败: %v", err)
+	}
+
+	h.base = &cfg
+	return cfg, nil
+}
+
+// ListAccounts 列出需要同步的账号ID
+// 未配置角色时返回凭证所属账号，否则返回每个角色所在的账号
+func (h *AWSHelper) ListAccounts() ([]string, error) {
+	ctx := context.Background()
+	if len(h.config.AssumeRoleARNs) == 0 {
+		cfg, err := h.baseConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		if err != nil {
+			return nil, fmt.Errorf("获取调用者身份失败: %v", err)
+		}
+		accountID := awssdk.ToString(identity.Account)
+
+		h.mu.Lock()
+		h.accountRoles[accountID] = ""
+		h.mu.Unlock()
+		return []string{accountID}, nil
+	}
+
+	var accounts []string
+	for _, roleARN := range h.config.AssumeRoleARNs {
+		accountID, err := accountFromARN(roleARN)
+		if err != nil {
+			return nil, err
+		}
+
+		h.mu.Lock()
+		h.accountRoles[accountID] = roleARN
+		h.mu.Unlock()
+		accounts = append(accounts, accountID)
+	}
+	return accounts, nil
+}
+
+// ConfigFor 获取指定账号和区域的配置，需要时通过STS扮演该账号的角色
+// 扮演角色得到的凭证按账号缓存，各区域共用，过期前不再重复调用AssumeRole
+func (h *AWSHelper) ConfigFor(accountID, region string) (awssdk.Config, error) {
+	base, err := h.baseConfig(context.Background())
+	if err != nil {
+		return awssdk.Config{}, err
+	}
+	cfg := base.Copy()
+	cfg.Region = region
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	roleARN, ok := h.accountRoles[accountID]
+	if !ok {
+		return awssdk.Config{}, fmt.Errorf("未知的AWS账号: %s", accountID)
+	}
+	if roleARN == "" {
+		return cfg, nil
+	}
+
+	creds, ok := h.roleCreds[accountID]
+	if !ok {
+		// STS客户端使用基础配置的区域，与请求的区域无关
+		provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(base), roleARN, func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = roleSessionName
+			if h.config.ExternalID != "" {
+				o.ExternalID = awssdk.String(h.config.ExternalID)
+			}
+		})
+		creds = awssdk.NewCredentialsCache(provider)
+		h.roleCreds[accountID] = creds
+	}
+	cfg.Credentials = creds
+	return cfg, nil
+}
+
+// accountFromARN 从ARN中解析账号ID，格式为 arn:partition:service:region:account:resource
+func accountFromARN(arn string) (string, error) {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) < 6 || parts[0] != "arn" || parts[4] == "" {
+		return "", fmt.Errorf("无效的ARN: %s", arn)
+	}
+	return parts[4], nil
+}
+
+// resourceTypeFromARN 从ARN中解析资源类型，如 ec2:instance、rds:db、s3
+func resourceTypeFromARN(arn string) string {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) < 6 {
+		return ""
+	}
+	service, resource := parts[2], parts[5]
+	if i := strings.IndexAny(resource, "/:"); i > 0 {
+		return service + ":" + resource[:i]
+	}
+	return service
+}
+
+// resourceNameFromARN 从ARN中解析资源名称（最后一段）
+func resourceNameFromARN(arn string) string {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) < 6 {
+		return arn
+	}
+	resource := parts[5]
+	if i := strings.LastIndexAny(resource, "/:"); i >= 0 {
+		return resource[i+1:]
+	}
+	return resource
+}
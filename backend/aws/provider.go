@@ -0,0 +1,367 @@
+package aws
+
+import (
+	"CMDB/config"
+	"CMDB/model"
+	"CMDB/provider"
+	"context"
+	"fmt"
+	"strings"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	taggingtypes "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+)
+
+// ProviderName AWS平台名称
+const ProviderName = "aws"
+
+func init() {
+	provider.RegisterFactory(ProviderName, newProviders)
+}
+
+// newProviders 为每个配置的AWS账号创建Provider
+func newProviders(cfg *config.Config) ([]provider.Provider, error) {
+	var providers []provider.Provider
+	for _, account := range cfg.AWSAccounts {
+		providers = append(providers, NewAWSProvider(NewAWSHelper(account)))
+	}
+	return providers, nil
+}
+
+// AWSProvider AWS资源发现，同步EC2、RDS以及标签API可见的全部资源
+// 同一范围同步期间，EC2和RDS实例列表在发现资源、虚拟机、数据库之间共享
+type AWSProvider struct {
+	provider.ScopeCache
+	awsHelper *AWSHelper
+}
+
+// NewAWSProvider 创建新的AWS Provider
+func NewAWSProvider(awsHelper *AWSHelper) *AWSProvider {
+	return &AWSProvider{awsHelper: awsHelper}
+}
+
+// Name 平台名称
+func (p *AWSProvider) Name() string {
+	return ProviderName
+}
+
+// Account 账号名称
+func (p *AWSProvider) Account() string {
+	return p.awsHelper.AccountName()
+}
+
+// Scopes 需要同步的AWS账号，每个账号内再遍历配置的区域
+func (p *AWSProvider) Scopes() ([]provider.Scope, error) {
+	accounts, err := p.awsHelper.ListAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := make([]provider.Scope, 0, len(accounts))
+	for _, accountID := range accounts {
+		scopes = append(scopes, provider.Scope{ID: accountID})
+	}
+	return scopes, nil
+}
+
+// DiscoverVMs 发现账号下各区域的EC2实例，已终止的实例不计入
+func (p *AWSProvider) DiscoverVMs(scope provider.Scope) ([]*model.VM, error) {
+	ctx := context.Background()
+
+	var vms []*model.VM
+	for _, region := range p.awsHelper.Regions() {
+		instances, err := p.describeInstances(ctx, scope.ID, region)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, instance := range instances {
+			if isTerminated(instance) {
+				continue
+			}
+			vms = append(vms, newVM(instance, region, scope.ID))
+		}
+	}
+
+	return vms, nil
+}
+
+// DiscoverDatabases 发现账号下各区域的RDS和Aurora实例
+func (p *AWSProvider) DiscoverDatabases(scope provider.Scope) ([]*model.Database, error) {
+	ctx := context.Background()
+
+	var databases []*model.Database
+	for _, region := range p.awsHelper.Regions() {
+		instances, err := p.describeDBInstances(ctx, scope.ID, region)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, instance := range instances {
+			databases = append(databases, newDatabase(instance, region, scope.ID))
+		}
+	}
+
+	return databases, nil
+}
+
+// DiscoverResources 通过资源组标签API发现账号下各区域的资源
+// 标签API只返回打过标签的资源，因此额外合并EC2和RDS实例，保证虚拟机和数据库都有对应的资源记录
+// 已终止的EC2实例可能仍出现在标签API中，一并跳过
+func (p *AWSProvider) DiscoverResources(scope provider.Scope) ([]*model.Resource, error) {
+	ctx := context.Background()
+
+	var resources []*model.Resource
+	seen := make(map[string]bool)
+	add := func(resource *model.Resource) {
+		if seen[resource.ResourceID] {
+			return
+		}
+		seen[resource.ResourceID] = true
+		resources = append(resources, resource)
+	}
+
+	for _, region := range p.awsHelper.Regions() {
+		instances, err := p.describeInstances(ctx, scope.ID, region)
+		if err != nil {
+			return nil, err
+		}
+		for _, instance := range instances {
+			if isTerminated(instance) {
+				seen[instanceARN(region, scope.ID, awssdk.ToString(instance.InstanceId))] = true
+				continue
+			}
+			vm := newVM(instance, region, scope.ID)
+			add(newResource(vm.ResourceID, region, scope.ID, vm.Tags))
+		}
+
+		dbInstances, err := p.describeDBInstances(ctx, scope.ID, region)
+		if err != nil {
+			return nil, err
+		}
+		for _, instance := range dbInstances {
+			database := newDatabase(instance, region, scope.ID)
+			add(newResource(database.ResourceID, region, scope.ID, database.Tags))
+		}
+
+		mappings, err := p.getTaggedResources(ctx, scope.ID, region)
+		if err != nil {
+			return nil, err
+		}
+		for _, mapping := range mappings {
+			arn := awssdk.ToString(mapping.ResourceARN)
+			tags := convertTaggingTags(mapping.Tags)
+			add(newResource(arn, region, scope.ID, tags))
+		}
+	}
+
+	return resources, nil
+}
+
+// newVM 根据EC2实例构造虚拟机
+func newVM(instance ec2types.Instance, region, accountID string) *model.VM {
+	tags := convertEC2Tags(instance.Tags)
+	arn := instanceARN(region, accountID, awssdk.ToString(instance.InstanceId))
+
+	status := ""
+	if instance.State != nil {
+		status = string(instance.State.Name)
+	}
+
+	name := tags["Name"]
+	if name == "" {
+		name = awssdk.ToString(instance.InstanceId)
+	}
+
+	return &model.VM{
+		Provider:       ProviderName,
+		VMID:           arn,
+		ResourceID:     arn,
+		Name:           name,
+		Location:       region,
+		Type:           string(instance.InstanceType),
+		Status:         status,
+		Owner:          tags["owner"],
+		SubscriptionID: accountID,
+		Tags:           tags,
+	}
+}
+
+// newDatabase 根据RDS实例构造数据库
+func newDatabase(instance rdstypes.DBInstance, region, accountID string) *model.Database {
+	tags := convertRDSTags(instance.TagList)
+	arn := awssdk.ToString(instance.DBInstanceArn)
+
+	dbType := "RDS " + awssdk.ToString(instance.Engine)
+	server := ""
+	if instance.Endpoint != nil {
+		server = awssdk.ToString(instance.Endpoint.Address)
+	}
+	// Aurora实例归属于集群，以集群作为服务器
+	if instance.DBClusterIdentifier != nil {
+		dbType = "Aurora " + awssdk.ToString(instance.Engine)
+		server = awssdk.ToString(instance.DBClusterIdentifier)
+	}
+
+	return &model.Database{
+		Provider:       ProviderName,
+		DatabaseID:     arn,
+		ResourceID:     arn,
+		Name:           awssdk.ToString(instance.DBInstanceIdentifier),
+		Location:       region,
+		Server:         server,
+		DBType:         dbType,
+		Version:        awssdk.ToString(instance.EngineVersion),
+		Status:         awssdk.ToString(instance.DBInstanceStatus),
+		Owner:          tags["owner"],
+		SubscriptionID: accountID,
+		Tags:           tags,
+	}
+}
+
+// isTerminated EC2实例是否已终止，已终止的实例只在列表中保留一段时间
+func isTerminated(instance ec2types.Instance) bool {
+	return instance.State != nil && instance.State.Name == ec2types.InstanceStateNameTerminated
+}
+
+// describeInstances 列出区域内的全部EC2实例，包括已终止的实例；范围同步期间结果被缓存
+func (p *AWSProvider) describeInstances(ctx context.Context, accountID, region string) ([]ec2types.Instance, error) {
+	value, err := p.Load(accountID, "ec2:"+region, func() (interface{}, error) {
+		cfg, err := p.awsHelper.ConfigFor(accountID, region)
+		if err != nil {
+			return nil, err
+		}
+
+		var instances []ec2types.Instance
+		pager := ec2.NewDescribeInstancesPaginator(ec2.NewFromConfig(cfg), &ec2.DescribeInstancesInput{})
+		for pager.HasMorePages() {
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("列举EC2实例失败 (%s): %v", region, err)
+			}
+			for _, reservation := range page.Reservations {
+				instances = append(instances, reservation.Instances...)
+			}
+		}
+		return instances, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]ec2types.Instance), nil
+}
+
+// describeDBInstances 列出区域内的全部RDS实例；范围同步期间结果被缓存
+func (p *AWSProvider) describeDBInstances(ctx context.Context, accountID, region string) ([]rdstypes.DBInstance, error) {
+	value, err := p.Load(accountID, "rds:"+region, func() (interface{}, error) {
+		cfg, err := p.awsHelper.ConfigFor(accountID, region)
+		if err != nil {
+			return nil, err
+		}
+
+		var instances []rdstypes.DBInstance
+		pager := rds.NewDescribeDBInstancesPaginator(rds.NewFromConfig(cfg), &rds.DescribeDBInstancesInput{})
+		for pager.HasMorePages() {
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("列举RDS实例失败 (%s): %v", region, err)
+			}
+			instances = append(instances, page.DBInstances...)
+		}
+		return instances, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]rdstypes.DBInstance), nil
+}
+
+// getTaggedResources 通过资源组标签API列出区域内的资源
+func (p *AWSProvider) getTaggedResources(ctx context.Context, accountID, region string) ([]taggingtypes.ResourceTagMapping, error) {
+	cfg, err := p.awsHelper.ConfigFor(accountID, region)
+	if err != nil {
+		return nil, err
+	}
+
+	var mappings []taggingtypes.ResourceTagMapping
+	client := resourcegroupstaggingapi.NewFromConfig(cfg)
+	pager := resourcegroupstaggingapi.NewGetResourcesPaginator(client, &resourcegroupstaggingapi.GetResourcesInput{
+		ResourcesPerPage: awssdk.Int32(100),
+	})
+	for pager.HasMorePages() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("列举标签资源失败 (%s): %v", region, err)
+		}
+		mappings = append(mappings, page.ResourceTagMappingList...)
+	}
+	return mappings, nil
+}
+
+// newResource 根据ARN构造通用资源
+func newResource(arn, region, accountID string, tags map[string]string) *model.Resource {
+	name := tags["Name"]
+	if name == "" {
+		name = resourceNameFromARN(arn)
+	}
+
+	return &model.Resource{
+		Provider:       ProviderName,
+		ResourceID:     arn,
+		Name:           name,
+		Location:       region,
+		ResourceType:   resourceTypeFromARN(arn),
+		Owner:          tags["owner"],
+		SubscriptionID: accountID,
+		Tags:           tags,
+	}
+}
+
+// instanceARN 构造EC2实例的ARN
+func instanceARN(region, accountID, instanceID string) string {
+	return fmt.Sprintf("arn:%s:ec2:%s:%s:instance/%s", partitionForRegion(region), region, accountID, instanceID)
+}
+
+// partitionForRegion 根据区域判断AWS分区
+func partitionForRegion(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	default:
+		return "aws"
+	}
+}
+
+// 辅助函数：转换EC2标签
+func convertEC2Tags(tags []ec2types.Tag) map[string]string {
+	result := make(map[string]string)
+	for _, tag := range tags {
+		result[awssdk.ToString(tag.Key)] = awssdk.ToString(tag.Value)
+	}
+	return result
+}
+
+// 辅助函数：转换RDS标签
+func convertRDSTags(tags []rdstypes.Tag) map[string]string {
+	result := make(map[string]string)
+	for _, tag := range tags {
+		result[awssdk.ToString(tag.Key)] = awssdk.ToString(tag.Value)
+	}
+	return result
+}
+
+// 辅助函数：转换资源组标签API的标签
+func convertTaggingTags(tags []taggingtypes.Tag) map[string]string {
+	result := make(map[string]string)
+	for _, tag := range tags {
+		result[awssdk.ToString(tag.Key)] = awssdk.ToString(tag.Value)
+	}
+	return result
+}
@@ -0,0 +1,297 @@
+package aws
+
+import (
+	"CMDB/config"
+	"CMDB/model"
+	"CMDB/provider"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	testAccountID = "222222222222"
+	testRoleARN   = "arn:aws:iam::222222222222:role/cmdb-reader"
+	testRegion    = "us-east-1"
+)
+
+// mockAWS 模拟EC2、RDS、STS和资源组标签API，按Action或X-Amz-Target分发请求
+type mockAWS struct {
+	t *testing.T
+
+	mu          sync.Mutex
+	calls       map[string]int
+	credentials map[string][]string // Action -> 请求签名使用的AccessKeyID
+}
+
+func newMockAWS(t *testing.T) (*mockAWS, *httptest.Server) {
+	m := &mockAWS{t: t, calls: make(map[string]int), credentials: make(map[string][]string)}
+	server := httptest.NewServer(http.HandlerFunc(m.serve))
+	t.Cleanup(server.Close)
+	return m, server
+}
+
+func (m *mockAWS) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	action := ""
+	var form url.Values
+	if target := r.Header.Get("X-Amz-Target"); target != "" {
+		action = target[strings.LastIndex(target, ".")+1:]
+	} else {
+		form, _ = url.ParseQuery(string(body))
+		action = form.Get("Action")
+	}
+
+	m.mu.Lock()
+	m.calls[action]++
+	m.credentials[action] = append(m.credentials[action], accessKeyOf(r))
+	m.mu.Unlock()
+
+	switch action {
+	case "AssumeRole":
+		if form.Get("RoleArn") != testRoleARN {
+			m.t.Errorf("AssumeRole RoleArn = %s", form.Get("RoleArn"))
+		}
+		if form.Get("ExternalId") != "ext-1" {
+			m.t.Errorf("AssumeRole ExternalId = %s", form.Get("ExternalId"))
+		}
+		writeXML(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/"><AssumeRoleResult>
+<Credentials><AccessKeyId>ASSUMEDKEY</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>token</SessionToken><Expiration>2099-01-01T00:00:00Z</Expiration></Credentials>
+<AssumedRoleUser><Arn>arn:aws:sts::222222222222:assumed-role/cmdb-reader/cmdb-sync</Arn><AssumedRoleId>AROA:cmdb-sync</AssumedRoleId></AssumedRoleUser>
+</AssumeRoleResult><ResponseMetadata><RequestId>1</RequestId></ResponseMetadata></AssumeRoleResponse>`)
+	case "DescribeInstances":
+		if form.Get("NextToken") == "" {
+			writeXML(w, describeInstancesPage(`<item><instanceId>i-1</instanceId><instanceType>t3.micro</instanceType><instanceState><code>16</code><name>running</name></instanceState>
+<tagSet><item><key>Name</key><value>web-1</value></item><item><key>owner</key><value>alice</value></item></tagSet></item>`, "page-2"))
+			return
+		}
+		writeXML(w, describeInstancesPage(`<item><instanceId>i-2</instanceId><instanceType>t3.large</instanceType><instanceState><code>80</code><name>stopped</name></instanceState></item>
+<item><instanceId>i-3</instanceId><instanceType>t3.large</instanceType><instanceState><code>48</code><name>terminated</name></instanceState></item>`, ""))
+	case "DescribeDBInstances":
+		if form.Get("Marker") == "" {
+			writeXML(w, describeDBInstancesPage(`<DBInstance><DBInstanceIdentifier>orders</DBInstanceIdentifier><DBInstanceArn>arn:aws:rds:us-east-1:222222222222:db:orders</DBInstanceArn>
+<Engine>mysql</Engine><EngineVersion>8.0.35</EngineVersion><DBInstanceStatus>available</DBInstanceStatus><Endpoint><Address>orders.example.com</Address></Endpoint>
+<TagList><Tag><Key>owner</Key><Value>bob</Value></Tag></TagList></DBInstance>`, "marker-2"))
+			return
+		}
+		writeXML(w, describeDBInstancesPage(`<DBInstance><DBInstanceIdentifier>reports-1</DBInstanceIdentifier><DBInstanceArn>arn:aws:rds:us-east-1:222222222222:db:reports-1</DBInstanceArn>
+<Engine>aurora-postgresql</Engine><DBInstanceStatus>available</DBInstanceStatus><DBClusterIdentifier>reports</DBClusterIdentifier></DBInstance>`, ""))
+	case "GetResources":
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		fmt.Fprint(w, `{"ResourceTagMappingList": [
+{"ResourceARN": "arn:aws:s3:::logs-bucket", "Tags": [{"Key": "owner", "Value": "carol"}]},
+{"ResourceARN": "arn:aws:ec2:us-east-1:222222222222:instance/i-1", "Tags": [{"Key": "Name", "Value": "web-1"}]},
+{"ResourceARN": "arn:aws:ec2:us-east-1:222222222222:instance/i-3", "Tags": [{"Key": "Name", "Value": "gone"}]}
+], "PaginationToken": ""}`)
+	default:
+		m.t.Errorf("未预期的请求: %s %s", action, body)
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+func (m *mockAWS) count(action string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls[action]
+}
+
+// accessKeyOf 从SigV4签名中解析请求使用的AccessKeyID
+func accessKeyOf(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	i := strings.Index(auth, "Credential=")
+	if i < 0 {
+		return ""
+	}
+	credential := auth[i+len("Credential="):]
+	return credential[:strings.Index(credential, "/")]
+}
+
+func writeXML(w http.ResponseWriter, body string) {
+	w.Header().Set("Content-Type", "text/xml")
+	fmt.Fprint(w, body)
+}
+
+func describeInstancesPage(instances, nextToken string) string {
+	return `<DescribeInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/"><requestId>1</requestId>
+<reservationSet><item><reservationId>r-1</reservationId><instancesSet>` + instances + `</instancesSet></item></reservationSet>
+<nextToken>` + nextToken + `</nextToken></DescribeInstancesResponse>`
+}
+
+func describeDBInstancesPage(instances, marker string) string {
+	return `<DescribeDBInstancesResponse xmlns="http://rds.amazonaws.com/doc/2014-10-31/"><DescribeDBInstancesResult>
+<DBInstances>` + instances + `</DBInstances><Marker>` + marker + `</Marker>
+</DescribeDBInstancesResult><ResponseMetadata><RequestId>1</RequestId></ResponseMetadata></DescribeDBInstancesResponse>`
+}
+
+// newTestProvider 创建指向模拟端点（ENDPOINT_URL）并扮演角色的Provider
+func newTestProvider(t *testing.T, endpoint string) *AWSProvider {
+	t.Setenv("AWS_CONFIG_FILE", t.TempDir()+"/config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", t.TempDir()+"/credentials")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	return NewAWSProvider(NewAWSHelper(config.AWSConfig{
+		Name:            "test",
+		Regions:         []string{testRegion},
+		AccessKeyID:     "BASEKEY",
+		SecretAccessKey: "secret",
+		AssumeRoleARNs:  []string{testRoleARN},
+		ExternalID:      "ext-1",
+		Endpoint:        endpoint,
+	}))
+}
+
+func testScope(t *testing.T, p *AWSProvider) provider.Scope {
+	scopes, err := p.Scopes()
+	if err != nil {
+		t.Fatalf("Scopes: %v", err)
+	}
+	if len(scopes) != 1 || scopes[0].ID != testAccountID {
+		t.Fatalf("Scopes = %+v, 期望账号 %s", scopes, testAccountID)
+	}
+	return scopes[0]
+}
+
+func TestDiscoverVMsPagesAndSkipsTerminated(t *testing.T) {
+	m, server := newMockAWS(t)
+	p := newTestProvider(t, server.URL)
+
+	vms, err := p.DiscoverVMs(testScope(t, p))
+	if err != nil {
+		t.Fatalf("DiscoverVMs: %v", err)
+	}
+	if m.count("DescribeInstances") != 2 {
+		t.Errorf("DescribeInstances调用%d次，期望按分页调用2次", m.count("DescribeInstances"))
+	}
+
+	got := make(map[string]*model.VM)
+	for _, vm := range vms {
+		got[vm.VMID] = vm
+	}
+	if len(got) != 2 {
+		t.Fatalf("得到%d台虚拟机，期望2台（不含已终止实例）: %+v", len(got), vms)
+	}
+	web := got["arn:aws:ec2:us-east-1:222222222222:instance/i-1"]
+	if web == nil || web.Name != "web-1" || web.Owner != "alice" || web.Status != "running" || web.Type != "t3.micro" {
+		t.Errorf("i-1 = %+v", web)
+	}
+	stopped := got["arn:aws:ec2:us-east-1:222222222222:instance/i-2"]
+	if stopped == nil || stopped.Name != "i-2" || stopped.SubscriptionID != testAccountID {
+		t.Errorf("i-2 = %+v", stopped)
+	}
+}
+
+func TestDiscoverDatabasesPages(t *testing.T) {
+	m, server := newMockAWS(t)
+	p := newTestProvider(t, server.URL)
+
+	databases, err := p.DiscoverDatabases(testScope(t, p))
+	if err != nil {
+		t.Fatalf("DiscoverDatabases: %v", err)
+	}
+	if m.count("DescribeDBInstances") != 2 {
+		t.Errorf("DescribeDBInstances调用%d次，期望按分页调用2次", m.count("DescribeDBInstances"))
+	}
+	if len(databases) != 2 {
+		t.Fatalf("得到%d个数据库，期望2个: %+v", len(databases), databases)
+	}
+	orders, reports := databases[0], databases[1]
+	if orders.Name != "orders" || orders.DBType != "RDS mysql" || orders.Server != "orders.example.com" || orders.Owner != "bob" {
+		t.Errorf("orders = %+v", orders)
+	}
+	if reports.DBType != "Aurora aurora-postgresql" || reports.Server != "reports" {
+		t.Errorf("reports-1 = %+v", reports)
+	}
+}
+
+func TestDiscoverResourcesMergesTaggingAPI(t *testing.T) {
+	m, server := newMockAWS(t)
+	p := newTestProvider(t, server.URL)
+	scope := testScope(t, p)
+
+	p.BeginScope(scope)
+	resources, err := p.DiscoverResources(scope)
+	if err != nil {
+		t.Fatalf("DiscoverResources: %v", err)
+	}
+	if _, err := p.DiscoverVMs(scope); err != nil {
+		t.Fatalf("DiscoverVMs: %v", err)
+	}
+	if _, err := p.DiscoverDatabases(scope); err != nil {
+		t.Fatalf("DiscoverDatabases: %v", err)
+	}
+	p.EndScope(scope)
+
+	if m.count("DescribeInstances") != 2 || m.count("DescribeDBInstances") != 2 || m.count("GetResources") != 1 {
+		t.Errorf("同一范围内应只查询一次实例列表: DescribeInstances=%d DescribeDBInstances=%d GetResources=%d",
+			m.count("DescribeInstances"), m.count("DescribeDBInstances"), m.count("GetResources"))
+	}
+
+	got := make(map[string]*model.Resource)
+	for _, resource := range resources {
+		if got[resource.ResourceID] != nil {
+			t.Errorf("资源 %s 重复", resource.ResourceID)
+		}
+		got[resource.ResourceID] = resource
+	}
+	want := map[string]string{
+		"arn:aws:ec2:us-east-1:222222222222:instance/i-1": "ec2:instance",
+		"arn:aws:ec2:us-east-1:222222222222:instance/i-2": "ec2:instance",
+		"arn:aws:rds:us-east-1:222222222222:db:orders":    "rds:db",
+		"arn:aws:rds:us-east-1:222222222222:db:reports-1": "rds:db",
+		"arn:aws:s3:::logs-bucket":                        "s3",
+	}
+	if len(got) != len(want) {
+		t.Errorf("得到%d个资源，期望%d个: %v", len(got), len(want), got)
+	}
+	for arn, resourceType := range want {
+		resource := got[arn]
+		if resource == nil {
+			t.Errorf("缺少资源 %s", arn)
+			continue
+		}
+		if resource.ResourceType != resourceType {
+			t.Errorf("%s 的类型为 %s，期望 %s", arn, resource.ResourceType, resourceType)
+		}
+	}
+	if bucket := got["arn:aws:s3:::logs-bucket"]; bucket != nil && (bucket.Name != "logs-bucket" || bucket.Owner != "carol") {
+		t.Errorf("logs-bucket = %+v", bucket)
+	}
+	if web := got["arn:aws:ec2:us-east-1:222222222222:instance/i-1"]; web != nil && web.Owner != "alice" {
+		t.Errorf("i-1 应保留EC2实例的全部标签: %+v", web)
+	}
+}
+
+func TestAssumeRoleCredentialsUsed(t *testing.T) {
+	m, server := newMockAWS(t)
+	p := newTestProvider(t, server.URL)
+	// 扮演角色得到的凭证在各区域和各服务之间共用
+	p.awsHelper.config.Regions = []string{testRegion, "us-west-2"}
+
+	scope := testScope(t, p)
+	if _, err := p.DiscoverVMs(scope); err != nil {
+		t.Fatalf("DiscoverVMs: %v", err)
+	}
+	if _, err := p.DiscoverDatabases(scope); err != nil {
+		t.Fatalf("DiscoverDatabases: %v", err)
+	}
+	if m.count("DescribeInstances") < 2 {
+		t.Fatalf("DescribeInstances调用%d次，期望每个区域至少1次", m.count("DescribeInstances"))
+	}
+	if m.count("AssumeRole") != 1 {
+		t.Errorf("AssumeRole调用%d次，期望1次", m.count("AssumeRole"))
+	}
+	for _, key := range m.credentials["AssumeRole"] {
+		if key != "BASEKEY" {
+			t.Errorf("AssumeRole应使用基础凭证签名，实际为 %s", key)
+		}
+	}
+	for _, key := range m.credentials["DescribeInstances"] {
+		if key != "ASSUMEDKEY" {
+			t.Errorf("DescribeInstances应使用扮演角色得到的凭证签名，实际为 %s", key)
+		}
+	}
+}
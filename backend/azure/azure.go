@@ -1,16 +1,14 @@
 package azure
 
 import (
+	"CMDB/config"
 	"context"
 	"fmt"
-	"os"
 	"strings"
+	"sync"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
-	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v4"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/mysql/armmysqlflexibleservers"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
@@ -27,6 +25,8 @@ type Resource struct {
 	Owner    string            `json:"owner"`
 	Type     string            `json:"type"`
 	Tags     map[string]string `json:"tags"`
+	// SubscriptionID 资源所属订阅
+	SubscriptionID string `json:"subscription_id"`
 }
 
 // Azure虚拟机
@@ -38,6 +38,8 @@ type VMResource struct {
 	Type     string            `json:"type,omitempty"`
 	Status   string            `json:"status,omitempty"`
 	Tags     map[string]string `json:"tags"`
+	// SubscriptionID 虚拟机所属订阅
+	SubscriptionID string `json:"subscription_id"`
 }
 
 // Azure数据库
@@ -52,47 +54,75 @@ type DBResource struct {
 	Version  string            `json:"version,omitempty"`
 	Status   string            `json:"status,omitempty"`
 	Tags     map[string]string `json:"tags"`
+	// SubscriptionID 数据库所属订阅
+	SubscriptionID string `json:"subscription_id"`
 }
 
 // AzureHelper 封装Azure认证和资源获取功能
 type AzureHelper struct {
-	clientSecretCredential *azidentity.ClientSecretCredential
-	graphClient            *msgraphsdk.GraphServiceClient
-	subscriptionID         string
+	config      *config.AzureConfig
+	cloud       cloudEnvironment
+	credential  azcore.TokenCredential
+	graphClient *msgraphsdk.GraphServiceClient
+
+	mu                  sync.Mutex
+	credentials         map[string]azcore.TokenCredential // 租户ID -> 凭证
+	subscriptionTenants map[string]string                 // 订阅ID -> 租户ID
 }
 
-// NewAzureHelper 创建新的AzureHelper实例
+// NewAzureHelper 创建新的AzureHelper实例，配置在初始化时从环境变量读取
 func NewAzureHelper() *AzureHelper {
-	return &AzureHelper{}
+	return &AzureHelper{
+		credentials:         make(map[string]azcore.TokenCredential),
+		subscriptionTenants: make(map[string]string),
+	}
+}
+
+// NewAzureHelperWithConfig 使用指定配置创建AzureHelper实例
+func NewAzureHelperWithConfig(cfg config.AzureConfig) *AzureHelper {
+	a := NewAzureHelper()
+	a.config = &cfg
+	return a
 }
 
 // Initialize 初始化Azure认证
 func (a *AzureHelper) Initialize() error {
-	clientID := os.Getenv("CLIENT_ID")
-	tenantID := os.Getenv("TENANT_ID")
-	clientSecret := os.Getenv("CLIENT_SECRET")
-	a.subscriptionID = os.Getenv("SUBSCRIPTION_ID")
+	if a.config == nil {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("加载Azure配置失败: %v", err)
+		}
+		a.config = &cfg.AzureConfig
+	}
 
-	if clientID == "" || tenantID == "" || clientSecret == "" || a.subscriptionID == "" {
-		return fmt.Errorf("环境变量未设置: 请确保设置了CLIENT_ID, TENANT_ID, CLIENT_SECRET和SUBSCRIPTION_ID")
+	if err := a.config.Validate(); err != nil {
+		return err
+	}
+	if len(a.config.TenantIDs) == 0 {
+		a.config.TenantIDs = []string{a.config.TenantID}
 	}
 
-	credOpts := azidentity.ClientSecretCredentialOptions{
-		ClientOptions: azcore.ClientOptions{
-			Cloud: cloud.AzureChina,
-		},
+	cloudEnv, err := resolveCloud(a.config)
+	if err != nil {
+		return err
 	}
+	a.cloud = cloudEnv
 
-	credential, err := azidentity.NewClientSecretCredential(tenantID, clientID, clientSecret, &credOpts)
+	credential, err := a.credentialForTenant(a.config.TenantID)
 	if err != nil {
-		return fmt.Errorf("创建Azure凭证失败: %v", err)
+		return err
 	}
 
-	a.clientSecretCredential = credential
+	a.credential = credential
+
+	// 自定义云环境未配置Graph端点时不创建Graph客户端
+	if a.cloud.GraphEndpoint == "" {
+		return nil
+	}
 
 	// 创建认证提供者
-	authProvider, err := auth.NewAzureIdentityAuthenticationProviderWithScopes(a.clientSecretCredential, []string{
-		"https://management.chinacloudapi.cn/.default",
+	authProvider, err := auth.NewAzureIdentityAuthenticationProviderWithScopes(a.credential, []string{
+		a.cloud.GraphScope(),
 	})
 	if err != nil {
 		return fmt.Errorf("创建认证提供者失败: %v", err)
@@ -104,8 +134,8 @@ func (a *AzureHelper) Initialize() error {
 		return fmt.Errorf("创建请求适配器失败: %v", err)
 	}
 
-	// 设置中国云端点
-	adapter.SetBaseUrl("https://microsoftgraph.chinacloudapi.cn/v1.0")
+	// 设置所在云环境的Graph端点
+	adapter.SetBaseUrl(a.cloud.GraphBaseURL())
 
 	// 创建Graph客户端
 	a.graphClient = msgraphsdk.NewGraphServiceClient(adapter)
@@ -113,16 +143,72 @@ func (a *AzureHelper) Initialize() error {
 	return nil
 }
 
+// AccountName 账号名称
+func (a *AzureHelper) AccountName() string {
+	if a.config == nil {
+		return ""
+	}
+	return a.config.Name
+}
+
+// CloudName 账号所在的云环境
+func (a *AzureHelper) CloudName() string {
+	if a.config == nil {
+		return ""
+	}
+	return a.config.Cloud
+}
+
+// credentialForTenant 获取指定租户的凭证，同一应用在每个租户下各自签发令牌
+func (a *AzureHelper) credentialForTenant(tenantID string) (azcore.TokenCredential, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if credential, ok := a.credentials[tenantID]; ok {
+		return credential, nil
+	}
+	// 托管标识只属于所在租户，所有租户共用同一个凭证
+	if a.config.AuthMethod == config.AzureAuthManagedIdentity && a.credential != nil {
+		return a.credential, nil
+	}
+
+	credential, err := a.newCredential(tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("创建Azure凭证失败 (租户 %s): %v", tenantID, err)
+	}
+
+	a.credentials[tenantID] = credential
+	return credential, nil
+}
+
+// credentialForSubscription 获取订阅所在租户的凭证
+func (a *AzureHelper) credentialForSubscription(subscriptionID string) (azcore.TokenCredential, error) {
+	if a.credential == nil {
+		if err := a.Initialize(); err != nil {
+			return nil, err
+		}
+	}
+
+	a.mu.Lock()
+	tenantID, ok := a.subscriptionTenants[subscriptionID]
+	a.mu.Unlock()
+	if !ok || tenantID == "" {
+		tenantID = a.config.TenantID
+	}
+
+	return a.credentialForTenant(tenantID)
+}
+
 // GetToken 获取Azure访问令牌（用于调试）
 func (a *AzureHelper) GetToken() (string, error) {
-	if a.clientSecretCredential == nil {
+	if a.credential == nil {
 		if err := a.Initialize(); err != nil {
 			return "", err
 		}
 	}
 
-	token, err := a.clientSecretCredential.GetToken(context.Background(), policy.TokenRequestOptions{
-		Scopes: []string{"https://management.chinacloudapi.cn/.default"},
+	token, err := a.credential.GetToken(context.Background(), policy.TokenRequestOptions{
+		Scopes: []string{a.cloud.ManagementScope()},
 	})
 	if err != nil {
 		return "", fmt.Errorf("获取访问令牌失败: %v", err)
@@ -131,20 +217,15 @@ func (a *AzureHelper) GetToken() (string, error) {
 	return token.Token, nil
 }
 
-// GetResources 获取Azure资源列表
-func (a *AzureHelper) GetResources() ([]Resource, error) {
-	if a.clientSecretCredential == nil {
-		if err := a.Initialize(); err != nil {
-			return nil, err
-		}
+// GetResources 获取指定订阅下的Azure资源列表
+func (a *AzureHelper) GetResources(subscriptionID string) ([]Resource, error) {
+	credential, err := a.credentialForSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
 	}
 
 	// 创建资源客户端工厂
-	clientFactory, err := armresources.NewClientFactory(a.subscriptionID, a.clientSecretCredential, &arm.ClientOptions{
-		ClientOptions: azcore.ClientOptions{
-			Cloud: cloud.AzureChina,
-		},
-	})
+	clientFactory, err := armresources.NewClientFactory(subscriptionID, credential, a.armClientOptions())
 	if err != nil {
 		return nil, fmt.Errorf("创建资源客户端工厂失败: %v", err)
 	}
@@ -174,6 +255,8 @@ func (a *AzureHelper) GetResources() ([]Resource, error) {
 				Owner:    owner,
 				Type:     *item.Type,
 				Tags:     make(map[string]string),
+
+				SubscriptionID: subscriptionID,
 			}
 
 			if item.Tags != nil {
@@ -191,20 +274,15 @@ func (a *AzureHelper) GetResources() ([]Resource, error) {
 	return resources, nil
 }
 
-// GetVirtualMachines 获取Azure虚拟机资源列表
-func (a *AzureHelper) GetVirtualMachines() ([]VMResource, error) {
-	if a.clientSecretCredential == nil {
-		if err := a.Initialize(); err != nil {
-			return nil, err
-		}
+// GetVirtualMachines 获取指定订阅下的Azure虚拟机资源列表
+func (a *AzureHelper) GetVirtualMachines(subscriptionID string) ([]VMResource, error) {
+	credential, err := a.credentialForSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
 	}
 
 	// 创建计算客户端工厂
-	clientFactory, err := armcompute.NewClientFactory(a.subscriptionID, a.clientSecretCredential, &arm.ClientOptions{
-		ClientOptions: azcore.ClientOptions{
-			Cloud: cloud.AzureChina,
-		},
-	})
+	clientFactory, err := armcompute.NewClientFactory(subscriptionID, credential, a.armClientOptions())
 	if err != nil {
 		return nil, fmt.Errorf("创建计算客户端工厂失败: %v", err)
 	}
@@ -246,6 +324,8 @@ func (a *AzureHelper) GetVirtualMachines() ([]VMResource, error) {
 				Type:     osType,
 				Status:   status,
 				Tags:     make(map[string]string),
+
+				SubscriptionID: subscriptionID,
 			}
 
 			if vm.Tags != nil {
@@ -263,19 +343,18 @@ func (a *AzureHelper) GetVirtualMachines() ([]VMResource, error) {
 	return vms, nil
 }
 
-// GetSQLDatabases 获取Azure SQL数据库资源列表
-func (a *AzureHelper) GetSQLDatabases() ([]DBResource, error) {
-	if a.clientSecretCredential == nil {
-		if err := a.Initialize(); err != nil {
-			return nil, err
-		}
+// GetSQLDatabases 获取指定订阅下的Azure SQL数据库资源列表
+func (a *AzureHelper) GetSQLDatabases(subscriptionID string) ([]DBResource, error) {
+	credential, err := a.credentialForSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
 	}
 
 	// 创建SQL客户端工厂
 	clientFactory, err := armsql.NewClientFactory(
-		a.subscriptionID,
-		a.clientSecretCredential,
-		&arm.ClientOptions{ClientOptions: azcore.ClientOptions{Cloud: cloud.AzureChina}},
+		subscriptionID,
+		credential,
+		a.armClientOptions(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("创建SQL客户端工厂失败: %v", err)
@@ -329,6 +408,8 @@ func (a *AzureHelper) GetSQLDatabases() ([]DBResource, error) {
 						DBType:   "SQL Database",
 						Status:   status,
 						Tags:     convertTags(db.Tags),
+
+						SubscriptionID: subscriptionID,
 					})
 				}
 			}
@@ -338,19 +419,18 @@ func (a *AzureHelper) GetSQLDatabases() ([]DBResource, error) {
 	return sqlDatabases, nil
 }
 
-// GetMySQLFlexibleServers 获取Azure MySQL灵活服务器资源列表
-func (a *AzureHelper) GetMySQLFlexibleServers() ([]DBResource, error) {
-	if a.clientSecretCredential == nil {
-		if err := a.Initialize(); err != nil {
-			return nil, err
-		}
+// GetMySQLFlexibleServers 获取指定订阅下的Azure MySQL灵活服务器资源列表
+func (a *AzureHelper) GetMySQLFlexibleServers(subscriptionID string) ([]DBResource, error) {
+	credential, err := a.credentialForSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
 	}
 
 	// 创建MySQL灵活服务器客户端工厂
 	clientFactory, err := armmysqlflexibleservers.NewClientFactory(
-		a.subscriptionID,
-		a.clientSecretCredential,
-		&arm.ClientOptions{ClientOptions: azcore.ClientOptions{Cloud: cloud.AzureChina}},
+		subscriptionID,
+		credential,
+		a.armClientOptions(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("创建MySQL灵活服务器客户端工厂失败: %v", err)
@@ -391,6 +471,8 @@ func (a *AzureHelper) GetMySQLFlexibleServers() ([]DBResource, error) {
 				Version:  version,
 				Status:   status,
 				Tags:     convertTags(srv.Tags),
+
+				SubscriptionID: subscriptionID,
 			})
 		}
 	}
@@ -398,19 +480,18 @@ func (a *AzureHelper) GetMySQLFlexibleServers() ([]DBResource, error) {
 	return mysqlServers, nil
 }
 
-// GetSQLServers 获取Azure SQL服务器资源列表
-func (a *AzureHelper) GetSQLServers() ([]DBResource, error) {
-	if a.clientSecretCredential == nil {
-		if err := a.Initialize(); err != nil {
-			return nil, err
-		}
+// GetSQLServers 获取指定订阅下的Azure SQL服务器资源列表
+func (a *AzureHelper) GetSQLServers(subscriptionID string) ([]DBResource, error) {
+	credential, err := a.credentialForSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
 	}
 
 	// 创建SQL客户端工厂
 	clientFactory, err := armsql.NewClientFactory(
-		a.subscriptionID,
-		a.clientSecretCredential,
-		&arm.ClientOptions{ClientOptions: azcore.ClientOptions{Cloud: cloud.AzureChina}},
+		subscriptionID,
+		credential,
+		a.armClientOptions(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("创建SQL客户端工厂失败: %v", err)
@@ -446,6 +527,8 @@ func (a *AzureHelper) GetSQLServers() ([]DBResource, error) {
 				Version:  version,
 				Status:   "Running",
 				Tags:     convertTags(srv.Tags),
+
+				SubscriptionID: subscriptionID,
 			})
 		}
 	}
@@ -464,30 +547,51 @@ func convertTags(tags map[string]*string) map[string]string {
 	return result
 }
 
+// collectAllSubscriptions 对所有需要同步的订阅执行查询并合并结果
+func collectAllSubscriptions[T any](a *AzureHelper, fetch func(subscriptionID string) ([]T, error)) ([]T, error) {
+	subs, err := a.ListSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	var items []T
+	for _, sub := range subs {
+		if sub.Err != nil {
+			return nil, sub.Err
+		}
+		subItems, err := fetch(sub.SubscriptionID)
+		if err != nil {
+			return nil, fmt.Errorf("订阅 %s: %v", sub.SubscriptionID, err)
+		}
+		items = append(items, subItems...)
+	}
+	return items, nil
+}
+
 // 为了向后兼容，添加全局函数
 func GetAzureSQLDatabases() ([]DBResource, error) {
 	azHelper := NewAzureHelper()
-	return azHelper.GetSQLDatabases()
+	return collectAllSubscriptions(azHelper, azHelper.GetSQLDatabases)
 }
 
 func GetAzureMySQLFlexibleServers() ([]DBResource, error) {
 	azHelper := NewAzureHelper()
-	return azHelper.GetMySQLFlexibleServers()
+	return collectAllSubscriptions(azHelper, azHelper.GetMySQLFlexibleServers)
 }
 
 func GetAzureSQLServers() ([]DBResource, error) {
 	azHelper := NewAzureHelper()
-	return azHelper.GetSQLServers()
+	return collectAllSubscriptions(azHelper, azHelper.GetSQLServers)
 }
 
-// GetDatabases 获取Azure数据库资源列表（向后兼容方法）
-func (a *AzureHelper) GetDatabases() ([]DBResource, error) {
-	sqlDatabases, err := a.GetSQLDatabases()
+// GetDatabases 获取指定订阅下的Azure数据库资源列表（向后兼容方法）
+func (a *AzureHelper) GetDatabases(subscriptionID string) ([]DBResource, error) {
+	sqlDatabases, err := a.GetSQLDatabases(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 
-	mysqlServers, err := a.GetMySQLFlexibleServers()
+	mysqlServers, err := a.GetMySQLFlexibleServers(subscriptionID)
 	if err != nil {
 		return nil, err
 	}
@@ -499,7 +603,7 @@ func (a *AzureHelper) GetDatabases() ([]DBResource, error) {
 // GetAzureResources 获取Azure资源列表（向后兼容函数）
 func GetAzureResources() ([]Resource, error) {
 	azHelper := NewAzureHelper()
-	return azHelper.GetResources()
+	return collectAllSubscriptions(azHelper, azHelper.GetResources)
 }
 
 // GetToken 获取Azure访问令牌（向后兼容函数）
@@ -511,10 +615,10 @@ func GetToken() (string, error) {
 // GetAzureVirtualMachines 获取Azure虚拟机资源列表（向后兼容函数）
 func GetAzureVirtualMachines() ([]VMResource, error) {
 	azHelper := NewAzureHelper()
-	return azHelper.GetVirtualMachines()
+	return collectAllSubscriptions(azHelper, azHelper.GetVirtualMachines)
 }
 
 func GetAzureDatabases() ([]DBResource, error) {
 	azHelper := NewAzureHelper()
-	return azHelper.GetDatabases()
+	return collectAllSubscriptions(azHelper, azHelper.GetDatabases)
 }
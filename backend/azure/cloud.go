@@ -0,0 +1,101 @@
+package azure
+
+import (
+	"CMDB/config"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+)
+
+// cloudEnvironment 描述一个Azure云环境的认证、ARM和Graph端点
+type cloudEnvironment struct {
+	Name          string
+	Configuration cloud.Configuration
+	GraphEndpoint string
+}
+
+// 内置云环境
+var builtinClouds = map[string]cloudEnvironment{
+	config.AzureCloudPublic: {
+		Name:          config.AzureCloudPublic,
+		Configuration: cloud.AzurePublic,
+		GraphEndpoint: "https://graph.microsoft.com",
+	},
+	config.AzureCloudChina: {
+		Name:          config.AzureCloudChina,
+		Configuration: cloud.AzureChina,
+		GraphEndpoint: "https://microsoftgraph.chinacloudapi.cn",
+	},
+	config.AzureCloudUSGov: {
+		Name:          config.AzureCloudUSGov,
+		Configuration: cloud.AzureGovernment,
+		GraphEndpoint: "https://graph.microsoft.us",
+	},
+}
+
+// resolveCloud 根据账号配置解析云环境
+func resolveCloud(cfg *config.AzureConfig) (cloudEnvironment, error) {
+	name := strings.ToLower(cfg.Cloud)
+	if name == "" {
+		name = config.AzureCloudChina
+	}
+
+	if env, ok := builtinClouds[name]; ok {
+		return env, nil
+	}
+	if name != config.AzureCloudCustom {
+		return cloudEnvironment{}, fmt.Errorf("不支持的Azure云环境: %s", cfg.Cloud)
+	}
+
+	if cfg.AuthorityHost == "" || cfg.ResourceManagerEndpoint == "" {
+		return cloudEnvironment{}, fmt.Errorf("自定义云环境缺少认证端点或ARM端点")
+	}
+	audience := cfg.ResourceManagerAudience
+	if audience == "" {
+		audience = cfg.ResourceManagerEndpoint
+	}
+	return cloudEnvironment{
+		Name: config.AzureCloudCustom,
+		Configuration: cloud.Configuration{
+			ActiveDirectoryAuthorityHost: cfg.AuthorityHost,
+			Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+				cloud.ResourceManager: {
+					Audience: audience,
+					Endpoint: cfg.ResourceManagerEndpoint,
+				},
+			},
+		},
+		GraphEndpoint: strings.TrimSuffix(cfg.GraphEndpoint, "/"),
+	}, nil
+}
+
+// ManagementScope ARM访问令牌的作用域
+func (e cloudEnvironment) ManagementScope() string {
+	audience := e.Configuration.Services[cloud.ResourceManager].Audience
+	return strings.TrimSuffix(audience, "/") + "/.default"
+}
+
+// GraphScope Graph访问令牌的作用域
+func (e cloudEnvironment) GraphScope() string {
+	return e.GraphEndpoint + "/.default"
+}
+
+// GraphBaseURL Graph v1.0接口的基础地址
+func (e cloudEnvironment) GraphBaseURL() string {
+	return e.GraphEndpoint + "/v1.0"
+}
+
+// clientOptions 当前账号云环境下的客户端选项
+func (a *AzureHelper) clientOptions() azcore.ClientOptions {
+	return azcore.ClientOptions{
+		Cloud: a.cloud.Configuration,
+	}
+}
+
+// armClientOptions 当前账号云环境下的ARM客户端选项
+func (a *AzureHelper) armClientOptions() *arm.ClientOptions {
+	return &arm.ClientOptions{ClientOptions: a.clientOptions()}
+}
@@ -0,0 +1,213 @@
+package azure
+
+import (
+	"CMDB/config"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// CredentialInfo 凭证诊断信息，不包含任何密钥内容
+type CredentialInfo struct {
+	Account                 string   `json:"account"`
+	Cloud                   string   `json:"cloud"`
+	AuthorityHost           string   `json:"authority_host"`
+	AuthMethod              string   `json:"auth_method"`
+	ClientID                string   `json:"client_id,omitempty"`
+	TenantIDs               []string `json:"tenant_ids,omitempty"`
+	SecretSource            string   `json:"secret_source,omitempty"`
+	CertificatePath         string   `json:"certificate_path,omitempty"`
+	ManagedIdentityClientID string   `json:"managed_identity_client_id,omitempty"`
+	FederatedTokenFile      string   `json:"federated_token_file,omitempty"`
+
+	// 以下字段来自实际获取的ARM访问令牌
+	TokenAcquired  bool           `json:"token_acquired"`
+	TokenExpiresOn time.Time      `json:"token_expires_on,omitempty"`
+	Identity       *TokenIdentity `json:"identity,omitempty"`
+	Error          string         `json:"error,omitempty"`
+}
+
+// TokenIdentity 访问令牌中声明的调用身份
+type TokenIdentity struct {
+	TenantID     string `json:"tenant_id,omitempty"`
+	ObjectID     string `json:"object_id,omitempty"`
+	AppID        string `json:"app_id,omitempty"`
+	UPN          string `json:"upn,omitempty"`
+	IdentityType string `json:"identity_type,omitempty"`
+}
+
+// newCredential 按配置的认证方式为指定租户创建凭证
+func (a *AzureHelper) newCredential(tenantID string) (azcore.TokenCredential, error) {
+	cfg := a.config
+	switch cfg.AuthMethod {
+	case "", config.AzureAuthClientSecret:
+		secret, err := readSecret(cfg.ClientSecret, cfg.ClientSecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取客户端密码失败: %v", err)
+		}
+		return azidentity.NewClientSecretCredential(tenantID, cfg.ClientID, secret, &azidentity.ClientSecretCredentialOptions{
+			ClientOptions: a.clientOptions(),
+		})
+
+	case config.AzureAuthClientCertificate:
+		certData, err := os.ReadFile(cfg.ClientCertificatePath)
+		if err != nil {
+			return nil, fmt.Errorf("读取客户端证书失败: %v", err)
+		}
+		password, err := readSecret(cfg.ClientCertificatePassword, cfg.ClientCertificatePasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取证书密码失败: %v", err)
+		}
+		var passwordBytes []byte
+		if password != "" {
+			passwordBytes = []byte(password)
+		}
+		certs, key, err := azidentity.ParseCertificates(certData, passwordBytes)
+		if err != nil {
+			return nil, fmt.Errorf("解析客户端证书失败: %v", err)
+		}
+		return azidentity.NewClientCertificateCredential(tenantID, cfg.ClientID, certs, key, &azidentity.ClientCertificateCredentialOptions{
+			ClientOptions:        a.clientOptions(),
+			SendCertificateChain: true,
+		})
+
+	case config.AzureAuthManagedIdentity:
+		// 托管标识始终属于所在租户，各租户共用同一个凭证
+		opts := &azidentity.ManagedIdentityCredentialOptions{
+			ClientOptions: a.clientOptions(),
+		}
+		if cfg.ManagedIdentityClientID != "" {
+			opts.ID = azidentity.ClientID(cfg.ManagedIdentityClientID)
+		}
+		return azidentity.NewManagedIdentityCredential(opts)
+
+	case config.AzureAuthWorkloadIdentity:
+		return azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{
+			ClientOptions: a.clientOptions(),
+			ClientID:      cfg.ClientID,
+			TenantID:      tenantID,
+			TokenFilePath: cfg.FederatedTokenFile,
+		})
+
+	case config.AzureAuthCLI:
+		// 本地开发使用 az login 的登录身份
+		return azidentity.NewAzureCLICredential(&azidentity.AzureCLICredentialOptions{
+			TenantID: tenantID,
+		})
+
+	default:
+		return nil, fmt.Errorf("不支持的认证方式: %s", cfg.AuthMethod)
+	}
+}
+
+// readSecret 读取密钥，配置了文件时优先从文件读取
+func readSecret(value, file string) (string, error) {
+	if file == "" {
+		return value, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// CredentialInfo 获取当前账号的凭证诊断信息，并尝试获取ARM访问令牌以确认调用身份
+func (a *AzureHelper) CredentialInfo() CredentialInfo {
+	var initErr error
+	if a.credential == nil {
+		initErr = a.Initialize()
+	}
+	if a.config == nil {
+		return CredentialInfo{Error: initErr.Error()}
+	}
+
+	cfg := a.config
+	info := CredentialInfo{
+		Account:       cfg.Name,
+		Cloud:         cfg.Cloud,
+		AuthorityHost: a.cloud.Configuration.ActiveDirectoryAuthorityHost,
+		AuthMethod:    cfg.AuthMethod,
+	}
+
+	switch cfg.AuthMethod {
+	case config.AzureAuthClientSecret:
+		info.ClientID = cfg.ClientID
+		info.TenantIDs = cfg.TenantIDs
+		info.SecretSource = "env"
+		if cfg.ClientSecretFile != "" {
+			info.SecretSource = "file:" + cfg.ClientSecretFile
+		}
+	case config.AzureAuthClientCertificate:
+		info.ClientID = cfg.ClientID
+		info.TenantIDs = cfg.TenantIDs
+		info.CertificatePath = cfg.ClientCertificatePath
+	case config.AzureAuthManagedIdentity:
+		info.ManagedIdentityClientID = cfg.ManagedIdentityClientID
+	case config.AzureAuthWorkloadIdentity:
+		info.ClientID = cfg.ClientID
+		info.TenantIDs = cfg.TenantIDs
+		info.FederatedTokenFile = cfg.FederatedTokenFile
+	case config.AzureAuthCLI:
+		info.TenantIDs = cfg.TenantIDs
+	}
+
+	if initErr != nil {
+		info.Error = initErr.Error()
+		return info
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	token, err := a.credential.GetToken(ctx, policy.TokenRequestOptions{
+		Scopes: []string{a.cloud.ManagementScope()},
+	})
+	if err != nil {
+		info.Error = fmt.Sprintf("获取访问令牌失败: %v", err)
+		return info
+	}
+
+	info.TokenAcquired = true
+	info.TokenExpiresOn = token.ExpiresOn
+	info.Identity = parseTokenIdentity(token.Token)
+	return info
+}
+
+// parseTokenIdentity 从JWT访问令牌的载荷中解析调用身份，仅用于诊断展示，不校验签名
+func parseTokenIdentity(token string) *TokenIdentity {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil
+	}
+
+	var claims struct {
+		TenantID     string `json:"tid"`
+		ObjectID     string `json:"oid"`
+		AppID        string `json:"appid"`
+		UPN          string `json:"upn"`
+		IdentityType string `json:"idtyp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil
+	}
+
+	return &TokenIdentity{
+		TenantID:     claims.TenantID,
+		ObjectID:     claims.ObjectID,
+		AppID:        claims.AppID,
+		UPN:          claims.UPN,
+		IdentityType: claims.IdentityType,
+	}
+}
@@ -0,0 +1,143 @@
+package azure
+
+import (
+	"CMDB/model"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	graphgroups "github.com/microsoftgraph/msgraph-sdk-go/groups"
+	graphmodels "github.com/microsoftgraph/msgraph-sdk-go/models"
+	"github.com/microsoftgraph/msgraph-sdk-go/models/odataerrors"
+	graphusers "github.com/microsoftgraph/msgraph-sdk-go/users"
+)
+
+// objectIDPattern Entra ID对象ID（GUID）
+var objectIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// 查询用户和组时返回的字段
+var (
+	userSelect  = []string{"id", "displayName", "userPrincipalName", "mail", "department", "accountEnabled"}
+	groupSelect = []string{"id", "displayName", "mail"}
+)
+
+// isDirectoryIdentifier 判断所有者取值是否为可在目录中查找的UPN、邮箱或对象ID
+func isDirectoryIdentifier(value string) bool {
+	return strings.Contains(value, "@") || objectIDPattern.MatchString(value)
+}
+
+// ResolveOwner 通过Microsoft Graph将所有者解析为Entra ID用户或组，目录中不存在时返回nil
+// 取值不是UPN、邮箱或对象ID时不查询目录，返回状态为unresolvable的结果
+// 对象ID依次按用户和组查找；UPN或邮箱先按UPN查找用户，再按mail查找用户和组
+// 需要应用具有 User.Read.All 和 Group.Read.All（或 Directory.Read.All）权限
+func (a *AzureHelper) ResolveOwner(value string) (*model.OwnerInfo, error) {
+	if a.graphClient == nil {
+		return nil, fmt.Errorf("账号 %s 未配置Graph端点", a.AccountName())
+	}
+	if !isDirectoryIdentifier(value) {
+		return &model.OwnerInfo{Value: value, Status: model.OwnerStatusUnresolvable}, nil
+	}
+
+	ctx := context.Background()
+	user, err := a.graphClient.Users().ByUserId(value).Get(ctx, &graphusers.UserItemRequestBuilderGetRequestConfiguration{
+		QueryParameters: &graphusers.UserItemRequestBuilderGetQueryParameters{Select: userSelect},
+	})
+	if err == nil {
+		return a.userOwner(ctx, value, user), nil
+	}
+	if !isNotFound(err) {
+		return nil, fmt.Errorf("查询用户 %s 失败: %v", value, err)
+	}
+
+	if objectIDPattern.MatchString(value) {
+		group, err := a.graphClient.Groups().ByGroupId(value).Get(ctx, &graphgroups.GroupItemRequestBuilderGetRequestConfiguration{
+			QueryParameters: &graphgroups.GroupItemRequestBuilderGetQueryParameters{Select: groupSelect},
+		})
+		if err == nil {
+			return groupOwner(value, group), nil
+		}
+		if !isNotFound(err) {
+			return nil, fmt.Errorf("查询组 %s 失败: %v", value, err)
+		}
+		return nil, nil
+	}
+
+	filter := fmt.Sprintf("mail eq '%s'", strings.ReplaceAll(value, "'", "''"))
+	users, err := a.graphClient.Users().Get(ctx, &graphusers.UsersRequestBuilderGetRequestConfiguration{
+		QueryParameters: &graphusers.UsersRequestBuilderGetQueryParameters{Filter: &filter, Select: userSelect},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("按邮箱查询用户 %s 失败: %v", value, err)
+	}
+	if found := users.GetValue(); len(found) > 0 {
+		return a.userOwner(ctx, value, found[0]), nil
+	}
+
+	groups, err := a.graphClient.Groups().Get(ctx, &graphgroups.GroupsRequestBuilderGetRequestConfiguration{
+		QueryParameters: &graphgroups.GroupsRequestBuilderGetQueryParameters{Filter: &filter, Select: groupSelect},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("按邮箱查询组 %s 失败: %v", value, err)
+	}
+	if found := groups.GetValue(); len(found) > 0 {
+		return groupOwner(value, found[0]), nil
+	}
+	return nil, nil
+}
+
+// userOwner 将Graph用户转换为所有者信息，并查询其经理；经理查询失败不影响结果
+func (a *AzureHelper) userOwner(ctx context.Context, value string, user graphmodels.Userable) *model.OwnerInfo {
+	owner := &model.OwnerInfo{
+		Value:             value,
+		Kind:              model.OwnerKindUser,
+		Status:            model.OwnerStatusActive,
+		ObjectID:          deref(user.GetId()),
+		DisplayName:       deref(user.GetDisplayName()),
+		UserPrincipalName: deref(user.GetUserPrincipalName()),
+		Mail:              deref(user.GetMail()),
+		Department:        deref(user.GetDepartment()),
+	}
+	if enabled := user.GetAccountEnabled(); enabled != nil && !*enabled {
+		owner.Status = model.OwnerStatusDisabled
+	}
+
+	if owner.ObjectID != "" {
+		manager, err := a.graphClient.Users().ByUserId(owner.ObjectID).Manager().Get(ctx, nil)
+		if err == nil && manager != nil {
+			owner.ManagerID = deref(manager.GetId())
+			if managerUser, ok := manager.(graphmodels.Userable); ok {
+				owner.ManagerName = deref(managerUser.GetDisplayName())
+			}
+		}
+	}
+	return owner
+}
+
+// groupOwner 将Graph组转换为所有者信息，组没有启用状态，总是视为有效
+func groupOwner(value string, group graphmodels.Groupable) *model.OwnerInfo {
+	return &model.OwnerInfo{
+		Value:       value,
+		Kind:        model.OwnerKindGroup,
+		Status:      model.OwnerStatusActive,
+		ObjectID:    deref(group.GetId()),
+		DisplayName: deref(group.GetDisplayName()),
+		Mail:        deref(group.GetMail()),
+	}
+}
+
+// isNotFound 判断Graph请求是否因对象不存在而失败
+func isNotFound(err error) bool {
+	var odataErr *odataerrors.ODataError
+	return errors.As(err, &odataErr) && odataErr.ResponseStatusCode == http.StatusNotFound
+}
+
+// deref 取字符串指针的值，nil时返回空字符串
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,224 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization/v2"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/monitor/armmonitor"
+)
+
+// 用于推断所有者的内置角色定义ID，排在前面的角色优先
+var ownerRoleDefinitions = []string{
+	"8e3af657-a8ff-443c-a75c-2fe8c4bcb635", // Owner
+	"b24988ac-6180-42a0-ab88-20f7382dd24c", // Contributor
+}
+
+// activityLogWindow 查询活动日志的时间范围，活动日志只保留90天
+const activityLogWindow = 89 * 24 * time.Hour
+
+// roleAssignmentCandidate 可作为所有者的角色分配
+type roleAssignmentCandidate struct {
+	principalID string
+	roleRank    int
+	typeRank    int
+	createdOn   time.Time
+}
+
+// GetRoleAssignmentOwners 按Owner/Contributor角色分配推断资源所有者，返回资源ID到主体对象ID的映射
+// 优先使用资源本身上的分配，没有时使用其资源组上的分配；订阅及以上的分配过于宽泛，不作为所有者
+// 同一作用域有多个分配时依次按角色（Owner优先）、主体类型（用户、组、其他）和分配时间选择
+func (a *AzureHelper) GetRoleAssignmentOwners(subscriptionID string, resourceIDs []string) (map[string]string, error) {
+	credential, err := a.credentialForSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := armauthorization.NewRoleAssignmentsClient(subscriptionID, credential, a.armClientOptions())
+	if err != nil {
+		return nil, fmt.Errorf("创建角色分配客户端失败: %v", err)
+	}
+
+	// 按作用域（小写）归集可作为所有者的角色分配
+	candidates := make(map[string][]roleAssignmentCandidate)
+	pager := client.NewListForSubscriptionPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("获取角色分配失败: %v", err)
+		}
+		for _, assignment := range page.Value {
+			props := assignment.Properties
+			if props == nil || props.Scope == nil || props.PrincipalID == nil || props.RoleDefinitionID == nil {
+				continue
+			}
+			roleRank := ownerRoleRank(*props.RoleDefinitionID)
+			if roleRank < 0 {
+				continue
+			}
+			candidate := roleAssignmentCandidate{
+				principalID: *props.PrincipalID,
+				roleRank:    roleRank,
+				typeRank:    principalTypeRank(props.PrincipalType),
+			}
+			if props.CreatedOn != nil {
+				candidate.createdOn = *props.CreatedOn
+			}
+			scope := strings.ToLower(*props.Scope)
+			candidates[scope] = append(candidates[scope], candidate)
+		}
+	}
+
+	owners := make(map[string]string)
+	for _, resourceID := range resourceIDs {
+		for _, scope := range []string{resourceID, resourceGroupID(resourceID)} {
+			if best, ok := bestRoleAssignment(candidates[strings.ToLower(scope)]); ok {
+				owners[resourceID] = best.principalID
+				break
+			}
+		}
+	}
+	return owners, nil
+}
+
+// ownerRoleRank 返回角色在ownerRoleDefinitions中的位置，不是用于推断所有者的角色时返回-1
+// roleDefinitionID为完整的资源ID，以角色定义的GUID结尾
+func ownerRoleRank(roleDefinitionID string) int {
+	id := strings.ToLower(roleDefinitionID[strings.LastIndex(roleDefinitionID, "/")+1:])
+	for i, roleID := range ownerRoleDefinitions {
+		if id == roleID {
+			return i
+		}
+	}
+	return -1
+}
+
+// principalTypeRank 主体类型的优先级，用户优先于组，组优先于服务主体等其他类型
+func principalTypeRank(principalType *armauthorization.PrincipalType) int {
+	if principalType == nil {
+		return 2
+	}
+	switch *principalType {
+	case armauthorization.PrincipalTypeUser:
+		return 0
+	case armauthorization.PrincipalTypeGroup:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// bestRoleAssignment 选出最适合作为所有者的角色分配
+func bestRoleAssignment(candidates []roleAssignmentCandidate) (roleAssignmentCandidate, bool) {
+	if len(candidates) == 0 {
+		return roleAssignmentCandidate{}, false
+	}
+	sorted := append([]roleAssignmentCandidate(nil), candidates...)
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.roleRank != b.roleRank {
+			return a.roleRank < b.roleRank
+		}
+		if a.typeRank != b.typeRank {
+			return a.typeRank < b.typeRank
+		}
+		if !a.createdOn.Equal(b.createdOn) {
+			return a.createdOn.Before(b.createdOn)
+		}
+		return a.principalID < b.principalID
+	})
+	return sorted[0], true
+}
+
+// resourceGroupID 从资源ID中截取资源组ID，如 /subscriptions/{id}/resourceGroups/{name}，不属于资源组时返回空字符串
+func resourceGroupID(resourceID string) string {
+	parts := strings.Split(resourceID, "/")
+	if len(parts) < 5 || !strings.EqualFold(parts[3], "resourceGroups") {
+		return ""
+	}
+	return strings.Join(parts[:5], "/")
+}
+
+// GetResourceCreators 从活动日志中查找创建资源的主体，返回资源ID到调用方（UPN或对象ID）的映射
+// 按资源组查询最近的活动日志，取每个资源最早一次成功且返回Created的写操作；超出日志保留期创建的资源无法找到
+// 单个资源组查询失败时继续查询其他资源组，返回已找到的结果和合并后的错误
+func (a *AzureHelper) GetResourceCreators(subscriptionID string, resourceIDs []string) (map[string]string, error) {
+	credential, err := a.credentialForSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := armmonitor.NewActivityLogsClient(subscriptionID, credential, a.armClientOptions())
+	if err != nil {
+		return nil, fmt.Errorf("创建活动日志客户端失败: %v", err)
+	}
+
+	// 按资源组归集要查找的资源，键为小写的资源ID
+	wanted := make(map[string]map[string]string)
+	for _, resourceID := range resourceIDs {
+		group := resourceGroupID(resourceID)
+		if group == "" {
+			continue
+		}
+		name := group[strings.LastIndex(group, "/")+1:]
+		if wanted[name] == nil {
+			wanted[name] = make(map[string]string)
+		}
+		wanted[name][strings.ToLower(resourceID)] = resourceID
+	}
+
+	now := time.Now().UTC()
+	creators := make(map[string]string)
+	var errs []error
+	for group, resources := range wanted {
+		filter := fmt.Sprintf("eventTimestamp ge '%s' and eventTimestamp le '%s' and resourceGroupName eq '%s'",
+			now.Add(-activityLogWindow).Format(time.RFC3339), now.Format(time.RFC3339), group)
+		pager := client.NewListPager(filter, &armmonitor.ActivityLogsClientListOptions{
+			Select: to.Ptr("caller,eventTimestamp,operationName,properties,resourceId,status"),
+		})
+
+		earliest := make(map[string]time.Time)
+		for pager.More() {
+			page, err := pager.NextPage(context.Background())
+			if err != nil {
+				errs = append(errs, fmt.Errorf("获取资源组 %s 的活动日志失败: %v", group, err))
+				break
+			}
+			for _, event := range page.Value {
+				if !isCreateEvent(event) {
+					continue
+				}
+				resourceID, ok := resources[strings.ToLower(*event.ResourceID)]
+				if !ok {
+					continue
+				}
+				if at, found := earliest[resourceID]; found && !event.EventTimestamp.Before(at) {
+					continue
+				}
+				earliest[resourceID] = *event.EventTimestamp
+				creators[resourceID] = *event.Caller
+			}
+		}
+	}
+	return creators, errors.Join(errs...)
+}
+
+// isCreateEvent 判断活动日志事件是否为成功创建资源的写操作
+func isCreateEvent(event *armmonitor.EventData) bool {
+	if event == nil || event.Caller == nil || *event.Caller == "" || event.ResourceID == nil || event.EventTimestamp == nil {
+		return false
+	}
+	if event.Status == nil || event.Status.Value == nil || *event.Status.Value != "Succeeded" {
+		return false
+	}
+	if event.OperationName == nil || event.OperationName.Value == nil || !strings.HasSuffix(strings.ToLower(*event.OperationName.Value), "/write") {
+		return false
+	}
+	statusCode := event.Properties["statusCode"]
+	return statusCode != nil && *statusCode == "Created"
+}
@@ -0,0 +1,211 @@
+package azure
+
+import (
+	"CMDB/config"
+	"CMDB/model"
+	"CMDB/provider"
+	"fmt"
+)
+
+// ProviderName Azure平台名称
+const ProviderName = "azure"
+
+func init() {
+	provider.RegisterFactory(ProviderName, newProviders)
+}
+
+// newProviders 为每个配置的Azure账号创建Provider，不同账号可以位于不同的云环境
+func newProviders(cfg *config.Config) ([]provider.Provider, error) {
+	var providers []provider.Provider
+	for _, account := range cfg.AzureAccounts {
+		azureHelper := NewAzureHelperWithConfig(account)
+		if err := azureHelper.Initialize(); err != nil {
+			return nil, fmt.Errorf("初始化Azure账号 %s 失败: %v", account.Name, err)
+		}
+		providers = append(providers, NewAzureProvider(azureHelper))
+	}
+	return providers, nil
+}
+
+// AzureProvider Azure资源发现，将Azure资源转换为CMDB模型
+type AzureProvider struct {
+	azureHelper *AzureHelper
+}
+
+// NewAzureProvider 创建新的Azure Provider
+func NewAzureProvider(azureHelper *AzureHelper) *AzureProvider {
+	return &AzureProvider{azureHelper: azureHelper}
+}
+
+// Name 平台名称
+func (p *AzureProvider) Name() string {
+	return ProviderName
+}
+
+// Account 账号名称
+func (p *AzureProvider) Account() string {
+	return p.azureHelper.AccountName()
+}
+
+// Scopes 需要同步的订阅
+func (p *AzureProvider) Scopes() ([]provider.Scope, error) {
+	subs, err := p.azureHelper.ListSubscriptions()
+	if err != nil {
+		return nil, fmt.Errorf("获取订阅列表失败: %v", err)
+	}
+
+	scopes := make([]provider.Scope, 0, len(subs))
+	for _, sub := range subs {
+		if sub.Err != nil {
+			// 列举失败的租户作为失败的范围，记录在同步结果和同步任务中
+			scopes = append(scopes, provider.Scope{ID: "/tenants/" + sub.TenantID, TenantID: sub.TenantID, Err: sub.Err})
+			continue
+		}
+		scopes = append(scopes, provider.Scope{
+			ID:       sub.SubscriptionID,
+			Name:     sub.DisplayName,
+			TenantID: sub.TenantID,
+		})
+	}
+	return scopes, nil
+}
+
+// DiscoverVMs 发现订阅下的虚拟机
+func (p *AzureProvider) DiscoverVMs(scope provider.Scope) ([]*model.VM, error) {
+	// 从Azure获取虚拟机资源
+	azureVMs, err := p.azureHelper.GetVirtualMachines(scope.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取虚拟机资源失败: %v", err)
+	}
+
+	// 转换为模型
+	var vms []*model.VM
+	for _, azureVM := range azureVMs {
+		vm := &model.VM{
+			Provider:       ProviderName,
+			VMID:           azureVM.ID, // 确保 azureVM.ID 现在是完整的 ARM ID
+			ResourceID:     azureVM.ID, // 确保 azureVM.ID 现在是完整的 ARM ID
+			Name:           azureVM.Name,
+			Location:       azureVM.Location,
+			Type:           azureVM.Type,
+			Status:         azureVM.Status,
+			Owner:          azureVM.Owner,
+			SubscriptionID: azureVM.SubscriptionID,
+			Tags:           azureVM.Tags,
+		}
+		vms = append(vms, vm)
+	}
+
+	return vms, nil
+}
+
+// DiscoverDatabases 发现订阅下的数据库
+func (p *AzureProvider) DiscoverDatabases(scope provider.Scope) ([]*model.Database, error) {
+	// 从Azure获取SQL数据库资源
+	sqlDatabases, err := p.azureHelper.GetSQLDatabases(scope.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取SQL数据库资源失败: %v", err)
+	}
+
+	// 从Azure获取MySQL灵活服务器资源
+	mysqlServers, err := p.azureHelper.GetMySQLFlexibleServers(scope.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取MySQL灵活服务器资源失败: %v", err)
+	}
+
+	// 从Azure获取SQL服务器资源
+	sqlServers, err := p.azureHelper.GetSQLServers(scope.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取SQL服务器资源失败: %v", err)
+	}
+
+	// 合并所有数据库资源
+	allDatabases := append(sqlDatabases, mysqlServers...)
+	allDatabases = append(allDatabases, sqlServers...)
+
+	// 转换为模型
+	var databases []*model.Database
+	for _, azureDB := range allDatabases {
+		database := &model.Database{
+			Provider:       ProviderName,
+			DatabaseID:     azureDB.ID,
+			ResourceID:     azureDB.ID,
+			Name:           azureDB.Name,
+			Location:       azureDB.Location,
+			Server:         azureDB.Server,
+			DBType:         azureDB.DBType,
+			Version:        azureDB.Version,
+			Status:         azureDB.Status,
+			Owner:          azureDB.Owner,
+			SubscriptionID: azureDB.SubscriptionID,
+			Tags:           azureDB.Tags,
+		}
+		databases = append(databases, database)
+	}
+
+	return databases, nil
+}
+
+// DiscoverResources 发现订阅下的通用资源
+func (p *AzureProvider) DiscoverResources(scope provider.Scope) ([]*model.Resource, error) {
+	// 从Azure获取资源列表
+	azureResources, err := p.azureHelper.GetResources(scope.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取Azure资源列表失败: %v", err)
+	}
+
+	// 转换为模型
+	var resources []*model.Resource
+	for _, azureResource := range azureResources {
+		resource := &model.Resource{
+			Provider:       ProviderName,
+			ResourceID:     azureResource.ID,
+			Name:           azureResource.Name,
+			Location:       azureResource.Location,
+			ResourceType:   azureResource.Type,
+			Owner:          azureResource.Owner,
+			SubscriptionID: azureResource.SubscriptionID,
+			Tags:           azureResource.Tags,
+		}
+		resources = append(resources, resource)
+	}
+
+	return resources, nil
+}
+
+// Diagnostics 同步所用Azure身份的诊断信息
+func (p *AzureProvider) Diagnostics() interface{} {
+	return p.azureHelper.CredentialInfo()
+}
+
+// UpdateTags 将标签变更写回Azure资源，scopeID为资源所属订阅
+func (p *AzureProvider) UpdateTags(scopeID, resourceID string, set map[string]string, remove []string) (map[string]string, error) {
+	return p.azureHelper.UpdateResourceTags(scopeID, resourceID, set, remove)
+}
+
+// ResolveOwner 在账号所在租户的Entra ID中解析所有者
+func (p *AzureProvider) ResolveOwner(value string) (*model.OwnerInfo, error) {
+	return p.azureHelper.ResolveOwner(value)
+}
+
+// OwnersFromRoleAssignments 按订阅中的Owner/Contributor角色分配推断资源所有者
+func (p *AzureProvider) OwnersFromRoleAssignments(scope provider.Scope, resourceIDs []string) (map[string]string, error) {
+	return p.azureHelper.GetRoleAssignmentOwners(scope.ID, resourceIDs)
+}
+
+// CreatorsFromActivityLog 按订阅的活动日志查找创建资源的主体
+func (p *AzureProvider) CreatorsFromActivityLog(scope provider.Scope, resourceIDs []string) (map[string]string, error) {
+	return p.azureHelper.GetResourceCreators(scope.ID, resourceIDs)
+}
+
+// DiscoverRelationships 发现订阅中资源之间的关系
+func (p *AzureProvider) DiscoverRelationships(scope provider.Scope) ([]*model.Relationship, error) {
+	relationships, err := p.azureHelper.GetRelationships(scope.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取Azure资源关系失败: %v", err)
+	}
+	for _, relationship := range relationships {
+		relationship.Provider = ProviderName
+	}
+	return relationships, nil
+}
@@ -0,0 +1,186 @@
+package azure
+
+import (
+	"CMDB/model"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v4"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v6"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+)
+
+// relationshipSet 按来源、目标和类型去重的关系集合，资源ID不区分大小写
+type relationshipSet struct {
+	subscriptionID string
+	seen           map[string]bool
+	items          []*model.Relationship
+}
+
+// add 添加一条关系，端点为空时忽略
+func (s *relationshipSet) add(sourceID, targetID, relationshipType string) {
+	if sourceID == "" || targetID == "" {
+		return
+	}
+	key := strings.ToLower(sourceID + "|" + targetID + "|" + relationshipType)
+	if s.seen[key] {
+		return
+	}
+	s.seen[key] = true
+	s.items = append(s.items, &model.Relationship{
+		SourceID:       sourceID,
+		SourceType:     resourceTypeFromID(sourceID),
+		TargetID:       targetID,
+		TargetType:     resourceTypeFromID(targetID),
+		Type:           relationshipType,
+		SubscriptionID: s.subscriptionID,
+	})
+}
+
+// addSubnet 添加到子网的关系，以及子网到其虚拟网络的关系
+func (s *relationshipSet) addSubnet(sourceID string, subnet *armnetwork.Subnet) {
+	if subnet == nil || subnet.ID == nil {
+		return
+	}
+	s.add(sourceID, *subnet.ID, model.RelationshipInSubnet)
+	if i := strings.LastIndex(strings.ToLower(*subnet.ID), "/subnets/"); i > 0 {
+		s.add(*subnet.ID, (*subnet.ID)[:i], model.RelationshipInVNet)
+	}
+}
+
+// GetRelationships 发现订阅中资源之间的关系：
+// 资源到资源组、资源组到订阅、SQL数据库到SQL服务器、虚拟机和专用终结点到网络接口、
+// 网络接口和专用终结点到子网、子网到虚拟网络、虚拟机到托管磁盘、专用终结点到其连接的目标资源
+func (a *AzureHelper) GetRelationships(subscriptionID string) ([]*model.Relationship, error) {
+	credential, err := a.credentialForSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &relationshipSet{subscriptionID: subscriptionID, seen: make(map[string]bool)}
+	ctx := context.Background()
+
+	// 资源的层级关系可直接由资源ID得出
+	resourcesClient, err := armresources.NewClient(subscriptionID, credential, a.armClientOptions())
+	if err != nil {
+		return nil, fmt.Errorf("创建资源客户端失败: %v", err)
+	}
+	resourcePager := resourcesClient.NewListPager(nil)
+	for resourcePager.More() {
+		page, err := resourcePager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("获取资源列表失败: %v", err)
+		}
+		for _, item := range page.Value {
+			if item.ID == nil {
+				continue
+			}
+			group := resourceGroupID(*item.ID)
+			set.add(*item.ID, group, model.RelationshipInResourceGroup)
+			if group != "" {
+				set.add(group, "/subscriptions/"+subscriptionID, model.RelationshipInSubscription)
+			}
+			if item.Type != nil && strings.EqualFold(*item.Type, "Microsoft.Sql/servers/databases") {
+				if i := strings.LastIndex(strings.ToLower(*item.ID), "/databases/"); i > 0 {
+					set.add(*item.ID, (*item.ID)[:i], model.RelationshipHostedOn)
+				}
+			}
+		}
+	}
+
+	networkFactory, err := armnetwork.NewClientFactory(subscriptionID, credential, a.armClientOptions())
+	if err != nil {
+		return nil, fmt.Errorf("创建网络客户端工厂失败: %v", err)
+	}
+
+	// 网络接口记录了所属的虚拟机或专用终结点以及所在子网
+	nicPager := networkFactory.NewInterfacesClient().NewListAllPager(nil)
+	for nicPager.More() {
+		page, err := nicPager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("获取网络接口列表失败: %v", err)
+		}
+		for _, nic := range page.Value {
+			if nic.ID == nil || nic.Properties == nil {
+				continue
+			}
+			if vm := nic.Properties.VirtualMachine; vm != nil && vm.ID != nil {
+				set.add(*vm.ID, *nic.ID, model.RelationshipHasNIC)
+			}
+			if endpoint := nic.Properties.PrivateEndpoint; endpoint != nil && endpoint.ID != nil {
+				set.add(*endpoint.ID, *nic.ID, model.RelationshipHasNIC)
+			}
+			for _, ipConfig := range nic.Properties.IPConfigurations {
+				if ipConfig != nil && ipConfig.Properties != nil {
+					set.addSubnet(*nic.ID, ipConfig.Properties.Subnet)
+				}
+			}
+		}
+	}
+
+	// 专用终结点连接的目标资源
+	endpointPager := networkFactory.NewPrivateEndpointsClient().NewListBySubscriptionPager(nil)
+	for endpointPager.More() {
+		page, err := endpointPager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("获取专用终结点列表失败: %v", err)
+		}
+		for _, endpoint := range page.Value {
+			if endpoint.ID == nil || endpoint.Properties == nil {
+				continue
+			}
+			set.addSubnet(*endpoint.ID, endpoint.Properties.Subnet)
+			connections := append(endpoint.Properties.PrivateLinkServiceConnections, endpoint.Properties.ManualPrivateLinkServiceConnections...)
+			for _, connection := range connections {
+				if connection != nil && connection.Properties != nil && connection.Properties.PrivateLinkServiceID != nil {
+					set.add(*endpoint.ID, *connection.Properties.PrivateLinkServiceID, model.RelationshipPrivateEndpointFor)
+				}
+			}
+		}
+	}
+
+	// 托管磁盘记录了挂载它的虚拟机
+	computeFactory, err := armcompute.NewClientFactory(subscriptionID, credential, a.armClientOptions())
+	if err != nil {
+		return nil, fmt.Errorf("创建计算客户端工厂失败: %v", err)
+	}
+	diskPager := computeFactory.NewDisksClient().NewListPager(nil)
+	for diskPager.More() {
+		page, err := diskPager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("获取托管磁盘列表失败: %v", err)
+		}
+		for _, disk := range page.Value {
+			if disk.ID != nil && disk.ManagedBy != nil {
+				set.add(*disk.ManagedBy, *disk.ID, model.RelationshipHasDisk)
+			}
+		}
+	}
+
+	return set.items, nil
+}
+
+// resourceTypeFromID 从资源ID中得出资源类型，如 Microsoft.Network/virtualNetworks/subnets
+// 资源组和订阅分别返回 Microsoft.Resources/resourceGroups 和 Microsoft.Resources/subscriptions
+func resourceTypeFromID(resourceID string) string {
+	parts := strings.Split(strings.Trim(resourceID, "/"), "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if !strings.EqualFold(parts[i], "providers") {
+			continue
+		}
+		// providers/{命名空间}/{类型}/{名称}/{子类型}/{名称}...
+		types := []string{parts[i+1]}
+		for j := i + 2; j < len(parts); j += 2 {
+			types = append(types, parts[j])
+		}
+		return strings.Join(types, "/")
+	}
+	switch len(parts) {
+	case 4:
+		return "Microsoft.Resources/resourceGroups"
+	case 2:
+		return "Microsoft.Resources/subscriptions"
+	}
+	return ""
+}
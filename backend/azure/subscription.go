@@ -0,0 +1,150 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+)
+
+// 订阅列表接口使用的API版本
+const subscriptionsAPIVersion = "2022-12-01"
+
+// Subscription Azure订阅
+type Subscription struct {
+	SubscriptionID string `json:"subscription_id"`
+	TenantID       string `json:"tenant_id"`
+	DisplayName    string `json:"display_name"`
+	State          string `json:"state"`
+	// Err 列举租户订阅失败时的错误，此时为该租户的占位项，SubscriptionID为空
+	Err error `json:"-"`
+}
+
+// subscriptionListResult 订阅列表接口的分页响应
+type subscriptionListResult struct {
+	Value []struct {
+		SubscriptionID string `json:"subscriptionId"`
+		TenantID       string `json:"tenantId"`
+		DisplayName    string `json:"displayName"`
+		State          string `json:"state"`
+	} `json:"value"`
+	NextLink string `json:"nextLink"`
+}
+
+// ListSubscriptions 列出需要同步的订阅
+// 未配置订阅列表时返回各租户下凭证可见的全部已启用订阅，列举失败的租户以Err不为空的占位项返回，全部租户都失败时返回错误；
+// 配置了订阅列表时只返回配置的订阅
+func (a *AzureHelper) ListSubscriptions() ([]Subscription, error) {
+	if a.credential == nil {
+		if err := a.Initialize(); err != nil {
+			return nil, err
+		}
+	}
+
+	var visible, failed []Subscription
+	var lastErr error
+	for _, tenantID := range a.config.TenantIDs {
+		subs, err := a.listTenantSubscriptions(context.Background(), tenantID)
+		if err != nil {
+			// 单个租户失败不影响其他租户
+			lastErr = fmt.Errorf("列举租户 %s 的订阅失败: %v", tenantID, err)
+			failed = append(failed, Subscription{TenantID: tenantID, Err: lastErr})
+			continue
+		}
+		visible = append(visible, subs...)
+	}
+
+	var result []Subscription
+	if len(a.config.SubscriptionIDs) == 0 {
+		for _, sub := range visible {
+			if sub.State == "" || strings.EqualFold(sub.State, "Enabled") {
+				result = append(result, sub)
+			}
+		}
+		if len(result) == 0 && len(failed) == len(a.config.TenantIDs) && lastErr != nil {
+			return nil, lastErr
+		}
+		result = append(result, failed...)
+	} else {
+		for _, subscriptionID := range a.config.SubscriptionIDs {
+			sub, ok := findSubscription(visible, subscriptionID)
+			if !ok {
+				// 凭证不可见的订阅仍然返回，由同步结果记录具体错误
+				sub = Subscription{SubscriptionID: subscriptionID, TenantID: a.config.TenantID}
+			}
+			result = append(result, sub)
+		}
+	}
+
+	a.mu.Lock()
+	for _, sub := range result {
+		if sub.Err == nil {
+			a.subscriptionTenants[sub.SubscriptionID] = sub.TenantID
+		}
+	}
+	a.mu.Unlock()
+
+	return result, nil
+}
+
+// listTenantSubscriptions 通过ARM接口列出指定租户下可见的订阅
+func (a *AzureHelper) listTenantSubscriptions(ctx context.Context, tenantID string) ([]Subscription, error) {
+	credential, err := a.credentialForTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := arm.NewClient("CMDB/azure", "v1.0.0", credential, a.armClientOptions())
+	if err != nil {
+		return nil, fmt.Errorf("创建ARM客户端失败: %v", err)
+	}
+
+	var subs []Subscription
+	nextLink := runtime.JoinPaths(client.Endpoint(), "/subscriptions") + "?api-version=" + subscriptionsAPIVersion
+	for nextLink != "" {
+		req, err := runtime.NewRequest(ctx, http.MethodGet, nextLink)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Pipeline().Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !runtime.HasStatusCode(resp, http.StatusOK) {
+			return nil, runtime.NewResponseError(resp)
+		}
+
+		var page subscriptionListResult
+		if err := runtime.UnmarshalAsJSON(resp, &page); err != nil {
+			return nil, err
+		}
+		for _, item := range page.Value {
+			sub := Subscription{
+				SubscriptionID: item.SubscriptionID,
+				TenantID:       item.TenantID,
+				DisplayName:    item.DisplayName,
+				State:          item.State,
+			}
+			if sub.TenantID == "" {
+				sub.TenantID = tenantID
+			}
+			subs = append(subs, sub)
+		}
+		nextLink = page.NextLink
+	}
+
+	return subs, nil
+}
+
+// findSubscription 在订阅列表中按ID查找订阅
+func findSubscription(subs []Subscription, subscriptionID string) (Subscription, bool) {
+	for _, sub := range subs {
+		if strings.EqualFold(sub.SubscriptionID, subscriptionID) {
+			return sub, true
+		}
+	}
+	return Subscription{}, false
+}
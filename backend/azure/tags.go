@@ -0,0 +1,90 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+)
+
+// UpdateResourceTags 通过ARM Tags API修改资源标签：set中的标签以Merge方式添加或修改，remove中的标签键以Delete方式删除
+// 返回修改后资源的全部标签；Merge成功后读取或删除标签失败时，同时返回Merge后的标签和错误
+// Azure标签键不区分大小写，remove中的标签键忽略大小写匹配
+func (a *AzureHelper) UpdateResourceTags(subscriptionID, resourceID string, set map[string]string, remove []string) (map[string]string, error) {
+	credential, err := a.credentialForSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := armresources.NewTagsClient(subscriptionID, credential, a.armClientOptions())
+	if err != nil {
+		return nil, fmt.Errorf("创建标签客户端失败: %v", err)
+	}
+
+	ctx := context.Background()
+	var tags map[string]*string
+	merged := false
+	// partial Merge已生效后出错时，返回Merge后的标签，调用方据此按部分成功处理
+	partial := func(err error) (map[string]string, error) {
+		if merged {
+			return convertTags(tags), err
+		}
+		return nil, err
+	}
+
+	if len(set) > 0 {
+		patch := make(map[string]*string, len(set))
+		for key, value := range set {
+			patch[key] = to.Ptr(value)
+		}
+		resp, err := client.UpdateAtScope(ctx, resourceID, armresources.TagsPatchResource{
+			Operation:  to.Ptr(armresources.TagsPatchOperationMerge),
+			Properties: &armresources.Tags{Tags: patch},
+		}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("修改标签失败: %v", err)
+		}
+		tags = resourceTags(resp.TagsResource)
+		merged = true
+	}
+
+	if len(remove) > 0 {
+		// Delete操作按名称和值匹配，先读取要删除标签的当前值
+		current, err := client.GetAtScope(ctx, resourceID, nil)
+		if err != nil {
+			return partial(fmt.Errorf("获取标签失败: %v", err))
+		}
+		tags = resourceTags(current.TagsResource)
+
+		patch := make(map[string]*string)
+		for _, key := range remove {
+			for existing, value := range tags {
+				if strings.EqualFold(existing, key) {
+					patch[existing] = value
+				}
+			}
+		}
+		if len(patch) > 0 {
+			resp, err := client.UpdateAtScope(ctx, resourceID, armresources.TagsPatchResource{
+				Operation:  to.Ptr(armresources.TagsPatchOperationDelete),
+				Properties: &armresources.Tags{Tags: patch},
+			}, nil)
+			if err != nil {
+				return partial(fmt.Errorf("删除标签失败: %v", err))
+			}
+			tags = resourceTags(resp.TagsResource)
+		}
+	}
+
+	return convertTags(tags), nil
+}
+
+// resourceTags 取出Tags API响应中的标签
+func resourceTags(resource armresources.TagsResource) map[string]*string {
+	if resource.Properties == nil {
+		return nil
+	}
+	return resource.Properties.Tags
+}
@@ -0,0 +1,220 @@
+// Package ciclass 加载手动维护的CI的类定义，并按类校验CI的属性和标签
+//
+// 类定义文件为JSON数组，每个元素对应一个model.CIClass，例如：
+//
+//	[
+//	  {"name": "physical_server", "description": "机房物理服务器",
+//	   "attributes": [
+//	     {"name": "serial_number", "required": true},
+//	     {"name": "rack", "pattern": "^[A-Z][0-9]{2}$"},
+//	     {"name": "cpu_cores", "type": "number"},
+//	     {"name": "warranty_until", "type": "date"},
+//	     {"name": "vendor", "allowed_values": ["Dell", "HPE", "Lenovo"]}
+//	   ],
+//	   "required_tags": ["env"]}
+//	]
+package ciclass
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"CMDB/model"
+)
+
+// classNamePattern 类名只能包含小写字母、数字、下划线和连字符，用作资源类型和资源ID的一段
+var classNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// LoadFile 从JSON文件加载CI类定义
+func LoadFile(path string) ([]model.CIClass, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取CI类定义文件失败: %v", err)
+	}
+	var classes []model.CIClass
+	if err := json.Unmarshal(data, &classes); err != nil {
+		return nil, fmt.Errorf("解析CI类定义文件 %s 失败: %v", path, err)
+	}
+	return classes, nil
+}
+
+// compiledAttribute 预编译正则并建立索引后的属性定义
+type compiledAttribute struct {
+	model.CIAttribute
+	pattern *regexp.Regexp
+	allowed map[string]bool
+}
+
+// compiledClass 预编译后的类定义
+type compiledClass struct {
+	model.CIClass
+	attributes map[string]*compiledAttribute
+}
+
+// Registry CI类注册表
+type Registry struct {
+	classes map[string]*compiledClass
+}
+
+// NewRegistry 校验并编译类定义，属性类型为空时使用string
+func NewRegistry(classes []model.CIClass) (*Registry, error) {
+	registry := &Registry{classes: make(map[string]*compiledClass)}
+	for i, c := range classes {
+		if !classNamePattern.MatchString(c.Name) {
+			return nil, fmt.Errorf("第%d个CI类的name %q 无效，只能包含小写字母、数字、下划线和连字符", i+1, c.Name)
+		}
+		if len(c.Name) > model.MaxCIClassNameLength {
+			return nil, fmt.Errorf("第%d个CI类的name %s 超过%d个字符", i+1, c.Name, model.MaxCIClassNameLength)
+		}
+		if registry.classes[c.Name] != nil {
+			return nil, fmt.Errorf("CI类 %s 重复", c.Name)
+		}
+
+		compiled := &compiledClass{CIClass: c, attributes: make(map[string]*compiledAttribute)}
+		compiled.Attributes = make([]model.CIAttribute, 0, len(c.Attributes))
+		for _, a := range c.Attributes {
+			if strings.TrimSpace(a.Name) == "" {
+				return nil, fmt.Errorf("CI类 %s 的属性缺少name", c.Name)
+			}
+			if compiled.attributes[a.Name] != nil {
+				return nil, fmt.Errorf("CI类 %s 的属性 %s 重复", c.Name, a.Name)
+			}
+			if a.Type == "" {
+				a.Type = model.AttributeTypeString
+			}
+			if !model.AttributeTypes[a.Type] {
+				return nil, fmt.Errorf("CI类 %s 的属性 %s 的type %s 无效，可选 string、number、boolean、date", c.Name, a.Name, a.Type)
+			}
+
+			attribute := &compiledAttribute{CIAttribute: a}
+			if len(a.AllowedValues) > 0 {
+				attribute.allowed = make(map[string]bool, len(a.AllowedValues))
+				for _, value := range a.AllowedValues {
+					attribute.allowed[value] = true
+				}
+			}
+			if a.Pattern != "" {
+				pattern, err := regexp.Compile(a.Pattern)
+				if err != nil {
+					return nil, fmt.Errorf("CI类 %s 的属性 %s 的pattern无效: %v", c.Name, a.Name, err)
+				}
+				attribute.pattern = pattern
+			}
+			compiled.attributes[a.Name] = attribute
+			compiled.Attributes = append(compiled.Attributes, a)
+		}
+		registry.classes[c.Name] = compiled
+	}
+	return registry, nil
+}
+
+// Classes 返回全部类定义，按类名排序
+func (r *Registry) Classes() []model.CIClass {
+	classes := make([]model.CIClass, 0, len(r.classes))
+	for _, c := range r.classes {
+		classes = append(classes, c.CIClass)
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		return classes[i].Name < classes[j].Name
+	})
+	return classes
+}
+
+// Has 类是否已定义
+func (r *Registry) Has(name string) bool {
+	return r.classes[name] != nil
+}
+
+// Validate 按类定义校验CI的属性和标签，返回的错误列出全部问题
+// 未定义的属性、缺少必填属性或必需标签、取值不符合类型、允许的取值或正则时校验失败
+func (r *Registry) Validate(class string, attributes, tags map[string]string) error {
+	c := r.classes[class]
+	if c == nil {
+		return fmt.Errorf("未定义的CI类 %s", class)
+	}
+
+	var problems []string
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		attribute := c.attributes[name]
+		if attribute == nil {
+			problems = append(problems, fmt.Sprintf("CI类 %s 未定义属性 %s", class, name))
+			continue
+		}
+		if problem := attribute.check(attributes[name]); problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+	for _, a := range c.Attributes {
+		if _, ok := attributes[a.Name]; a.Required && !ok {
+			problems = append(problems, fmt.Sprintf("缺少必填属性 %s", a.Name))
+		}
+	}
+
+	for key := range tags {
+		if strings.TrimSpace(key) == "" {
+			problems = append(problems, "标签键不能为空")
+			break
+		}
+	}
+	for _, key := range c.RequiredTags {
+		if !hasTag(tags, key) {
+			problems = append(problems, fmt.Sprintf("缺少必需标签 %s", key))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// check 校验属性取值，通过时返回空字符串
+func (a *compiledAttribute) check(value string) string {
+	if a.Required && value == "" {
+		return fmt.Sprintf("属性 %s 不能为空", a.Name)
+	}
+	if value == "" {
+		return ""
+	}
+
+	var err error
+	switch a.Type {
+	case model.AttributeTypeNumber:
+		_, err = strconv.ParseFloat(value, 64)
+	case model.AttributeTypeBoolean:
+		_, err = strconv.ParseBool(value)
+	case model.AttributeTypeDate:
+		_, err = time.Parse("2006-01-02", value)
+	}
+	if err != nil {
+		return fmt.Sprintf("属性 %s 的取值 %q 不是有效的%s", a.Name, value, a.Type)
+	}
+	if a.allowed != nil && !a.allowed[value] {
+		return fmt.Sprintf("属性 %s 的取值 %q 不在允许的取值中", a.Name, value)
+	}
+	if a.pattern != nil && !a.pattern.MatchString(value) {
+		return fmt.Sprintf("属性 %s 的取值 %q 不匹配 %s", a.Name, value, a.Pattern)
+	}
+	return ""
+}
+
+// hasTag 是否带有标签，与Azure一致忽略标签键的大小写
+func hasTag(tags map[string]string, key string) bool {
+	for k := range tags {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// AliyunConfig 阿里云账号配置
+type AliyunConfig struct {
+	// Name 账号名称，用于区分同时同步的多个阿里云账号
+	Name string
+	// Regions 需要同步的地域列表，如 cn-hangzhou
+	Regions []string
+	// 访问密钥，未配置ECS实例RAM角色时必填
+	AccessKeyID     string
+	AccessKeySecret string
+	// RoleARN 使用访问密钥扮演的RAM角色，用于同步其他账号
+	RoleARN string
+	// ExternalID 扮演角色时使用的外部ID
+	ExternalID string
+	// ECSRAMRole 部署在ECS上时使用实例绑定的RAM角色，优先于访问密钥
+	ECSRAMRole string
+}
+
+// loadAliyunAccounts 加载阿里云账号配置
+// 设置了ALIYUN_REGIONS时启用默认账号，ALIYUN_ACCOUNTS中列出的其他账号使用 ALIYUN_<NAME>_ 前缀的环境变量
+func loadAliyunAccounts() ([]AliyunConfig, error) {
+	var names []string
+	if os.Getenv("ALIYUN_REGIONS") != "" {
+		names = append(names, "")
+	}
+	names = append(names, splitList(os.Getenv("ALIYUN_ACCOUNTS"))...)
+
+	var accounts []AliyunConfig
+	for _, name := range names {
+		account := loadAliyunConfig(name)
+		if err := account.Validate(); err != nil {
+			return nil, fmt.Errorf("阿里云账号 %s 配置无效: %v", account.Name, err)
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// loadAliyunConfig 加载阿里云账号配置，name为空时读取默认账号
+func loadAliyunConfig(name string) AliyunConfig {
+	getenv := func(key string) string {
+		if name == "" {
+			return os.Getenv("ALIYUN_" + key)
+		}
+		return os.Getenv("ALIYUN_" + strings.ToUpper(name) + "_" + key)
+	}
+
+	aliyunConfig := AliyunConfig{
+		Name:            name,
+		Regions:         splitList(getenv("REGIONS")),
+		AccessKeyID:     getenv("ACCESS_KEY_ID"),
+		AccessKeySecret: getenv("ACCESS_KEY_SECRET"),
+		RoleARN:         getenv("ROLE_ARN"),
+		ExternalID:      getenv("EXTERNAL_ID"),
+		ECSRAMRole:      getenv("ECS_RAM_ROLE"),
+	}
+	if aliyunConfig.Name == "" {
+		aliyunConfig.Name = "default"
+	}
+
+	return aliyunConfig
+}
+
+// Validate 校验阿里云账号配置
+func (c *AliyunConfig) Validate() error {
+	if len(c.Regions) == 0 {
+		return fmt.Errorf("未设置REGIONS")
+	}
+	if c.ECSRAMRole == "" && (c.AccessKeyID == "" || c.AccessKeySecret == "") {
+		return fmt.Errorf("需要设置ACCESS_KEY_ID和ACCESS_KEY_SECRET，或设置ECS_RAM_ROLE")
+	}
+	return nil
+}
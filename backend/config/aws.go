@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// AWSConfig AWS账号配置
+type AWSConfig struct {
+	// Name 账号名称，用于区分同时同步的多个AWS账号
+	Name string
+	// Regions 需要同步的区域列表
+	Regions []string
+	// Profile 共享配置文件中的profile，为空时使用默认凭证链
+	Profile string
+	// 静态访问密钥，为空时使用默认凭证链（环境变量、实例角色等）
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// AssumeRoleARNs 需要扮演的角色列表，每个角色对应一个被同步的账号；为空时同步凭证所属账号
+	AssumeRoleARNs []string
+	// ExternalID 扮演角色时使用的外部ID
+	ExternalID string
+	// Endpoint 自定义API端点，用于对接本地模拟服务
+	Endpoint string
+}
+
+// loadAWSAccounts 加载AWS账号配置
+// 设置了AWS_REGIONS时启用默认账号，AWS_ACCOUNTS中列出的其他账号使用 AWS_<NAME>_ 前缀的环境变量
+func loadAWSAccounts() ([]AWSConfig, error) {
+	var accounts []AWSConfig
+	if os.Getenv("AWS_REGIONS") != "" {
+		accounts = append(accounts, loadAWSConfig(""))
+	}
+	for _, name := range splitList(os.Getenv("AWS_ACCOUNTS")) {
+		account := loadAWSConfig(name)
+		if len(account.Regions) == 0 {
+			return nil, fmt.Errorf("AWS账号 %s 未设置REGIONS", name)
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// loadAWSConfig 加载AWS账号配置，name为空时读取默认账号
+func loadAWSConfig(name string) AWSConfig {
+	getenv := func(key string) string {
+		if name == "" {
+			return os.Getenv("AWS_" + key)
+		}
+		return os.Getenv("AWS_" + strings.ToUpper(name) + "_" + key)
+	}
+
+	awsConfig := AWSConfig{
+		Name:            name,
+		Regions:         splitList(getenv("REGIONS")),
+		Profile:         getenv("PROFILE"),
+		AccessKeyID:     getenv("ACCESS_KEY_ID"),
+		SecretAccessKey: getenv("SECRET_ACCESS_KEY"),
+		SessionToken:    getenv("SESSION_TOKEN"),
+		AssumeRoleARNs:  splitList(getenv("ASSUME_ROLE_ARNS")),
+		ExternalID:      getenv("EXTERNAL_ID"),
+		Endpoint:        getenv("ENDPOINT_URL"),
+	}
+	if awsConfig.Name == "" {
+		awsConfig.Name = "default"
+	}
+
+	return awsConfig
+}
@@ -4,6 +4,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+
 	"github.com/joho/godotenv"
 )
 
@@ -13,28 +16,90 @@ type Config struct {
 	ServerPort    string
 	ServerAddress string
 	AzureConfig   AzureConfig
+	// AzureAccounts 需要同步的全部Azure账号，包含AzureConfig对应的默认账号
+	AzureAccounts []AzureConfig
+	// AWSAccounts 需要同步的AWS账号
+	AWSAccounts []AWSConfig
+	// AliyunAccounts 需要同步的阿里云账号
+	AliyunAccounts []AliyunConfig
+	// VSphereAccounts 需要同步的vCenter
+	VSphereAccounts []VSphereConfig
+	// KubernetesAccounts 需要同步的Kubernetes集群
+	KubernetesAccounts []KubernetesConfig
+	// DeletedRetentionDays 已删除资源的保留天数，超过后永久删除，为0时不清理
+	DeletedRetentionDays int
+	// TagPolicyFile 标签策略文件（JSON），为空时不评估标签合规
+	TagPolicyFile string
+	// CIClassFile 手动维护的CI的类定义文件（JSON），为空时不能创建手动CI
+	CIClassFile string
+	// OwnerCacheTTLHours 所有者目录解析结果的缓存小时数，过期后在下次同步时重新解析
+	OwnerCacheTTLHours int
 }
 
+// Azure云环境
+const (
+	AzureCloudPublic = "public"
+	AzureCloudChina  = "china"
+	AzureCloudUSGov  = "usgov"
+	AzureCloudCustom = "custom"
+)
+
+// Azure认证方式
+const (
+	AzureAuthClientSecret      = "client_secret"
+	AzureAuthClientCertificate = "client_certificate"
+	AzureAuthManagedIdentity   = "managed_identity"
+	AzureAuthWorkloadIdentity  = "workload_identity"
+	AzureAuthCLI               = "azure_cli"
+)
+
 // AzureConfig Azure配置
 type AzureConfig struct {
+	// Name 账号名称，用于区分同时同步的多个Azure账号
+	Name string
+	// Cloud 云环境: public, china, usgov 或 custom，默认为china
+	Cloud string
+	// 以下端点仅在Cloud为custom时使用
+	AuthorityHost           string
+	ResourceManagerEndpoint string
+	ResourceManagerAudience string
+	GraphEndpoint           string
+
+	// AuthMethod 认证方式，默认为client_secret
+	AuthMethod string
+	// ClientSecretFile 从文件读取客户端密码，优先于ClientSecret
+	ClientSecretFile string
+	// 证书认证使用的PEM/PKCS12证书及其密码（或密码文件）
+	ClientCertificatePath         string
+	ClientCertificatePassword     string
+	ClientCertificatePasswordFile string
+	// ManagedIdentityClientID 用户分配托管标识的客户端ID，为空时使用系统分配标识
+	ManagedIdentityClientID string
+	// FederatedTokenFile 工作负载标识联合令牌文件
+	FederatedTokenFile string
+
 	ClientID       string
 	TenantID       string
 	ClientSecret   string
 	SubscriptionID string
+	// TenantIDs 需要扫描的租户列表，为空时仅使用TenantID
+	TenantIDs []string
+	// SubscriptionIDs 需要同步的订阅列表，为空时同步凭证可见的全部订阅
+	SubscriptionIDs []string
 }
 
 // LoadConfig 从环境变量加载配置
 func LoadConfig() (*Config, error) {
 	// 尝试加载.env文件
 	_ = godotenv.Load()
-	
+
 	// 数据库配置
 	dbUser := os.Getenv("DB_USER")
 	dbPass := os.Getenv("DB_PASSWORD")
 	dbHost := os.Getenv("DB_HOST")
 	dbPort := os.Getenv("DB_PORT")
 	dbName := os.Getenv("DB_NAME")
-	
+
 	// 使用默认值（如果环境变量未设置）
 	if dbUser == "" {
 		dbUser = "root"
@@ -51,31 +116,185 @@ func LoadConfig() (*Config, error) {
 	if dbName == "" {
 		dbName = "cmdb"
 	}
-	
+
 	// 构建DSN
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local", 
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		dbUser, dbPass, dbHost, dbPort, dbName)
-	
+
 	// 服务器配置
 	serverPort := getEnvOrDefault("SERVER_PORT", "8080")
 	serverAddress := fmt.Sprintf(":%s", serverPort)
-	
-	// Azure配置
-	azureConfig := AzureConfig{
-		ClientID:       os.Getenv("CLIENT_ID"),
-		TenantID:       os.Getenv("TENANT_ID"),
-		ClientSecret:   os.Getenv("CLIENT_SECRET"),
-		SubscriptionID: os.Getenv("SUBSCRIPTION_ID"),
+
+	// Azure配置：默认账号使用不带前缀的环境变量，
+	// AZURE_ACCOUNTS中列出的其他账号使用 AZURE_<NAME>_ 前缀的环境变量
+	// 默认账号只在配置了凭据时启用，启用时与其他账号一样完整校验，未启用时仍校验云环境
+	azureConfig := loadAzureConfig("")
+	var azureAccounts []AzureConfig
+	if azureConfig.ClientID != "" || azureConfig.AuthMethod != AzureAuthClientSecret {
+		if err := azureConfig.Validate(); err != nil {
+			return nil, err
+		}
+		azureAccounts = append(azureAccounts, azureConfig)
+	} else if err := azureConfig.validateCloud(); err != nil {
+		return nil, err
+	}
+	for _, name := range splitList(os.Getenv("AZURE_ACCOUNTS")) {
+		account := loadAzureConfig(name)
+		if err := account.Validate(); err != nil {
+			return nil, err
+		}
+		azureAccounts = append(azureAccounts, account)
+	}
+
+	// AWS配置
+	awsAccounts, err := loadAWSAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	// 阿里云配置
+	aliyunAccounts, err := loadAliyunAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	// vSphere配置
+	vsphereAccounts, err := loadVSphereAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	// Kubernetes配置
+	kubernetesAccounts, err := loadKubernetesAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	// 已删除资源的保留天数
+	deletedRetentionDays, err := strconv.Atoi(getEnvOrDefault("DELETED_RETENTION_DAYS", "30"))
+	if err != nil || deletedRetentionDays < 0 {
+		return nil, fmt.Errorf("DELETED_RETENTION_DAYS必须是非负整数")
+	}
+
+	// 所有者目录缓存时长
+	ownerCacheTTLHours, err := strconv.Atoi(getEnvOrDefault("OWNER_CACHE_TTL_HOURS", "24"))
+	if err != nil || ownerCacheTTLHours <= 0 {
+		return nil, fmt.Errorf("OWNER_CACHE_TTL_HOURS必须是正整数")
 	}
-	
+
 	return &Config{
-		DatabaseDSN:   dsn,
-		ServerPort:    serverPort,
-		ServerAddress: serverAddress,
-		AzureConfig:   azureConfig,
+		DatabaseDSN:          dsn,
+		ServerPort:           serverPort,
+		ServerAddress:        serverAddress,
+		AzureConfig:          azureConfig,
+		AzureAccounts:        azureAccounts,
+		AWSAccounts:          awsAccounts,
+		AliyunAccounts:       aliyunAccounts,
+		VSphereAccounts:      vsphereAccounts,
+		KubernetesAccounts:   kubernetesAccounts,
+		DeletedRetentionDays: deletedRetentionDays,
+		TagPolicyFile:        os.Getenv("TAG_POLICY_FILE"),
+		CIClassFile:          os.Getenv("CI_CLASS_FILE"),
+		OwnerCacheTTLHours:   ownerCacheTTLHours,
 	}, nil
 }
 
+// loadAzureConfig 加载Azure账号配置，name为空时读取默认账号
+func loadAzureConfig(name string) AzureConfig {
+	getenv := func(key string) string {
+		if name == "" {
+			return os.Getenv(key)
+		}
+		return os.Getenv("AZURE_" + strings.ToUpper(name) + "_" + strings.TrimPrefix(key, "AZURE_"))
+	}
+
+	azureConfig := AzureConfig{
+		Name:                          name,
+		Cloud:                         strings.ToLower(getenv("AZURE_CLOUD")),
+		AuthorityHost:                 getenv("AZURE_AUTHORITY_HOST"),
+		ResourceManagerEndpoint:       getenv("AZURE_RESOURCE_MANAGER_ENDPOINT"),
+		ResourceManagerAudience:       getenv("AZURE_RESOURCE_MANAGER_AUDIENCE"),
+		GraphEndpoint:                 getenv("AZURE_GRAPH_ENDPOINT"),
+		AuthMethod:                    strings.ToLower(getenv("AZURE_AUTH_METHOD")),
+		ClientSecretFile:              getenv("CLIENT_SECRET_FILE"),
+		ClientCertificatePath:         getenv("CLIENT_CERTIFICATE_PATH"),
+		ClientCertificatePassword:     getenv("CLIENT_CERTIFICATE_PASSWORD"),
+		ClientCertificatePasswordFile: getenv("CLIENT_CERTIFICATE_PASSWORD_FILE"),
+		ManagedIdentityClientID:       getenv("MANAGED_IDENTITY_CLIENT_ID"),
+		FederatedTokenFile:            getenv("AZURE_FEDERATED_TOKEN_FILE"),
+		ClientID:                      getenv("CLIENT_ID"),
+		TenantID:                      getenv("TENANT_ID"),
+		ClientSecret:                  getenv("CLIENT_SECRET"),
+		SubscriptionID:                getenv("SUBSCRIPTION_ID"),
+		TenantIDs:                     splitList(getenv("TENANT_IDS")),
+		SubscriptionIDs:               splitList(getenv("SUBSCRIPTION_IDS")),
+	}
+	if azureConfig.Name == "" {
+		azureConfig.Name = "default"
+	}
+	if azureConfig.Cloud == "" {
+		azureConfig.Cloud = AzureCloudChina
+	}
+	if azureConfig.AuthMethod == "" {
+		azureConfig.AuthMethod = AzureAuthClientSecret
+	}
+	if len(azureConfig.TenantIDs) == 0 && azureConfig.TenantID != "" {
+		azureConfig.TenantIDs = []string{azureConfig.TenantID}
+	}
+	// 兼容旧的单订阅配置
+	if len(azureConfig.SubscriptionIDs) == 0 && azureConfig.SubscriptionID != "" {
+		azureConfig.SubscriptionIDs = []string{azureConfig.SubscriptionID}
+	}
+
+	return azureConfig
+}
+
+// validateAuth 校验认证方式及其必需的配置项
+func (c AzureConfig) validateAuth() error {
+	switch c.AuthMethod {
+	case AzureAuthClientSecret:
+		if c.ClientID == "" || c.TenantID == "" || (c.ClientSecret == "" && c.ClientSecretFile == "") {
+			return fmt.Errorf("Azure账号 %s 使用客户端密码认证时必须设置CLIENT_ID, TENANT_ID和CLIENT_SECRET(或CLIENT_SECRET_FILE)", c.Name)
+		}
+	case AzureAuthClientCertificate:
+		if c.ClientID == "" || c.TenantID == "" || c.ClientCertificatePath == "" {
+			return fmt.Errorf("Azure账号 %s 使用证书认证时必须设置CLIENT_ID, TENANT_ID和CLIENT_CERTIFICATE_PATH", c.Name)
+		}
+	case AzureAuthWorkloadIdentity:
+		if c.ClientID == "" || c.TenantID == "" {
+			return fmt.Errorf("Azure账号 %s 使用工作负载标识认证时必须设置CLIENT_ID和TENANT_ID", c.Name)
+		}
+	case AzureAuthManagedIdentity, AzureAuthCLI:
+		// 托管标识和Azure CLI不需要额外配置
+	default:
+		return fmt.Errorf("Azure账号 %s 的认证方式 %q 无效，可选值: client_secret, client_certificate, managed_identity, workload_identity, azure_cli", c.Name, c.AuthMethod)
+	}
+	return nil
+}
+
+// Validate 校验Azure账号配置
+func (c AzureConfig) Validate() error {
+	if err := c.validateCloud(); err != nil {
+		return err
+	}
+	return c.validateAuth()
+}
+
+// validateCloud 校验云环境配置
+func (c AzureConfig) validateCloud() error {
+	switch c.Cloud {
+	case AzureCloudPublic, AzureCloudChina, AzureCloudUSGov:
+		return nil
+	case AzureCloudCustom:
+		if c.AuthorityHost == "" || c.ResourceManagerEndpoint == "" {
+			return fmt.Errorf("Azure账号 %s 使用自定义云环境时必须设置AUTHORITY_HOST和RESOURCE_MANAGER_ENDPOINT", c.Name)
+		}
+		return nil
+	default:
+		return fmt.Errorf("Azure账号 %s 的云环境 %q 无效，可选值: public, china, usgov, custom", c.Name, c.Cloud)
+	}
+}
+
 // getEnvOrDefault 获取环境变量，如果不存在则返回默认值
 func getEnvOrDefault(key, defaultValue string) string {
 	value := os.Getenv(key)
@@ -83,4 +302,15 @@ func getEnvOrDefault(key, defaultValue string) string {
 		return defaultValue
 	}
 	return value
-}
\ No newline at end of file
+}
+
+// splitList 将逗号分隔的环境变量拆分为列表，忽略空白项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KubernetesConfig Kubernetes集群连接配置
+type KubernetesConfig struct {
+	// Name 账号名称，用于区分同时同步的多个kubeconfig
+	Name string
+	// Kubeconfig kubeconfig文件路径，多个文件用系统路径分隔符分隔
+	Kubeconfig string
+	// Contexts 需要同步的上下文，为空时同步kubeconfig中的全部上下文
+	Contexts []string
+	// AKSResourceIDs 上下文 -> AKS集群的Azure资源ID，未配置时根据节点信息推断
+	AKSResourceIDs map[string]string
+}
+
+// loadKubernetesAccounts 加载Kubernetes配置
+// 设置了KUBERNETES_KUBECONFIG时启用默认账号，KUBERNETES_ACCOUNTS中列出的其他账号使用 KUBERNETES_<NAME>_ 前缀的环境变量
+func loadKubernetesAccounts() ([]KubernetesConfig, error) {
+	var names []string
+	if os.Getenv("KUBERNETES_KUBECONFIG") != "" {
+		names = append(names, "")
+	}
+	names = append(names, splitList(os.Getenv("KUBERNETES_ACCOUNTS"))...)
+
+	var accounts []KubernetesConfig
+	for _, name := range names {
+		account, err := loadKubernetesConfig(name)
+		if err != nil {
+			return nil, err
+		}
+		if account.Kubeconfig == "" {
+			return nil, fmt.Errorf("Kubernetes账号 %s 未设置KUBECONFIG", account.Name)
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// loadKubernetesConfig 加载Kubernetes配置，name为空时读取默认账号
+func loadKubernetesConfig(name string) (KubernetesConfig, error) {
+	getenv := func(key string) string {
+		if name == "" {
+			return os.Getenv("KUBERNETES_" + key)
+		}
+		return os.Getenv("KUBERNETES_" + strings.ToUpper(name) + "_" + key)
+	}
+
+	kubernetesConfig := KubernetesConfig{
+		Name:           name,
+		Kubeconfig:     getenv("KUBECONFIG"),
+		Contexts:       splitList(getenv("CONTEXTS")),
+		AKSResourceIDs: make(map[string]string),
+	}
+	if kubernetesConfig.Name == "" {
+		kubernetesConfig.Name = "default"
+	}
+
+	// 格式: context=/subscriptions/.../managedClusters/name,context2=...
+	for _, item := range splitList(getenv("AKS_RESOURCE_IDS")) {
+		contextName, resourceID, ok := strings.Cut(item, "=")
+		if !ok || contextName == "" || resourceID == "" {
+			return KubernetesConfig{}, fmt.Errorf("Kubernetes账号 %s 的AKS_RESOURCE_IDS格式无效: %s", kubernetesConfig.Name, item)
+		}
+		kubernetesConfig.AKSResourceIDs[contextName] = resourceID
+	}
+
+	return kubernetesConfig, nil
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// VSphereConfig vCenter连接配置
+type VSphereConfig struct {
+	// Name 账号名称，用于区分同时同步的多个vCenter
+	Name string
+	// URL vCenter SDK地址，如 https://vcenter.example.com/sdk
+	URL      string
+	Username string
+	Password string
+	// Insecure 跳过TLS证书校验，用于自签名证书或vcsim模拟器
+	Insecure bool
+	// Datacenters 需要同步的数据中心，为空时同步全部数据中心
+	Datacenters []string
+}
+
+// loadVSphereAccounts 加载vCenter配置
+// 设置了VSPHERE_URL时启用默认vCenter，VSPHERE_ACCOUNTS中列出的其他vCenter使用 VSPHERE_<NAME>_ 前缀的环境变量
+func loadVSphereAccounts() ([]VSphereConfig, error) {
+	var names []string
+	if os.Getenv("VSPHERE_URL") != "" {
+		names = append(names, "")
+	}
+	names = append(names, splitList(os.Getenv("VSPHERE_ACCOUNTS"))...)
+
+	var accounts []VSphereConfig
+	for _, name := range names {
+		account := loadVSphereConfig(name)
+		if account.URL == "" {
+			return nil, fmt.Errorf("vCenter %s 未设置URL", account.Name)
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// loadVSphereConfig 加载vCenter配置，name为空时读取默认vCenter
+func loadVSphereConfig(name string) VSphereConfig {
+	getenv := func(key string) string {
+		if name == "" {
+			return os.Getenv("VSPHERE_" + key)
+		}
+		return os.Getenv("VSPHERE_" + strings.ToUpper(name) + "_" + key)
+	}
+
+	vsphereConfig := VSphereConfig{
+		Name:        name,
+		URL:         getenv("URL"),
+		Username:    getenv("USERNAME"),
+		Password:    getenv("PASSWORD"),
+		Insecure:    getenv("INSECURE") == "true",
+		Datacenters: splitList(getenv("DATACENTERS")),
+	}
+	if vsphereConfig.Name == "" {
+		vsphereConfig.Name = "default"
+	}
+
+	return vsphereConfig
+}
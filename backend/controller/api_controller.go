@@ -1,44 +1,102 @@
 package controller
 
 import (
-	"CMDB/azure"
+	"CMDB/model"
 	"CMDB/repository"
+	"CMDB/search"
+	"CMDB/service"
+	"CMDB/topology"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // APIController 结构体
 type APIController struct {
-	vmRepo       *repository.VMRepository
-	databaseRepo *repository.DatabaseRepository // 添加 DatabaseRepository
-	azureService *azure.AzureService
+	vmRepo              *repository.VMRepository
+	databaseRepo        *repository.DatabaseRepository // 添加 DatabaseRepository
+	resourceRepo        *repository.ResourceRepository
+	historyRepo         *repository.ChangeHistoryRepository
+	searchRepo          *repository.SearchRepository
+	syncService         *service.SyncService
+	tagService          *service.TagService
+	complianceService   *service.ComplianceService
+	ownerService        *service.OwnerService
+	relationshipService *service.RelationshipService
+	topologyService     *service.TopologyService
+	impactService       *service.ImpactService
+	applicationService  *service.ApplicationService
+	manualCIService     *service.ManualCIService
 }
 
 // NewAPIController 创建新的API控制器
 func NewAPIController(
 	vmRepo *repository.VMRepository,
 	databaseRepo *repository.DatabaseRepository, // 添加 DatabaseRepository
-	azureService *azure.AzureService,
+	resourceRepo *repository.ResourceRepository,
+	historyRepo *repository.ChangeHistoryRepository,
+	searchRepo *repository.SearchRepository,
+	syncService *service.SyncService,
+	tagService *service.TagService,
+	complianceService *service.ComplianceService,
+	ownerService *service.OwnerService,
+	relationshipService *service.RelationshipService,
+	topologyService *service.TopologyService,
+	impactService *service.ImpactService,
+	applicationService *service.ApplicationService,
+	manualCIService *service.ManualCIService,
 ) *APIController {
 	return &APIController{
-		vmRepo:       vmRepo,
-		databaseRepo: databaseRepo, // 初始化 DatabaseRepository
-		azureService: azureService,
+		vmRepo:              vmRepo,
+		databaseRepo:        databaseRepo, // 初始化 DatabaseRepository
+		resourceRepo:        resourceRepo,
+		historyRepo:         historyRepo,
+		searchRepo:          searchRepo,
+		syncService:         syncService,
+		tagService:          tagService,
+		complianceService:   complianceService,
+		ownerService:        ownerService,
+		relationshipService: relationshipService,
+		topologyService:     topologyService,
+		impactService:       impactService,
+		applicationService:  applicationService,
+		manualCIService:     manualCIService,
 	}
 }
 
-// HandleGetAllVMs 处理获取所有虚拟机的请求
+// HandleGetAllVMs 处理获取所有虚拟机的请求，指定as_of时返回该时刻的虚拟机清单
 func (c *APIController) HandleGetAllVMs(w http.ResponseWriter, r *http.Request) {
-	vms, err := c.vmRepo.ListVMs()
+	format, err := responseFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	asOf, err := parseAsOf(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var vms []*model.VM
+	if asOf != nil {
+		vms, err = c.vmRepo.ListVMsAsOf(*asOf)
+	} else {
+		vms, err = c.vmRepo.ListVMs(includeDeleted(r))
+	}
 	if err != nil {
 		http.Error(w, "Failed to get VMs", http.StatusInternalServerError)
 		log.Printf("Error getting VMs: %v", err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(vms)
+	if err := c.ownerService.AttachToVMs(vms); err != nil {
+		log.Printf("获取虚拟机所有者信息错误: %v", err)
+	}
+	writeList(w, r, format, "vms", vms, vms)
 }
 
 // HandleGetVMByID 处理根据ID获取虚拟机的请求
@@ -54,20 +112,43 @@ func (c *APIController) HandleGetVMByID(w http.ResponseWriter, r *http.Request)
 		log.Printf("Error getting VM by ID %s: %v", id, err)
 		return
 	}
+	if vm != nil {
+		if err := c.ownerService.AttachToVMs([]*model.VM{vm}); err != nil {
+			log.Printf("获取虚拟机 %s 所有者信息错误: %v", id, err)
+		}
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(vm)
 }
 
-// HandleGetAllDatabases 处理获取所有数据库的请求 (新增)
+// HandleGetAllDatabases 处理获取所有数据库的请求 (新增)，指定as_of时返回该时刻的数据库清单
 func (c *APIController) HandleGetAllDatabases(w http.ResponseWriter, r *http.Request) {
-	databases, err := c.databaseRepo.GetAllDatabases()
+	format, err := responseFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	asOf, err := parseAsOf(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var databases []*model.Database
+	if asOf != nil {
+		databases, err = c.databaseRepo.ListDatabasesAsOf(*asOf)
+	} else {
+		databases, err = c.databaseRepo.GetAllDatabases(includeDeleted(r))
+	}
 	if err != nil {
 		http.Error(w, "Failed to get databases", http.StatusInternalServerError)
 		log.Printf("Error getting databases: %v", err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(databases)
+	if err := c.ownerService.AttachToDatabases(databases); err != nil {
+		log.Printf("获取数据库所有者信息错误: %v", err)
+	}
+	writeList(w, r, format, "databases", databases, databases)
 }
 
 // HandleGetDatabaseByID 处理根据ID获取数据库的请求 (新增)
@@ -83,44 +164,214 @@ func (c *APIController) HandleGetDatabaseByID(w http.ResponseWriter, r *http.Req
 		log.Printf("Error getting database by ID %s: %v", id, err)
 		return
 	}
+	if database != nil {
+		if err := c.ownerService.AttachToDatabases([]*model.Database{database}); err != nil {
+			log.Printf("获取数据库 %s 所有者信息错误: %v", id, err)
+		}
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(database)
 }
 
 // HandleGetAllSQLDatabases 处理获取所有SQL数据库的请求
 func (c *APIController) HandleGetAllSQLDatabases(w http.ResponseWriter, r *http.Request) {
-	databases, err := c.databaseRepo.GetDatabasesByType("SQL Database")
+	format, err := responseFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	asOf, err := parseAsOf(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	databases, err := c.getDatabasesByType("SQL Database", asOf, includeDeleted(r))
 	if err != nil {
 		http.Error(w, "获取SQL数据库失败", http.StatusInternalServerError)
 		log.Printf("获取SQL数据库错误: %v", err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(databases)
+	writeList(w, r, format, "sql_databases", databases, databases)
 }
 
 // HandleGetAllSQLServers 处理获取所有SQL服务器的请求
 func (c *APIController) HandleGetAllSQLServers(w http.ResponseWriter, r *http.Request) {
-	databases, err := c.databaseRepo.GetDatabasesByType("SQL Server")
+	format, err := responseFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	asOf, err := parseAsOf(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	databases, err := c.getDatabasesByType("SQL Server", asOf, includeDeleted(r))
 	if err != nil {
 		http.Error(w, "获取SQL服务器失败", http.StatusInternalServerError)
 		log.Printf("获取SQL服务器错误: %v", err)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(databases)
+	writeList(w, r, format, "sql_servers", databases, databases)
 }
 
 // HandleGetAllMySQLFlexibles 处理获取所有MySQL灵活服务器的请求
 func (c *APIController) HandleGetAllMySQLFlexibles(w http.ResponseWriter, r *http.Request) {
-	databases, err := c.databaseRepo.GetDatabasesByType("MySQL Flexible Server")
+	format, err := responseFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	asOf, err := parseAsOf(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	databases, err := c.getDatabasesByType("MySQL Flexible Server", asOf, includeDeleted(r))
 	if err != nil {
 		http.Error(w, "获取MySQL灵活服务器失败", http.StatusInternalServerError)
 		log.Printf("获取MySQL灵活服务器错误: %v", err)
 		return
 	}
+	writeList(w, r, format, "mysql_flexible_servers", databases, databases)
+}
+
+// getDatabasesByType 获取指定类型的数据库，asOf不为空时返回该时刻的数据库清单
+func (c *APIController) getDatabasesByType(dbType string, asOf *time.Time, includeDeleted bool) ([]*model.Database, error) {
+	if asOf != nil {
+		return c.databaseRepo.GetDatabasesByTypeAsOf(dbType, *asOf)
+	}
+	return c.databaseRepo.GetDatabasesByType(dbType, includeDeleted)
+}
+
+// HandleListResources 处理分页查询资源的请求
+// 支持 provider、type、location、subscription、resource_group、owner、owner_source、status、tag_key、tag_value 过滤，
+// sort 指定排序字段（order=desc时倒序），limit 默认为100、offset 默认为0；指定as_of时查询该时刻的资源清单
+// 导出CSV/XLSX且未指定limit时导出全部满足条件的资源
+func (c *APIController) HandleListResources(w http.ResponseWriter, r *http.Request) {
+	format, err := responseFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseResourceFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	asOf, err := parseAsOf(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	listPage := func(filter model.ResourceFilter) (*model.ResourcePage, error) {
+		if asOf != nil {
+			return c.resourceRepo.ListResourcesAsOf(filter, *asOf)
+		}
+		return c.resourceRepo.ListResources(filter)
+	}
+
+	fail := func(err error) {
+		http.Error(w, "获取资源失败", http.StatusInternalServerError)
+		log.Printf("获取资源错误: %v", err)
+	}
+	if exportAll(format, r) {
+		listKeys := c.resourceRepo.ListResourceKeys
+		if asOf != nil {
+			listKeys = func(filter model.ResourceFilter) ([]string, []string, error) {
+				return c.resourceRepo.ListResourceKeysAsOf(filter, *asOf)
+			}
+		}
+		c.streamResources(w, r, format, "resources", filter, listPage, listKeys, fail)
+		return
+	}
+
+	page, err := listPage(filter)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if err := c.ownerService.AttachToResources(page.Items); err != nil {
+		log.Printf("获取资源所有者信息错误: %v", err)
+	}
+	writeList(w, r, format, "resources", page, page.Items)
+}
+
+// streamResources 分页导出全部满足条件的资源，每页附加所有者信息；listKeys查询标签和属性展开的列
+func (c *APIController) streamResources(w http.ResponseWriter, r *http.Request, format, name string, filter model.ResourceFilter,
+	list func(model.ResourceFilter) (*model.ResourcePage, error), listKeys func(model.ResourceFilter) ([]string, []string, error), fail func(err error)) {
+	keys := func() (map[string][]string, error) {
+		tagKeys, attributeKeys, err := listKeys(filter)
+		return resourceKeys(tagKeys, attributeKeys), err
+	}
+	streamPages(w, r, format, name, keys, func(limit, offset int) ([]*model.Resource, int, error) {
+		filter.Limit, filter.Offset = limit, offset
+		page, err := list(filter)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := c.ownerService.AttachToResources(page.Items); err != nil {
+			log.Printf("获取资源所有者信息错误: %v", err)
+		}
+		return page.Items, page.Total, nil
+	}, fail)
+}
+
+// parseResourceFilter 解析资源列表的过滤、排序和分页参数
+func parseResourceFilter(r *http.Request) (model.ResourceFilter, error) {
+	query := r.URL.Query()
+	filter := model.ResourceFilter{
+		Provider:       query.Get("provider"),
+		ResourceType:   query.Get("type"),
+		Location:       query.Get("location"),
+		SubscriptionID: query.Get("subscription"),
+		ResourceGroup:  query.Get("resource_group"),
+		Owner:          query.Get("owner"),
+		OwnerSource:    query.Get("owner_source"),
+		Source:         query.Get("source"),
+		Status:         query.Get("status"),
+		TagKey:         query.Get("tag_key"),
+		TagValue:       query.Get("tag_value"),
+		IncludeDeleted: includeDeleted(r),
+		Sort:           query.Get("sort"),
+		Desc:           strings.EqualFold(query.Get("order"), "desc"),
+	}
+	if filter.TagValue != "" && filter.TagKey == "" {
+		return filter, fmt.Errorf("指定tag_value时必须同时指定tag_key")
+	}
+	if filter.Sort != "" && !model.ResourceSortFields[filter.Sort] {
+		return filter, fmt.Errorf("不支持的排序字段: %s", filter.Sort)
+	}
+	var err error
+	filter.Limit, filter.Offset, err = parsePagination(r, 100)
+	return filter, err
+}
+
+// HandleGetResourceByID 处理根据ID获取资源的请求，已删除的资源同样返回
+func (c *APIController) HandleGetResourceByID(w http.ResponseWriter, r *http.Request, resourceID string) {
+	if resourceID == "" {
+		http.Error(w, "Resource ID is required", http.StatusBadRequest)
+		return
+	}
+
+	resource, err := c.resourceRepo.GetResourceByID(resourceID)
+	if err != nil {
+		http.Error(w, "获取资源失败", http.StatusInternalServerError)
+		log.Printf("获取资源 %s 错误: %v", resourceID, err)
+		return
+	}
+	if resource == nil {
+		http.Error(w, "Resource not found", http.StatusNotFound)
+		return
+	}
+	if err := c.ownerService.AttachToResources([]*model.Resource{resource}); err != nil {
+		log.Printf("获取资源 %s 所有者信息错误: %v", resourceID, err)
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(databases)
+	json.NewEncoder(w).Encode(resource)
 }
 
 // HandleSyncResources 处理同步资源的请求
@@ -133,7 +384,7 @@ func (c *APIController) HandleSyncResources(w http.ResponseWriter, r *http.Reque
 	// 异步执行同步任务
 	go func() {
 		log.Println("Starting resource synchronization...")
-		err := c.azureService.SyncAllResources()
+		err := c.syncService.SyncAllResources(model.SyncTriggerManual)
 		if err != nil {
 			log.Printf("Error during resource synchronization: %v", err)
 		} else {
@@ -145,6 +396,900 @@ func (c *APIController) HandleSyncResources(w http.ResponseWriter, r *http.Reque
 	w.Write([]byte("Resource synchronization started"))
 }
 
+// HandleGetSyncResults 处理获取各同步范围最近同步结果的请求
+func (c *APIController) HandleGetSyncResults(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.syncService.GetLastSyncResults())
+}
+
+// HandleGetSyncTasks 处理获取同步任务列表的请求
+// 支持 provider、account、task_type、status 过滤，limit 默认为50
+func (c *APIController) HandleGetSyncTasks(w http.ResponseWriter, r *http.Request) {
+	format, err := responseFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := model.SyncTaskFilter{
+		Provider: query.Get("provider"),
+		Account:  query.Get("account"),
+		TaskType: query.Get("task_type"),
+		Status:   strings.ToUpper(query.Get("status")),
+		Limit:    50,
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 || n > 1000 {
+			http.Error(w, "limit必须是1到1000之间的整数", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = n
+	}
+
+	tasks, err := c.syncService.ListSyncTasks(filter)
+	if err != nil {
+		http.Error(w, "获取同步任务失败", http.StatusInternalServerError)
+		log.Printf("获取同步任务错误: %v", err)
+		return
+	}
+
+	lastSyncTime, err := c.syncService.GetLastSyncTime()
+	if err != nil {
+		http.Error(w, "获取最后同步时间失败", http.StatusInternalServerError)
+		log.Printf("获取最后同步时间错误: %v", err)
+		return
+	}
+
+	response := struct {
+		LastSyncTime *time.Time        `json:"last_sync_time"`
+		Tasks        []*model.SyncTask `json:"tasks"`
+	}{Tasks: tasks}
+	if !lastSyncTime.IsZero() {
+		response.LastSyncTime = &lastSyncTime
+	}
+
+	writeList(w, r, format, "sync_tasks", response, tasks)
+}
+
+// HandleGetSyncTaskByID 处理根据ID获取同步任务的请求
+func (c *APIController) HandleGetSyncTaskByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/api/sync/tasks/"), 10, 64)
+	if err != nil {
+		http.Error(w, "无效的任务ID", http.StatusBadRequest)
+		return
+	}
+
+	task, err := c.syncService.GetSyncTask(id)
+	if err != nil {
+		http.Error(w, "获取同步任务失败", http.StatusInternalServerError)
+		log.Printf("获取同步任务 %d 错误: %v", id, err)
+		return
+	}
+	if task == nil {
+		http.Error(w, "同步任务不存在", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+// HandleGetDiagnostics 处理获取Provider诊断信息的请求，用于确认同步使用的身份
+// 路径 /api/diagnostics/{provider} 只返回指定平台的信息
+func (c *APIController) HandleGetDiagnostics(w http.ResponseWriter, r *http.Request) {
+	name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/diagnostics"), "/")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.syncService.GetDiagnostics(name))
+}
+
+// resourceViews 资源的视图，通过查询参数view或以 /{view} 结尾的路径访问
+var resourceViews = []string{"history", "relationships", "graph", "impact"}
+
+// HandleResourcePath 处理 /api/resources/{id} 的请求，查询参数view指定返回资源的哪个视图：
+// history 变更历史、relationships 直接关系、graph 关系图、impact 影响分析，未指定时返回资源本身
+// 兼容以 /{view} 结尾的路径；此时若完整路径本身就是资源ID（如名为history的资源），优先返回该资源，
+// 这类资源的视图只能通过view参数访问
+// Azure资源ID以"/"开头且包含"/"，请求时需要对ID做URL编码（如 %2Fsubscriptions%2F...）
+func (c *APIController) HandleResourcePath(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/resources/")
+	view := r.URL.Query().Get("view")
+	if view == "" {
+		for _, candidate := range resourceViews {
+			if !strings.HasSuffix(path, "/"+candidate) {
+				continue
+			}
+			resource, err := c.resourceRepo.GetResourceByID(path)
+			if err != nil {
+				http.Error(w, "获取资源失败", http.StatusInternalServerError)
+				log.Printf("获取资源 %s 错误: %v", path, err)
+				return
+			}
+			if resource == nil {
+				view = candidate
+				path = strings.TrimSuffix(path, "/"+candidate)
+			}
+			break
+		}
+	}
+
+	switch view {
+	case "":
+		c.HandleGetResourceByID(w, r, path)
+	case "history":
+		c.HandleGetResourceHistory(w, r, path)
+	case "relationships":
+		c.HandleGetRelationships(w, r, path)
+	case "graph":
+		c.HandleGetResourceGraph(w, r, path)
+	case "impact":
+		c.HandleGetImpact(w, r, path)
+	default:
+		http.Error(w, "不支持的view: "+view+"，可选 "+strings.Join(resourceViews, "、"), http.StatusBadRequest)
+	}
+}
+
+// HandleGetRelationships 处理获取资源直接关系的请求
+// 支持 direction（outgoing、incoming、both，默认both）和 type（逗号分隔的关系类型）过滤
+func (c *APIController) HandleGetRelationships(w http.ResponseWriter, r *http.Request, resourceID string) {
+	if resourceID == "" {
+		http.Error(w, "Resource ID is required", http.StatusBadRequest)
+		return
+	}
+	format, err := responseFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	relationships, err := c.relationshipService.ListRelationships(resourceID, parseRelationshipFilter(r))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "获取资源关系失败", http.StatusInternalServerError)
+		log.Printf("获取资源 %s 关系错误: %v", resourceID, err)
+		return
+	}
+	writeList(w, r, format, "relationships", relationships, relationships)
+}
+
+// HandleGetResourceGraph 处理从资源出发遍历关系图的请求
+// depth 指定遍历层数（默认2，最大5），direction 和 type 与直接关系查询相同
+func (c *APIController) HandleGetResourceGraph(w http.ResponseWriter, r *http.Request, resourceID string) {
+	if resourceID == "" {
+		http.Error(w, "Resource ID is required", http.StatusBadRequest)
+		return
+	}
+	depth := 2
+	if value := r.URL.Query().Get("depth"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "depth必须是整数", http.StatusBadRequest)
+			return
+		}
+		depth = n
+	}
+
+	graph, err := c.relationshipService.Traverse(resourceID, depth, parseRelationshipFilter(r))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "获取资源关系图失败", http.StatusInternalServerError)
+		log.Printf("获取资源 %s 关系图错误: %v", resourceID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(graph)
+}
+
+// HandleGetImpact 处理影响分析请求，返回对资源做维护时直接或间接依赖它的虚拟机、数据库、其他CI及其所有者
+// depth 指定依赖的层数（默认3，最大5）；导出CSV/XLSX时每行为一个受影响的CI，可作为变更单附件
+func (c *APIController) HandleGetImpact(w http.ResponseWriter, r *http.Request, resourceID string) {
+	if resourceID == "" {
+		http.Error(w, "Resource ID is required", http.StatusBadRequest)
+		return
+	}
+	format, err := responseFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	depth := 3
+	if value := r.URL.Query().Get("depth"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "depth必须是整数", http.StatusBadRequest)
+			return
+		}
+		depth = n
+	}
+
+	report, err := c.impactService.Analyze(resourceID, depth)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "影响分析失败", http.StatusInternalServerError)
+		log.Printf("资源 %s 影响分析错误: %v", resourceID, err)
+		return
+	}
+	if report == nil {
+		http.Error(w, "Resource not found", http.StatusNotFound)
+		return
+	}
+	writeList(w, r, format, "impact", report, report.Items())
+}
+
+// parseRelationshipFilter 解析关系查询参数direction和type
+func parseRelationshipFilter(r *http.Request) model.RelationshipFilter {
+	query := r.URL.Query()
+	filter := model.RelationshipFilter{Direction: query.Get("direction")}
+	for _, value := range strings.Split(query.Get("type"), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			filter.Types = append(filter.Types, value)
+		}
+	}
+	return filter
+}
+
+// HandleGetTopology 处理获取拓扑图的请求，用于前端绘制架构图
+// 指定 root 时从该资源出发遍历 depth 层（默认2，最大5）；否则以满足 provider、subscription、resource_group、
+// resource_type、tag_key、tag_value 条件的资源（最多500个）及与其直接相连的节点作为拓扑，两者至少指定其一
+// direction 和 type 与关系图查询相同；group_by 为逗号分隔的 resource_group、vnet（默认两者都分组），none 表示不分组
+// format 为 cytoscape（默认，Cytoscape JSON）或 dot（Graphviz DOT），节点坐标为服务端计算的布局
+func (c *APIController) HandleGetTopology(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := strings.ToLower(query.Get("format"))
+	if format != "" && format != "cytoscape" && format != "dot" {
+		http.Error(w, "不支持的格式: "+format+"，可选 cytoscape、dot", http.StatusBadRequest)
+		return
+	}
+
+	filter := model.TopologyFilter{
+		Root:           query.Get("root"),
+		Depth:          2,
+		Provider:       query.Get("provider"),
+		SubscriptionID: query.Get("subscription"),
+		ResourceGroup:  query.Get("resource_group"),
+		ResourceType:   query.Get("resource_type"),
+		TagKey:         query.Get("tag_key"),
+		TagValue:       query.Get("tag_value"),
+		Relationship:   parseRelationshipFilter(r),
+	}
+	if value := query.Get("depth"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "depth必须是整数", http.StatusBadRequest)
+			return
+		}
+		filter.Depth = n
+	}
+	groupBy := query.Get("group_by")
+	if groupBy == "" {
+		groupBy = topology.GroupResourceGroup + "," + topology.GroupVNet
+	}
+	for _, value := range strings.Split(groupBy, ",") {
+		switch strings.TrimSpace(value) {
+		case topology.GroupResourceGroup:
+			filter.GroupByResourceGroup = true
+		case topology.GroupVNet:
+			filter.GroupByVNet = true
+		case "none", "":
+		default:
+			http.Error(w, "不支持的分组: "+value+"，可选 resource_group、vnet、none", http.StatusBadRequest)
+			return
+		}
+	}
+
+	graph, err := c.topologyService.Topology(filter)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "获取拓扑失败", http.StatusInternalServerError)
+		log.Printf("获取拓扑错误: %v", err)
+		return
+	}
+
+	result := topology.Build(graph, topology.Options{
+		GroupByResourceGroup: filter.GroupByResourceGroup,
+		GroupByVNet:          filter.GroupByVNet,
+	})
+	if format == "dot" {
+		w.Header().Set("Content-Type", topology.ContentTypeDOT)
+		if err := result.WriteDOT(w); err != nil {
+			log.Printf("输出拓扑DOT错误: %v", err)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result.Cytoscape())
+}
+
+// HandleGetResourceHistory 处理获取资源变更历史的请求，按时间倒序返回字段级变更
+// 包含同一资源ID下虚拟机和数据库记录的变更，limit 默认为100
+func (c *APIController) HandleGetResourceHistory(w http.ResponseWriter, r *http.Request, resourceID string) {
+	if resourceID == "" {
+		http.Error(w, "Resource ID is required", http.StatusBadRequest)
+		return
+	}
+	format, err := responseFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit := 100
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > 1000 {
+			http.Error(w, "limit必须是1到1000之间的整数", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	changes, err := c.historyRepo.ListByResourceID(resourceID, limit)
+	if err != nil {
+		http.Error(w, "获取变更历史失败", http.StatusInternalServerError)
+		log.Printf("获取资源 %s 变更历史错误: %v", resourceID, err)
+		return
+	}
+
+	writeList(w, r, format, "resource_history", changes, changes)
+}
+
+// includeDeleted 查询参数include_deleted为true时列表包含已在平台删除的记录
+func includeDeleted(r *http.Request) bool {
+	value, _ := strconv.ParseBool(r.URL.Query().Get("include_deleted"))
+	return value
+}
+
+// HandleSearch 处理搜索请求，q为查询语言表达式，语法见search包
+// 查询有语法错误时返回400，响应中的position为出错的字符位置（从1开始）；导出CSV/XLSX且未指定limit时导出全部结果
+func (c *APIController) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if strings.TrimSpace(q) == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	format, err := responseFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit, offset, err := parsePagination(r, 100)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query, err := search.Parse(q)
+	if err != nil {
+		var syntaxErr *search.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":    syntaxErr.Msg,
+				"position": syntaxErr.Pos,
+			})
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fail := func(err error) {
+		http.Error(w, "搜索失败", http.StatusInternalServerError)
+		log.Printf("搜索 %q 错误: %v", q, err)
+	}
+	if exportAll(format, r) {
+		streamPages(w, r, format, "search", nil, func(limit, offset int) ([]*model.SearchResult, int, error) {
+			p, err := c.searchRepo.Search(query, includeDeleted(r), limit, offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			return p.Items, p.Total, nil
+		}, fail)
+		return
+	}
+
+	page, err := c.searchRepo.Search(query, includeDeleted(r), limit, offset)
+	if err != nil {
+		fail(err)
+		return
+	}
+	writeList(w, r, format, "search", page, page.Items)
+}
+
+// HandleTextSearch 处理全文搜索请求，在名称、资源ID、所有者和标签中检索q中的全部词，按相关度排序
+// 结果中的highlights为匹配字段的高亮内容，匹配部分以<em>标记，其余部分已做HTML转义；导出CSV/XLSX且未指定limit时导出全部结果
+func (c *APIController) HandleTextSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if strings.TrimSpace(q) == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	format, err := responseFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit, offset, err := parsePagination(r, 50)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fail := func(err error) {
+		http.Error(w, "全文搜索失败", http.StatusInternalServerError)
+		log.Printf("全文搜索 %q 错误: %v", q, err)
+	}
+	if exportAll(format, r) {
+		keys := func() (map[string][]string, error) {
+			tagKeys, err := c.searchRepo.TextSearchTagKeys(q, includeDeleted(r))
+			if err != nil {
+				return nil, err
+			}
+			// 高亮的字段为名称、ID、所有者和各标签
+			highlights := []string{"name", "item_id", "owner"}
+			for _, key := range tagKeys {
+				highlights = append(highlights, model.TagFieldPrefix+key)
+			}
+			return map[string][]string{"tags": tagKeys, "highlights": highlights}, nil
+		}
+		streamPages(w, r, format, "text_search", keys, func(limit, offset int) ([]*model.TextSearchResult, int, error) {
+			p, err := c.searchRepo.TextSearch(q, includeDeleted(r), limit, offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			return p.Items, p.Total, nil
+		}, fail)
+		return
+	}
+
+	page, err := c.searchRepo.TextSearch(q, includeDeleted(r), limit, offset)
+	if err != nil {
+		fail(err)
+		return
+	}
+	writeList(w, r, format, "text_search", page, page.Items)
+}
+
+// HandleBulkTags 处理批量编辑标签的请求，请求体见model.BulkTagRequest
+// dry_run为true时只返回受影响的资源及修改后的标签；否则写回云平台，响应中逐个资源给出成功或失败
+func (c *APIController) HandleBulkTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req model.BulkTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "无效的请求体: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := c.tagService.BulkUpdateTags(req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "批量编辑标签失败", http.StatusInternalServerError)
+		log.Printf("批量编辑标签错误: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// HandleGetCompliance 处理获取标签合规报告的请求，报告基于最近一次同步后的策略评估
+// 支持 subscription、owner、owner_source 过滤；不合规资源列表按 limit（默认100）、offset 分页；days 指定趋势的天数，默认为30
+func (c *APIController) HandleGetCompliance(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := model.ComplianceFilter{
+		SubscriptionID: query.Get("subscription"),
+		Owner:          query.Get("owner"),
+		OwnerSource:    query.Get("owner_source"),
+	}
+	var err error
+	filter.Limit, filter.Offset, err = parsePagination(r, 100)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	days := 30
+	if value := query.Get("days"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > 366 {
+			http.Error(w, "days必须是1到366之间的整数", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+
+	report, err := c.complianceService.Report(filter, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		http.Error(w, "获取合规报告失败", http.StatusInternalServerError)
+		log.Printf("获取合规报告错误: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// HandleListOwners 处理获取所有者目录的请求，列出已解析的所有者及其名下的资源数
+// 支持 status 过滤（active、disabled、not_found、unresolvable），用于找出账号已禁用或已不存在的所有者
+func (c *APIController) HandleListOwners(w http.ResponseWriter, r *http.Request) {
+	format, err := responseFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	owners, err := c.ownerService.ListOwners(r.URL.Query().Get("status"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "获取所有者失败", http.StatusInternalServerError)
+		log.Printf("获取所有者错误: %v", err)
+		return
+	}
+	writeList(w, r, format, "owners", owners, owners)
+}
+
+// HandleApplications 处理 /api/applications 请求：GET 列出应用和业务服务，支持 kind、criticality、owner 过滤；
+// POST 创建应用，请求体见model.Application
+func (c *APIController) HandleApplications(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		format, err := responseFormat(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		query := r.URL.Query()
+		applications, err := c.applicationService.ListApplications(model.ApplicationFilter{
+			Kind:        query.Get("kind"),
+			Criticality: query.Get("criticality"),
+			Owner:       query.Get("owner"),
+		})
+		if err != nil {
+			writeApplicationError(w, err, "获取应用失败")
+			return
+		}
+		if err := c.ownerService.AttachToApplications(applications); err != nil {
+			log.Printf("获取应用所有者信息错误: %v", err)
+		}
+		writeList(w, r, format, "applications", applications, applications)
+	case http.MethodPost:
+		var req model.Application
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "无效的请求体: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		application, err := c.applicationService.CreateApplication(&req)
+		if err != nil {
+			writeApplicationError(w, err, "创建应用失败")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(application)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleApplicationPath 处理 /api/applications/{id} 及其子路径的请求：
+// {id} 支持 GET、PUT（tag_rules、members未指定时保持不变）、DELETE；
+// {id}/resources 分页列出属于应用的资源，过滤、排序和导出与资源列表相同；
+// {id}/members 以 POST 添加、DELETE 移除手动成员，请求体见model.ApplicationMembersRequest
+func (c *APIController) HandleApplicationPath(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/applications/"), "/")
+	idPart, sub, _ := strings.Cut(path, "/")
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "无效的应用ID", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case sub == "resources" && r.Method == http.MethodGet:
+		c.HandleGetApplicationResources(w, r, id)
+	case sub == "members" && (r.Method == http.MethodPost || r.Method == http.MethodDelete):
+		var req model.ApplicationMembersRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "无效的请求体: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		var application *model.Application
+		if r.Method == http.MethodPost {
+			application, err = c.applicationService.AddMembers(id, req)
+		} else {
+			application, err = c.applicationService.RemoveMembers(id, req)
+		}
+		c.writeApplication(w, application, err, "修改应用成员失败")
+	case sub == "" && r.Method == http.MethodGet:
+		application, err := c.applicationService.GetApplication(id)
+		c.writeApplication(w, application, err, "获取应用失败")
+	case sub == "" && r.Method == http.MethodPut:
+		var req model.Application
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "无效的请求体: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		application, err := c.applicationService.UpdateApplication(id, &req)
+		c.writeApplication(w, application, err, "更新应用失败")
+	case sub == "" && r.Method == http.MethodDelete:
+		deleted, err := c.applicationService.DeleteApplication(id)
+		if err != nil {
+			writeApplicationError(w, err, "删除应用失败")
+			return
+		}
+		if !deleted {
+			http.Error(w, "Application not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case sub == "" || sub == "resources" || sub == "members":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// HandleGetApplicationResources 处理分页列出应用成员资源的请求，响应的total为应用的资源总数
+func (c *APIController) HandleGetApplicationResources(w http.ResponseWriter, r *http.Request, id int64) {
+	format, err := responseFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := parseResourceFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	application, err := c.applicationService.GetApplication(id)
+	if err != nil {
+		writeApplicationError(w, err, "获取应用失败")
+		return
+	}
+	if application == nil {
+		http.Error(w, "Application not found", http.StatusNotFound)
+		return
+	}
+
+	fail := func(err error) {
+		writeApplicationError(w, err, "获取应用资源失败")
+	}
+	if exportAll(format, r) {
+		c.streamResources(w, r, format, "application_resources", filter,
+			func(filter model.ResourceFilter) (*model.ResourcePage, error) {
+				return c.applicationService.ListResources(id, filter)
+			},
+			func(filter model.ResourceFilter) ([]string, []string, error) {
+				return c.applicationService.ListResourceKeys(id, filter)
+			}, fail)
+		return
+	}
+
+	page, err := c.applicationService.ListResources(id, filter)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if err := c.ownerService.AttachToResources(page.Items); err != nil {
+		log.Printf("获取资源所有者信息错误: %v", err)
+	}
+	writeList(w, r, format, "application_resources", page, page.Items)
+}
+
+// writeApplication 输出单个应用，应用不存在时返回404
+func (c *APIController) writeApplication(w http.ResponseWriter, application *model.Application, err error, message string) {
+	if err != nil {
+		writeApplicationError(w, err, message)
+		return
+	}
+	if application == nil {
+		http.Error(w, "Application not found", http.StatusNotFound)
+		return
+	}
+	if err := c.ownerService.AttachToApplications([]*model.Application{application}); err != nil {
+		log.Printf("获取应用 %d 所有者信息错误: %v", application.ID, err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(application)
+}
+
+// writeApplicationError 输出应用接口的错误，请求无效时返回400
+func writeApplicationError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, service.ErrInvalidRequest) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Error(w, message, http.StatusInternalServerError)
+	log.Printf("%s: %v", message, err)
+}
+
+// HandleListCIClasses 处理获取手动维护的CI的类定义的请求
+func (c *APIController) HandleListCIClasses(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.manualCIService.Classes())
+}
+
+// HandleManualCIs 处理 /api/manual-cis 的请求：
+// GET 分页列出手动维护的CI，过滤、排序和导出与资源列表相同，type为类名；
+// POST 创建手动CI，请求体见model.ManualCIRequest，属性和标签按类定义校验
+func (c *APIController) HandleManualCIs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		format, err := responseFormat(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter, err := parseResourceFilter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		fail := func(err error) {
+			writeManualCIError(w, err, "获取手动CI失败")
+		}
+		if exportAll(format, r) {
+			c.streamResources(w, r, format, "manual_cis", filter, c.manualCIService.ListManualCIs, c.manualCIService.ListManualCIKeys, fail)
+			return
+		}
+
+		page, err := c.manualCIService.ListManualCIs(filter)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if err := c.ownerService.AttachToResources(page.Items); err != nil {
+			log.Printf("获取资源所有者信息错误: %v", err)
+		}
+		writeList(w, r, format, "manual_cis", page, page.Items)
+	case http.MethodPost:
+		var req model.ManualCIRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "无效的请求体: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		resource, err := c.manualCIService.CreateManualCI(&req)
+		if err != nil {
+			writeManualCIError(w, err, "创建手动CI失败")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(resource)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleManualCIPath 处理 /api/manual-cis/{id} 的请求，支持 GET、PUT（整体替换属性和标签）、DELETE
+// {id} 为手动CI的资源ID，如 /api/manual-cis/manual/physical_server/db-01，开头的/可以省略
+func (c *APIController) HandleManualCIPath(w http.ResponseWriter, r *http.Request) {
+	id := "/" + strings.TrimLeft(strings.TrimPrefix(r.URL.Path, "/api/manual-cis/"), "/")
+	if id == "/" {
+		http.Error(w, "Resource ID is required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		resource, err := c.manualCIService.GetManualCI(id)
+		c.writeManualCI(w, resource, err, "获取手动CI失败")
+	case http.MethodPut:
+		var req model.ManualCIRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "无效的请求体: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		resource, err := c.manualCIService.UpdateManualCI(id, &req)
+		c.writeManualCI(w, resource, err, "更新手动CI失败")
+	case http.MethodDelete:
+		deleted, err := c.manualCIService.DeleteManualCI(id)
+		if err != nil {
+			writeManualCIError(w, err, "删除手动CI失败")
+			return
+		}
+		if !deleted {
+			http.Error(w, "Manual CI not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// writeManualCI 输出单个手动CI，不存在时返回404
+func (c *APIController) writeManualCI(w http.ResponseWriter, resource *model.Resource, err error, message string) {
+	if err != nil {
+		writeManualCIError(w, err, message)
+		return
+	}
+	if resource == nil {
+		http.Error(w, "Manual CI not found", http.StatusNotFound)
+		return
+	}
+	if err := c.ownerService.AttachToResources([]*model.Resource{resource}); err != nil {
+		log.Printf("获取资源 %s 所有者信息错误: %v", resource.ResourceID, err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resource)
+}
+
+// writeManualCIError 输出手动CI接口的错误，请求无效时返回400
+func writeManualCIError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, service.ErrInvalidRequest) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Error(w, message, http.StatusInternalServerError)
+	log.Printf("%s: %v", message, err)
+}
+
+// parsePagination 解析分页参数limit和offset，limit默认为defaultLimit，最大为1000
+func parsePagination(r *http.Request, defaultLimit int) (int, int, error) {
+	query := r.URL.Query()
+	limit, offset := defaultLimit, 0
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > 1000 {
+			return 0, 0, fmt.Errorf("limit必须是1到1000之间的整数")
+		}
+		limit = n
+	}
+	if value := query.Get("offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return 0, 0, fmt.Errorf("offset必须是非负整数")
+		}
+		offset = n
+	}
+	return limit, offset, nil
+}
+
+// parseAsOf 解析查询参数as_of，支持RFC3339时间或日期（YYYY-MM-DD，按本地时间当天0点），未指定时返回nil
+func parseAsOf(r *http.Request) (*time.Time, error) {
+	value := r.URL.Query().Get("as_of")
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return &t, nil
+	}
+	return nil, fmt.Errorf("无效的as_of: %s，应为RFC3339时间或YYYY-MM-DD日期", value)
+}
+
 // RegisterRoutes 注册API路由
 func (c *APIController) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/api/vms", c.HandleGetAllVMs)
@@ -152,4 +1297,24 @@ func (c *APIController) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/api/sqldatabase", c.HandleGetAllSQLDatabases)
 	mux.HandleFunc("/api/sqlserver", c.HandleGetAllSQLServers)
 	mux.HandleFunc("/api/mysqlflexible", c.HandleGetAllMySQLFlexibles)
+	mux.HandleFunc("/api/sync", c.HandleSyncResources)
+	mux.HandleFunc("/api/sync/results", c.HandleGetSyncResults)
+	mux.HandleFunc("/api/sync/tasks", c.HandleGetSyncTasks)
+	mux.HandleFunc("/api/sync/tasks/", c.HandleGetSyncTaskByID)
+	mux.HandleFunc("/api/diagnostics", c.HandleGetDiagnostics)
+	mux.HandleFunc("/api/diagnostics/", c.HandleGetDiagnostics)
+	mux.HandleFunc("/api/databases", c.HandleGetAllDatabases)
+	mux.HandleFunc("/api/resources", c.HandleListResources)
+	mux.HandleFunc("/api/resources/", c.HandleResourcePath)
+	mux.HandleFunc("/api/search", c.HandleSearch)
+	mux.HandleFunc("/api/search/text", c.HandleTextSearch)
+	mux.HandleFunc("/api/tags/bulk", c.HandleBulkTags)
+	mux.HandleFunc("/api/compliance", c.HandleGetCompliance)
+	mux.HandleFunc("/api/owners", c.HandleListOwners)
+	mux.HandleFunc("/api/topology", c.HandleGetTopology)
+	mux.HandleFunc("/api/applications", c.HandleApplications)
+	mux.HandleFunc("/api/applications/", c.HandleApplicationPath)
+	mux.HandleFunc("/api/ci-classes", c.HandleListCIClasses)
+	mux.HandleFunc("/api/manual-cis", c.HandleManualCIs)
+	mux.HandleFunc("/api/manual-cis/", c.HandleManualCIPath)
 }
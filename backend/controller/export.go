@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"CMDB/export"
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// exportPageSize 导出全部记录时每次查询的条数
+const exportPageSize = 1000
+
+// exportControlParams 控制输出方式而不是过滤数据的查询参数，不计入导出文件名
+var exportControlParams = map[string]bool{
+	"format":  true,
+	"columns": true,
+	"limit":   true,
+	"offset":  true,
+	"sort":    true,
+	"order":   true,
+}
+
+// responseFormat 确定列表接口的响应格式，format参数（json、csv、xlsx）优先，其次为Accept头，默认为JSON
+func responseFormat(r *http.Request) (string, error) {
+	return export.NegotiateFormat(r.URL.Query().Get("format"), r.Header.Get("Accept"))
+}
+
+// exportAll 分页接口导出CSV/XLSX且未指定limit时导出全部匹配的记录，而不是只导出一页
+func exportAll(format string, r *http.Request) bool {
+	return format != export.FormatJSON && r.URL.Query().Get("limit") == ""
+}
+
+// streamPages 分页查询并逐页导出全部记录，内存中只保留当前页，用于未指定limit的CSV/XLSX导出
+// keys查询map字段（如tags）展开的全部键，为nil时不展开map字段；fetch返回当前页和总数
+// keys或第一页查询失败时调用fail输出错误响应，开始输出之后的错误只能记录日志
+func streamPages[T any](w http.ResponseWriter, r *http.Request, format, name string,
+	keys func() (map[string][]string, error), fetch func(limit, offset int) ([]T, int, error), fail func(err error)) {
+	var mapKeys map[string][]string
+	if keys != nil {
+		var err error
+		if mapKeys, err = keys(); err != nil {
+			fail(err)
+			return
+		}
+	}
+	layout, err := export.NewLayout([]T(nil), exportColumns(r), mapKeys)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	items, total, err := fetch(exportPageSize, 0)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	filename := startExport(w, r, format, name)
+	writer, err := export.NewWriter(w, format, layout)
+	if err != nil {
+		log.Printf("导出 %s 错误: %v", filename, err)
+		return
+	}
+	defer func() {
+		if err := writer.Close(); err != nil {
+			log.Printf("导出 %s 错误: %v", filename, err)
+		}
+	}()
+	for offset := 0; ; offset += exportPageSize {
+		if err := writer.WriteRows(items); err != nil {
+			// 响应头已发送，只能记录错误
+			log.Printf("导出 %s 错误: %v", filename, err)
+			return
+		}
+		if len(items) < exportPageSize || offset+len(items) >= total {
+			return
+		}
+		if items, total, err = fetch(exportPageSize, offset+exportPageSize); err != nil {
+			log.Printf("导出 %s 时查询第%d条之后的记录错误: %v", filename, offset+exportPageSize, err)
+			return
+		}
+	}
+}
+
+// resourceKeys 资源的标签和属性按键展开的列
+func resourceKeys(tagKeys, attributeKeys []string) map[string][]string {
+	return map[string][]string{"tags": tagKeys, "attributes": attributeKeys}
+}
+
+// writeList 按format输出列表接口的结果：JSON时输出body；CSV/XLSX时将items导出为附件
+// columns参数以逗号分隔指定导出的列，标签按键展开为 tags.<键> 列，文件名包含name、过滤条件和时间戳
+func writeList(w http.ResponseWriter, r *http.Request, format, name string, body interface{}, items interface{}) {
+	if format == export.FormatJSON {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+		return
+	}
+
+	table, err := export.NewTable(items, exportColumns(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filename := startExport(w, r, format, name)
+	if err := export.Write(w, format, table); err != nil {
+		// 响应头已发送，只能记录错误
+		log.Printf("导出 %s 错误: %v", filename, err)
+	}
+}
+
+// exportColumns 解析columns参数，以逗号分隔指定导出的列，未指定时导出全部列
+func exportColumns(r *http.Request) []string {
+	var columns []string
+	for _, column := range strings.Split(r.URL.Query().Get("columns"), ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// startExport 设置导出附件的响应头，返回文件名
+func startExport(w http.ResponseWriter, r *http.Request, format, name string) string {
+	filters := r.URL.Query()
+	for key := range filters {
+		if exportControlParams[key] {
+			filters.Del(key)
+		}
+	}
+	filename := export.Filename(name, filters, format, time.Now())
+
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	return filename
+}
@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+type exportItem struct {
+	Name string            `json:"name"`
+	Tags map[string]string `json:"tags"`
+}
+
+// pagedItems 共total条记录的分页查询，每次查询前调用check
+func pagedItems(total int, check func(offset int)) func(limit, offset int) ([]exportItem, int, error) {
+	return func(limit, offset int) ([]exportItem, int, error) {
+		check(offset)
+		var items []exportItem
+		for i := offset; i < offset+limit && i < total; i++ {
+			item := exportItem{Name: fmt.Sprintf("item-%04d", i)}
+			if i == total-1 {
+				// 只有最后一条带标签，列须来自keys而不是已取出的记录
+				item.Tags = map[string]string{"env": "prod"}
+			}
+			items = append(items, item)
+		}
+		return items, total, nil
+	}
+}
+
+func TestStreamPagesWritesEachPageBeforeNextFetch(t *testing.T) {
+	const total = 2*exportPageSize + 10
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/api/resources?format=csv&provider=azure", nil)
+
+	var fetched []int
+	keys := func() (map[string][]string, error) {
+		return map[string][]string{"tags": {"env"}}, nil
+	}
+	fetch := pagedItems(total, func(offset int) {
+		fetched = append(fetched, offset)
+		if offset == 0 {
+			return
+		}
+		// 查询下一页时上一页必须已经写出并发送
+		body := recorder.Body.String()
+		if last := fmt.Sprintf("item-%04d", offset-1); !strings.Contains(body, last) {
+			t.Errorf("查询offset %d时上一页的 %s 尚未写出", offset, last)
+		}
+		if next := fmt.Sprintf("item-%04d", offset); strings.Contains(body, next) {
+			t.Errorf("查询offset %d之前已写出 %s", offset, next)
+		}
+		if !recorder.Flushed {
+			t.Errorf("查询offset %d时响应尚未刷新", offset)
+		}
+	})
+	streamPages(recorder, request, "csv", "resources", keys, fetch, func(err error) {
+		t.Fatalf("不应调用fail: %v", err)
+	})
+
+	if want := []int{0, exportPageSize, 2 * exportPageSize}; fmt.Sprint(fetched) != fmt.Sprint(want) {
+		t.Errorf("查询的offset为 %v，期望 %v", fetched, want)
+	}
+	if got := recorder.Header().Get("Content-Disposition"); !strings.Contains(got, "resources_provider-azure_") {
+		t.Errorf("Content-Disposition为 %q", got)
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(recorder.Body.String(), "\ufeff"))).ReadAll()
+	if err != nil {
+		t.Fatalf("解析CSV失败: %v", err)
+	}
+	if len(rows) != total+1 {
+		t.Fatalf("CSV共%d行，期望 %d", len(rows), total+1)
+	}
+	if got := strings.Join(rows[0], ","); got != "name,tags.env" {
+		t.Errorf("表头为 %s", got)
+	}
+	if got := strings.Join(rows[total], ","); got != fmt.Sprintf("item-%04d,prod", total-1) {
+		t.Errorf("最后一行为 %s", got)
+	}
+}
+
+func TestStreamPagesXLSX(t *testing.T) {
+	const total = exportPageSize + 1
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/api/resources?format=xlsx&columns=tags,name", nil)
+
+	keys := func() (map[string][]string, error) {
+		return map[string][]string{"tags": {"env"}}, nil
+	}
+	streamPages(recorder, request, "xlsx", "resources", keys, pagedItems(total, func(int) {}), func(err error) {
+		t.Fatalf("不应调用fail: %v", err)
+	})
+
+	f, err := excelize.OpenReader(bytes.NewReader(recorder.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("打开XLSX失败: %v", err)
+	}
+	defer f.Close()
+	rows, err := f.GetRows("Sheet1")
+	if err != nil {
+		t.Fatalf("读取工作表失败: %v", err)
+	}
+	if len(rows) != total+1 {
+		t.Fatalf("工作表共%d行，期望 %d", len(rows), total+1)
+	}
+	if got := strings.Join(rows[0], ","); got != "tags.env,name" {
+		t.Errorf("表头为 %s", got)
+	}
+	if got := strings.Join(rows[total], ","); got != fmt.Sprintf("prod,item-%04d", total-1) {
+		t.Errorf("最后一行为 %s", got)
+	}
+}
+
+func TestStreamPagesErrors(t *testing.T) {
+	noRows := pagedItems(0, func(int) {})
+
+	// 第一页查询失败时由fail输出错误响应
+	recorder := httptest.NewRecorder()
+	var failed error
+	streamPages(recorder, httptest.NewRequest(http.MethodGet, "/api/resources?format=csv", nil), "csv", "resources", nil,
+		func(limit, offset int) ([]exportItem, int, error) {
+			return nil, 0, errors.New("db down")
+		}, func(err error) {
+			failed = err
+			http.Error(recorder, "获取资源失败", http.StatusInternalServerError)
+		})
+	if failed == nil || recorder.Code != http.StatusInternalServerError {
+		t.Errorf("第一页查询失败时应调用fail，状态码为 %d", recorder.Code)
+	}
+
+	// 未知的列返回400
+	recorder = httptest.NewRecorder()
+	streamPages(recorder, httptest.NewRequest(http.MethodGet, "/api/resources?format=csv&columns=unknown", nil), "csv", "resources", nil,
+		noRows, func(err error) {
+			t.Errorf("不应调用fail: %v", err)
+		})
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("未知的列状态码为 %d，期望 400", recorder.Code)
+	}
+}
@@ -0,0 +1,357 @@
+// dao/application_dao.go
+package dao
+
+import (
+	"database/sql"
+	"strings"
+
+	"CMDB/model"
+)
+
+// ApplicationDAO 应用和业务服务数据访问对象
+type ApplicationDAO struct {
+	db *sql.DB
+}
+
+// NewApplicationDAO 创建新的ApplicationDAO实例
+func NewApplicationDAO(db *sql.DB) *ApplicationDAO {
+	return &ApplicationDAO{db: db}
+}
+
+// applicationMembership 资源r属于应用的条件：手动指定为成员，或带有满足任一标签规则的标签
+// application为应用ID的SQL表达式，可以是列名或占位符
+func applicationMembership(application string) string {
+	return "(EXISTS (SELECT 1 FROM application_members m WHERE m.application_id = " + application + " AND m.resource_id = r.resource_id)" +
+		" OR EXISTS (SELECT 1 FROM application_tag_rules ar JOIN resource_tags t ON t.tag_key = ar.tag_key AND (ar.tag_value = '' OR t.tag_value = ar.tag_value)" +
+		" WHERE ar.application_id = " + application + " AND t.resource_id = r.resource_id))"
+}
+
+// applicationColumns 查询应用时的列，最后一列为成员资源数
+var applicationColumns = `a.id, a.name, a.kind, a.description, a.owner, a.criticality, a.support_group, a.created_at, a.updated_at,
+            (SELECT COUNT(*) FROM resources r WHERE r.deleted_at IS NULL AND ` + applicationMembership("a.id") + `)`
+
+// scanApplication 扫描applicationColumns对应的一行
+func scanApplication(scanner interface{ Scan(...interface{}) error }) (*model.Application, error) {
+	application := &model.Application{TagRules: []model.ApplicationTagRule{}, Members: []string{}}
+	err := scanner.Scan(
+		&application.ID,
+		&application.Name,
+		&application.Kind,
+		&application.Description,
+		&application.Owner,
+		&application.Criticality,
+		&application.SupportGroup,
+		&application.CreatedAt,
+		&application.UpdatedAt,
+		&application.ResourceCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return application, nil
+}
+
+// CreateApplication 在事务中创建应用及其标签规则和手动成员，并回填应用ID
+func (dao *ApplicationDAO) CreateApplication(application *model.Application) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"INSERT INTO applications (name, kind, description, owner, criticality, support_group) VALUES (?, ?, ?, ?, ?, ?)",
+		application.Name, application.Kind, application.Description, application.Owner, application.Criticality, application.SupportGroup,
+	)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	if err := replaceApplicationTagRulesTx(tx, id, application.TagRules); err != nil {
+		return err
+	}
+	if err := insertApplicationMembersTx(tx, id, application.Members); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	application.ID = id
+	return nil
+}
+
+// UpdateApplication 在事务中更新应用的属性，tagRules、members不为nil时整体替换标签规则、手动成员
+func (dao *ApplicationDAO) UpdateApplication(application *model.Application, tagRules []model.ApplicationTagRule, members []string) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		"UPDATE applications SET name = ?, kind = ?, description = ?, owner = ?, criticality = ?, support_group = ? WHERE id = ?",
+		application.Name, application.Kind, application.Description, application.Owner, application.Criticality, application.SupportGroup, application.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tagRules != nil {
+		if err := replaceApplicationTagRulesTx(tx, application.ID, tagRules); err != nil {
+			return err
+		}
+	}
+	if members != nil {
+		if _, err := tx.Exec("DELETE FROM application_members WHERE application_id = ?", application.ID); err != nil {
+			return err
+		}
+		if err := insertApplicationMembersTx(tx, application.ID, members); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// replaceApplicationTagRulesTx 在事务中替换应用的标签规则
+func replaceApplicationTagRulesTx(tx *sql.Tx, applicationID int64, rules []model.ApplicationTagRule) error {
+	if _, err := tx.Exec("DELETE FROM application_tag_rules WHERE application_id = ?", applicationID); err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		_, err := tx.Exec(
+			"INSERT IGNORE INTO application_tag_rules (application_id, tag_key, tag_value) VALUES (?, ?, ?)",
+			applicationID, rule.Key, rule.Value,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertApplicationMembersTx 在事务中添加应用的手动成员，已是成员的资源忽略
+func insertApplicationMembersTx(tx *sql.Tx, applicationID int64, resourceIDs []string) error {
+	for _, resourceID := range resourceIDs {
+		_, err := tx.Exec(
+			"INSERT IGNORE INTO application_members (application_id, resource_id) VALUES (?, ?)",
+			applicationID, resourceID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteApplication 删除应用，标签规则和手动成员随之级联删除，应用不存在时返回false
+func (dao *ApplicationDAO) DeleteApplication(id int64) (bool, error) {
+	result, err := dao.db.Exec("DELETE FROM applications WHERE id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// AddMembers 添加应用的手动成员
+func (dao *ApplicationDAO) AddMembers(applicationID int64, resourceIDs []string) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertApplicationMembersTx(tx, applicationID, resourceIDs); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveMembers 移除应用的手动成员，不是成员的资源忽略
+func (dao *ApplicationDAO) RemoveMembers(applicationID int64, resourceIDs []string) error {
+	args := []interface{}{applicationID}
+	for _, resourceID := range resourceIDs {
+		args = append(args, resourceID)
+	}
+	_, err := dao.db.Exec(
+		"DELETE FROM application_members WHERE application_id = ? AND resource_id IN (?"+strings.Repeat(", ?", len(resourceIDs)-1)+")",
+		args...,
+	)
+	return err
+}
+
+// ApplicationNameExists 名称是否已被其他应用使用，excludeID为更新时的应用自身
+func (dao *ApplicationDAO) ApplicationNameExists(name string, excludeID int64) (bool, error) {
+	var count int
+	err := dao.db.QueryRow("SELECT COUNT(*) FROM applications WHERE name = ? AND id <> ?", name, excludeID).Scan(&count)
+	return count > 0, err
+}
+
+// MissingResourceIDs 返回给定ID中不在资源清单（未删除的资源）中的ID
+func (dao *ApplicationDAO) MissingResourceIDs(resourceIDs []string) ([]string, error) {
+	args := make([]interface{}, len(resourceIDs))
+	for i, id := range resourceIDs {
+		args[i] = id
+	}
+	rows, err := dao.db.Query(
+		"SELECT resource_id FROM resources WHERE deleted_at IS NULL AND resource_id IN (?"+strings.Repeat(", ?", len(resourceIDs)-1)+")",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		existing[strings.ToLower(id)] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, id := range resourceIDs {
+		if !existing[strings.ToLower(id)] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
+
+// GetApplication 根据ID获取应用及其标签规则和手动成员，不存在时返回nil
+func (dao *ApplicationDAO) GetApplication(id int64) (*model.Application, error) {
+	row := dao.db.QueryRow("SELECT "+applicationColumns+" FROM applications a WHERE a.id = ?", id)
+	application, err := scanApplication(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	applications := map[int64]*model.Application{application.ID: application}
+	if err := dao.loadTagRules(applications); err != nil {
+		return nil, err
+	}
+	if err := dao.loadMembers(applications); err != nil {
+		return nil, err
+	}
+	return application, nil
+}
+
+// ListApplications 按条件列出应用，按名称排序
+func (dao *ApplicationDAO) ListApplications(filter model.ApplicationFilter) ([]*model.Application, error) {
+	query := "SELECT " + applicationColumns + " FROM applications a WHERE 1 = 1"
+	var args []interface{}
+	if filter.Kind != "" {
+		query += " AND a.kind = ?"
+		args = append(args, filter.Kind)
+	}
+	if filter.Criticality != "" {
+		query += " AND a.criticality = ?"
+		args = append(args, filter.Criticality)
+	}
+	if filter.Owner != "" {
+		query += " AND a.owner = ?"
+		args = append(args, filter.Owner)
+	}
+	query += " ORDER BY a.name"
+
+	rows, err := dao.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []*model.Application{}
+	applications := make(map[int64]*model.Application)
+	for rows.Next() {
+		application, err := scanApplication(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, application)
+		applications[application.ID] = application
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := dao.loadTagRules(applications); err != nil {
+		return nil, err
+	}
+	if err := dao.loadMembers(applications); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// loadTagRules 为应用填充标签规则
+func (dao *ApplicationDAO) loadTagRules(applications map[int64]*model.Application) error {
+	if len(applications) == 0 {
+		return nil
+	}
+	ids, args := applicationIDs(applications)
+	rows, err := dao.db.Query(
+		"SELECT application_id, tag_key, tag_value FROM application_tag_rules WHERE application_id IN "+ids+" ORDER BY tag_key, tag_value",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var rule model.ApplicationTagRule
+		if err := rows.Scan(&id, &rule.Key, &rule.Value); err != nil {
+			return err
+		}
+		applications[id].TagRules = append(applications[id].TagRules, rule)
+	}
+	return rows.Err()
+}
+
+// loadMembers 为应用填充手动成员
+func (dao *ApplicationDAO) loadMembers(applications map[int64]*model.Application) error {
+	if len(applications) == 0 {
+		return nil
+	}
+	ids, args := applicationIDs(applications)
+	rows, err := dao.db.Query(
+		"SELECT application_id, resource_id FROM application_members WHERE application_id IN "+ids+" ORDER BY resource_id",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var resourceID string
+		if err := rows.Scan(&id, &resourceID); err != nil {
+			return err
+		}
+		applications[id].Members = append(applications[id].Members, resourceID)
+	}
+	return rows.Err()
+}
+
+// applicationIDs 构造应用ID的IN列表及参数
+func applicationIDs(applications map[int64]*model.Application) (string, []interface{}) {
+	args := make([]interface{}, 0, len(applications))
+	for id := range applications {
+		args = append(args, id)
+	}
+	return "(?" + strings.Repeat(", ?", len(args)-1) + ")", args
+}
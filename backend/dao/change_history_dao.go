@@ -0,0 +1,119 @@
+// dao/change_history_dao.go
+package dao
+
+import (
+	"database/sql"
+	"time"
+
+	"CMDB/model"
+)
+
+// ChangeHistoryDAO 变更历史数据访问对象
+type ChangeHistoryDAO struct {
+	db *sql.DB
+}
+
+// NewChangeHistoryDAO 创建新的ChangeHistoryDAO实例
+func NewChangeHistoryDAO(db *sql.DB) *ChangeHistoryDAO {
+	return &ChangeHistoryDAO{db: db}
+}
+
+// InsertChangesTx 在事务中追加变更记录
+func (dao *ChangeHistoryDAO) InsertChangesTx(tx *sql.Tx, changes []*model.ChangeRecord) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare(`
+        INSERT INTO change_history (item_type, item_id, resource_id, change_type, field, old_value, new_value, sync_task_id, changed_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, change := range changes {
+		_, err = stmt.Exec(
+			change.ItemType,
+			change.ItemID,
+			change.ResourceID,
+			change.ChangeType,
+			change.Field,
+			change.OldValue,
+			change.NewValue,
+			change.SyncTaskID,
+			change.ChangedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListChangesByResourceID 获取资源及其虚拟机、数据库记录的变更历史，按时间倒序
+func (dao *ChangeHistoryDAO) ListChangesByResourceID(resourceID string, limit int) ([]*model.ChangeRecord, error) {
+	query := `
+        SELECT id, item_type, item_id, resource_id, change_type, field, old_value, new_value, sync_task_id, changed_at
+        FROM change_history
+        WHERE resource_id = ?
+        ORDER BY changed_at DESC, id DESC
+        LIMIT ?
+    `
+
+	rows, err := dao.db.Query(query, resourceID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []*model.ChangeRecord{}
+	for rows.Next() {
+		change := &model.ChangeRecord{}
+		var oldValue, newValue sql.NullString
+		err := rows.Scan(
+			&change.ID,
+			&change.ItemType,
+			&change.ItemID,
+			&change.ResourceID,
+			&change.ChangeType,
+			&change.Field,
+			&oldValue,
+			&newValue,
+			nullInt64{&change.SyncTaskID},
+			&change.ChangedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if oldValue.Valid {
+			change.OldValue = &oldValue.String
+		}
+		if newValue.Valid {
+			change.NewValue = &newValue.String
+		}
+		changes = append(changes, change)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// recordDeletionsTx 在事务中为即将标记删除的记录追加删除变更，须在更新deleted_at之前调用
+// table、itemType和idColumn由调用方给定常量，不来自外部输入
+func recordDeletionsTx(tx *sql.Tx, table, itemType, idColumn string, provider, subscriptionID string, syncedBefore time.Time, taskID int64, now time.Time) error {
+	query := `
+        INSERT INTO change_history (item_type, item_id, resource_id, change_type, field, old_value, new_value, sync_task_id, changed_at)
+        SELECT ?, ` + idColumn + `, resource_id, ?, '', NULL, NULL, ?, ?
+        FROM ` + table + `
+        WHERE provider = ? AND subscription_id = ? AND last_sync_at < ? AND deleted_at IS NULL
+    `
+
+	_, err := tx.Exec(query, itemType, model.ChangeTypeDeleted, nullableInt64(taskID), now, provider, subscriptionID, syncedBefore)
+	return err
+}
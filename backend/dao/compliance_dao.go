@@ -0,0 +1,233 @@
+// dao/compliance_dao.go
+package dao
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"CMDB/model"
+)
+
+// ComplianceDAO 标签合规数据访问对象
+type ComplianceDAO struct {
+	db *sql.DB
+}
+
+// NewComplianceDAO 创建新的ComplianceDAO实例
+func NewComplianceDAO(db *sql.DB) *ComplianceDAO {
+	return &ComplianceDAO{db: db}
+}
+
+// SaveEvaluation 在事务中用本次评估的结果替换各资源的合规结果，并追加一条合规快照
+func (dao *ComplianceDAO) SaveEvaluation(results []*model.ResourceCompliance, snapshot *model.ComplianceSnapshot) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM resource_compliance"); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+        INSERT INTO resource_compliance (resource_id, name, resource_type, subscription_id, owner, owner_source, compliant, violation_count, violations, evaluated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, result := range results {
+		violations := result.Violations
+		if violations == nil {
+			violations = []*model.TagViolation{}
+		}
+		data, err := json.Marshal(violations)
+		if err != nil {
+			return err
+		}
+		_, err = stmt.Exec(
+			result.ResourceID,
+			result.Name,
+			result.ResourceType,
+			result.SubscriptionID,
+			result.Owner,
+			result.OwnerSource,
+			result.Compliant,
+			len(result.Violations),
+			data,
+			result.EvaluatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO compliance_snapshots (evaluated_at, total_resources, compliant_resources, violation_count) VALUES (?, ?, ?, ?)",
+		snapshot.EvaluatedAt, snapshot.TotalResources, snapshot.CompliantResources, snapshot.ViolationCount,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// complianceConditions 根据过滤条件构造WHERE子句
+func complianceConditions(filter model.ComplianceFilter) (string, []interface{}) {
+	where := " WHERE 1 = 1"
+	var args []interface{}
+	if filter.SubscriptionID != "" {
+		where += " AND subscription_id = ?"
+		args = append(args, filter.SubscriptionID)
+	}
+	if filter.Owner != "" {
+		where += " AND owner = ?"
+		args = append(args, filter.Owner)
+	}
+	if filter.OwnerSource != "" {
+		where += " AND owner_source = ?"
+		args = append(args, filter.OwnerSource)
+	}
+	return where, args
+}
+
+// Summarize 汇总满足条件的资源的合规情况，尚未评估时EvaluatedAt为零值
+func (dao *ComplianceDAO) Summarize(filter model.ComplianceFilter) (*model.ComplianceSnapshot, error) {
+	where, args := complianceConditions(filter)
+	query := `
+        SELECT COUNT(*), COALESCE(SUM(compliant), 0), COALESCE(SUM(violation_count), 0), MAX(evaluated_at)
+        FROM resource_compliance` + where
+
+	summary := &model.ComplianceSnapshot{}
+	var evaluatedAt sql.NullTime
+	err := dao.db.QueryRow(query, args...).Scan(&summary.TotalResources, &summary.CompliantResources, &summary.ViolationCount, &evaluatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if evaluatedAt.Valid {
+		summary.EvaluatedAt = evaluatedAt.Time
+	}
+	summary.ComplianceRate = model.ComplianceRate(summary.CompliantResources, summary.TotalResources)
+	return summary, nil
+}
+
+// ListNonCompliant 分页查询满足条件的不合规资源，按违规数倒序，返回当前页和总数
+func (dao *ComplianceDAO) ListNonCompliant(filter model.ComplianceFilter) ([]*model.ResourceCompliance, int, error) {
+	where, args := complianceConditions(filter)
+	where += " AND compliant = 0"
+
+	var total int
+	if err := dao.db.QueryRow("SELECT COUNT(*) FROM resource_compliance"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+        SELECT resource_id, name, resource_type, subscription_id, owner, owner_source, compliant, violations, evaluated_at
+        FROM resource_compliance` + where + `
+        ORDER BY violation_count DESC, name
+        LIMIT ? OFFSET ?`
+	rows, err := dao.db.Query(query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var results []*model.ResourceCompliance
+	for rows.Next() {
+		result := &model.ResourceCompliance{}
+		var violations []byte
+		err := rows.Scan(
+			&result.ResourceID,
+			&result.Name,
+			&result.ResourceType,
+			&result.SubscriptionID,
+			&result.Owner,
+			&result.OwnerSource,
+			&result.Compliant,
+			&violations,
+			&result.EvaluatedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := json.Unmarshal(violations, &result.Violations); err != nil {
+			return nil, 0, err
+		}
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return results, total, nil
+}
+
+// SummarizeBy 按列分组汇总合规情况，按合规率升序，column由调用方给定常量（owner、owner_source或subscription_id）
+func (dao *ComplianceDAO) SummarizeBy(column string, filter model.ComplianceFilter) ([]*model.ComplianceGroup, error) {
+	where, args := complianceConditions(filter)
+	query := `
+        SELECT ` + column + `, COUNT(*), SUM(compliant), SUM(violation_count)
+        FROM resource_compliance` + where + `
+        GROUP BY ` + column + `
+        ORDER BY SUM(compliant) / COUNT(*), ` + column
+
+	rows, err := dao.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []*model.ComplianceGroup
+	for rows.Next() {
+		group := &model.ComplianceGroup{}
+		if err := rows.Scan(&group.Key, &group.TotalResources, &group.CompliantResources, &group.ViolationCount); err != nil {
+			return nil, err
+		}
+		group.ComplianceRate = model.ComplianceRate(group.CompliantResources, group.TotalResources)
+		groups = append(groups, group)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// ListSnapshots 获取since之后的合规快照，按时间升序
+func (dao *ComplianceDAO) ListSnapshots(since time.Time) ([]*model.ComplianceSnapshot, error) {
+	query := `
+        SELECT evaluated_at, total_resources, compliant_resources, violation_count
+        FROM compliance_snapshots
+        WHERE evaluated_at >= ?
+        ORDER BY evaluated_at
+    `
+
+	rows, err := dao.db.Query(query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []*model.ComplianceSnapshot
+	for rows.Next() {
+		snapshot := &model.ComplianceSnapshot{}
+		if err := rows.Scan(&snapshot.EvaluatedAt, &snapshot.TotalResources, &snapshot.CompliantResources, &snapshot.ViolationCount); err != nil {
+			return nil, err
+		}
+		snapshot.ComplianceRate = model.ComplianceRate(snapshot.CompliantResources, snapshot.TotalResources)
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
@@ -21,9 +21,10 @@ func NewDatabaseDAO(db *sql.DB) *DatabaseDAO {
 // UpsertDatabase 插入或更新数据库信息
 func (dao *DatabaseDAO) UpsertDatabase(database *model.Database) error {
 	query := `
-        INSERT INTO cmdb_databases (database_id, resource_id, name, location, server, db_type, version, status, owner, subscription_id, last_sync_at)
-        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        INSERT INTO cmdb_databases (provider, database_id, resource_id, name, location, server, db_type, version, status, owner, subscription_id, last_sync_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
         ON DUPLICATE KEY UPDATE
+            provider = VALUES(provider),
             resource_id = VALUES(resource_id),
             name = VALUES(name),
             location = VALUES(location),
@@ -33,12 +34,15 @@ func (dao *DatabaseDAO) UpsertDatabase(database *model.Database) error {
             status = VALUES(status),
             owner = VALUES(owner),
             subscription_id = VALUES(subscription_id),
-            last_sync_at = VALUES(last_sync_at)
+            last_sync_at = VALUES(last_sync_at),
+            deleted_at = NULL,
+            deleted_by_task_id = NULL
     `
 
 	now := time.Now()
 	_, err := dao.db.Exec(
 		query,
+		database.Provider,
 		database.DatabaseID,
 		database.ResourceID,
 		database.Name,
@@ -89,7 +93,7 @@ func (dao *DatabaseDAO) UpsertDatabaseTags(databaseID string, tags map[string]st
 // GetDatabaseByID 根据ID获取数据库信息
 func (dao *DatabaseDAO) GetDatabaseByID(databaseID string) (*model.Database, error) {
 	query := `
-        SELECT id, database_id, resource_id, name, location, server, db_type, version, status, owner, subscription_id, last_sync_at, created_at, updated_at
+        SELECT id, provider, database_id, resource_id, name, location, server, db_type, version, status, owner, subscription_id, last_sync_at, deleted_at, deleted_by_task_id, created_at, updated_at
         FROM cmdb_databases
         WHERE database_id = ?
     `
@@ -97,6 +101,7 @@ func (dao *DatabaseDAO) GetDatabaseByID(databaseID string) (*model.Database, err
 	database := &model.Database{}
 	err := dao.db.QueryRow(query, databaseID).Scan(
 		&database.ID,
+		&database.Provider,
 		&database.DatabaseID,
 		&database.ResourceID,
 		&database.Name,
@@ -108,6 +113,8 @@ func (dao *DatabaseDAO) GetDatabaseByID(databaseID string) (*model.Database, err
 		&database.Owner,
 		&database.SubscriptionID,
 		&database.LastSyncAt,
+		nullTime{&database.DeletedAt},
+		nullInt64{&database.DeletedByTaskID},
 		&database.CreatedAt,
 		&database.UpdatedAt,
 	)
@@ -138,15 +145,32 @@ func (dao *DatabaseDAO) GetDatabaseByID(databaseID string) (*model.Database, err
 	return database, nil
 }
 
-// ListDatabases 列出所有数据库
-func (dao *DatabaseDAO) ListDatabases() ([]*model.Database, error) {
+// ListDatabases 列出所有数据库，includeDeleted为false时排除已删除的数据库
+func (dao *DatabaseDAO) ListDatabases(includeDeleted bool) ([]*model.Database, error) {
+	return dao.queryDatabases(includeDeleted, "")
+}
+
+// ListDatabasesByType 列出指定类型的数据库，includeDeleted为false时排除已删除的数据库
+func (dao *DatabaseDAO) ListDatabasesByType(dbType string, includeDeleted bool) ([]*model.Database, error) {
+	return dao.queryDatabases(includeDeleted, "db_type = ?", dbType)
+}
+
+// queryDatabases 按条件查询数据库，condition为空时不附加条件
+func (dao *DatabaseDAO) queryDatabases(includeDeleted bool, condition string, args ...interface{}) ([]*model.Database, error) {
 	query := `
-        SELECT id, database_id, resource_id, name, location, server, db_type, version, status, owner, subscription_id, last_sync_at, created_at, updated_at
+        SELECT id, provider, database_id, resource_id, name, location, server, db_type, version, status, owner, subscription_id, last_sync_at, deleted_at, deleted_by_task_id, created_at, updated_at
         FROM cmdb_databases
-        ORDER BY name
+        WHERE 1 = 1
     `
+	if !includeDeleted {
+		query += " AND deleted_at IS NULL"
+	}
+	if condition != "" {
+		query += " AND " + condition
+	}
+	query += " ORDER BY name"
 	
-	rows, err := dao.db.Query(query)
+	rows, err := dao.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -157,6 +181,7 @@ func (dao *DatabaseDAO) ListDatabases() ([]*model.Database, error) {
 		database := &model.Database{Tags: make(map[string]string)}
 		err := rows.Scan(
 			&database.ID,
+			&database.Provider,
 			&database.DatabaseID,
 			&database.ResourceID,
 			&database.Name,
@@ -168,6 +193,8 @@ func (dao *DatabaseDAO) ListDatabases() ([]*model.Database, error) {
 			&database.Owner,
 			&database.SubscriptionID,
 			&database.LastSyncAt,
+			nullTime{&database.DeletedAt},
+			nullInt64{&database.DeletedByTaskID},
 			&database.CreatedAt,
 			&database.UpdatedAt,
 		)
@@ -206,9 +233,10 @@ func (dao *DatabaseDAO) BeginTx() (*sql.Tx, error) {
 // UpsertDatabaseTx 在事务中插入或更新数据库信息
 func (dao *DatabaseDAO) UpsertDatabaseTx(tx *sql.Tx, database *model.Database) error {
 	query := `
-        INSERT INTO cmdb_databases (database_id, resource_id, name, location, server, db_type, version, status, owner, subscription_id, last_sync_at)
-        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        INSERT INTO cmdb_databases (provider, database_id, resource_id, name, location, server, db_type, version, status, owner, subscription_id, last_sync_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
         ON DUPLICATE KEY UPDATE
+            provider = VALUES(provider),
             resource_id = VALUES(resource_id),
             name = VALUES(name),
             location = VALUES(location),
@@ -218,12 +246,15 @@ func (dao *DatabaseDAO) UpsertDatabaseTx(tx *sql.Tx, database *model.Database) e
             status = VALUES(status),
             owner = VALUES(owner),
             subscription_id = VALUES(subscription_id),
-            last_sync_at = VALUES(last_sync_at)
+            last_sync_at = VALUES(last_sync_at),
+            deleted_at = NULL,
+            deleted_by_task_id = NULL
     `
 
 	now := time.Now()
 	_, err := tx.Exec(
 		query,
+		database.Provider,
 		database.DatabaseID,
 		database.ResourceID,
 		database.Name,
@@ -268,4 +299,53 @@ func (dao *DatabaseDAO) UpsertDatabaseTagsTx(tx *sql.Tx, databaseID string, tags
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// MarkDatabasesDeleted 将范围内本次同步未出现的数据库标记为已删除
+// 同时追加删除变更记录、结束这些记录的当前版本并将其检索文档标记为已删除
+func (dao *DatabaseDAO) MarkDatabasesDeleted(provider, subscriptionID string, syncedBefore time.Time, taskID int64) (int64, error) {
+	query := `
+        UPDATE cmdb_databases
+        SET deleted_at = ?, deleted_by_task_id = ?
+        WHERE provider = ? AND subscription_id = ? AND last_sync_at < ? AND deleted_at IS NULL
+    `
+
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err := recordDeletionsTx(tx, "cmdb_databases", model.ItemTypeDatabase, "database_id", provider, subscriptionID, syncedBefore, taskID, now); err != nil {
+		return 0, err
+	}
+	if err := closeVersionsForDeletionTx(tx, "cmdb_databases", model.ItemTypeDatabase, "database_id", provider, subscriptionID, syncedBefore, now); err != nil {
+		return 0, err
+	}
+	if err := markSearchDocumentsDeletedTx(tx, "cmdb_databases", model.ItemTypeDatabase, "database_id", provider, subscriptionID, syncedBefore); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(query, now, nullableInt64(taskID), provider, subscriptionID, syncedBefore)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return affected, tx.Commit()
+}
+
+// PurgeDeletedDatabases 永久删除在deletedBefore之前被标记删除的数据库，标签随外键级联删除，检索文档随之删除
+func (dao *DatabaseDAO) PurgeDeletedDatabases(deletedBefore time.Time) (int64, error) {
+	result, err := dao.db.Exec("DELETE FROM cmdb_databases WHERE deleted_at < ?", deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	if err := deleteOrphanSearchDocuments(dao.db, "cmdb_databases", model.ItemTypeDatabase, "database_id"); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,103 @@
+// dao/impact_dao.go
+package dao
+
+import (
+	"database/sql"
+	"strings"
+
+	"CMDB/model"
+	"CMDB/search"
+)
+
+// ImpactDAO 影响分析数据访问对象，按资源ID层级和数据库服务器查找依赖方
+type ImpactDAO struct {
+	db *sql.DB
+}
+
+// NewImpactDAO 创建新的ImpactDAO实例
+func NewImpactDAO(db *sql.DB) *ImpactDAO {
+	return &ImpactDAO{db: db}
+}
+
+// ListChildResourceIDs 列出资源ID位于parentID之下的未删除资源，如SQL服务器下的数据库，最多返回limit个
+func (dao *ImpactDAO) ListChildResourceIDs(parentID string, limit int) ([]string, error) {
+	return dao.queryIDs(
+		"SELECT resource_id FROM resources WHERE deleted_at IS NULL AND resource_id LIKE ? ORDER BY resource_id LIMIT ?",
+		search.EscapeLike(strings.TrimRight(parentID, "/"))+"/%", limit,
+	)
+}
+
+// ListDatabaseIDsByServer 列出server字段为指定服务器名称的未删除数据库的资源ID，限定在同一订阅（同步范围）内
+func (dao *ImpactDAO) ListDatabaseIDsByServer(server, subscriptionID string) ([]string, error) {
+	return dao.queryIDs(
+		"SELECT resource_id FROM cmdb_databases WHERE deleted_at IS NULL AND server = ? AND subscription_id = ? ORDER BY resource_id",
+		server, subscriptionID,
+	)
+}
+
+// queryIDs 执行只返回资源ID一列的查询
+func (dao *ImpactDAO) queryIDs(query string, args ...interface{}) ([]string, error) {
+	rows, err := dao.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetImpactedItems 获取资源清单中未删除的资源作为受影响的CI，按是否存在于vms、cmdb_databases表分类
+// 返回值以小写的资源ID为键，不在清单中的ID不出现
+func (dao *ImpactDAO) GetImpactedItems(resourceIDs []string) (map[string]*model.ImpactedItem, error) {
+	items := make(map[string]*model.ImpactedItem)
+	for start := 0; start < len(resourceIDs); start += relationshipLookupBatch {
+		end := start + relationshipLookupBatch
+		if end > len(resourceIDs) {
+			end = len(resourceIDs)
+		}
+		batch := resourceIDs[start:end]
+
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+		query := `
+        SELECT r.resource_id, r.name, r.resource_type, r.provider, r.subscription_id, r.status, COALESCE(r.owner, ''),
+            CASE
+                WHEN EXISTS (SELECT 1 FROM vms v WHERE v.resource_id = r.resource_id AND v.deleted_at IS NULL) THEN ?
+                WHEN EXISTS (SELECT 1 FROM cmdb_databases d WHERE d.resource_id = r.resource_id AND d.deleted_at IS NULL) THEN ?
+                ELSE ?
+            END
+        FROM resources r
+        WHERE r.deleted_at IS NULL AND r.resource_id IN (?` + strings.Repeat(", ?", len(batch)-1) + ")"
+		args = append([]interface{}{model.ImpactCategoryVM, model.ImpactCategoryDatabase, model.ImpactCategoryOther}, args...)
+
+		rows, err := dao.db.Query(query, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			item := &model.ImpactedItem{InInventory: true}
+			err := rows.Scan(&item.ResourceID, &item.Name, &item.Type, &item.Provider, &item.SubscriptionID, &item.Status, &item.Owner, &item.Category)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			items[strings.ToLower(item.ResourceID)] = item
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
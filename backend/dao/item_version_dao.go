@@ -0,0 +1,167 @@
+// dao/item_version_dao.go
+package dao
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"CMDB/model"
+)
+
+// ItemVersionDAO 配置项版本数据访问对象
+// 每个版本保存配置项在有效区间 [valid_from, valid_to) 内的完整快照，valid_to为NULL表示当前版本
+type ItemVersionDAO struct {
+	db *sql.DB
+}
+
+// NewItemVersionDAO 创建新的ItemVersionDAO实例
+func NewItemVersionDAO(db *sql.DB) *ItemVersionDAO {
+	return &ItemVersionDAO{db: db}
+}
+
+// SaveVersionTx 在事务中保存配置项的当前版本
+// changed为true时结束当前版本并以snapshot开始新版本；否则仅在没有当前版本时（如历史数据）补充一个版本
+func (dao *ItemVersionDAO) SaveVersionTx(tx *sql.Tx, itemType, itemID, resourceID, name string, snapshot interface{}, changed bool, now time.Time) error {
+	if changed {
+		_, err := tx.Exec(
+			"UPDATE item_versions SET valid_to = ? WHERE item_type = ? AND item_id = ? AND valid_to IS NULL",
+			now, itemType, itemID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO item_versions (item_type, item_id, resource_id, name, snapshot, valid_from)
+        SELECT ?, ?, ?, ?, ?, ?
+        FROM DUAL
+        WHERE NOT EXISTS (
+            SELECT 1 FROM item_versions WHERE item_type = ? AND item_id = ? AND valid_to IS NULL
+        )
+    `
+	_, err = tx.Exec(query, itemType, itemID, resourceID, name, data, now, itemType, itemID)
+	return err
+}
+
+// ListSnapshotsAsOf 获取指定时刻有效的全部版本快照，按名称排序
+func (dao *ItemVersionDAO) ListSnapshotsAsOf(itemType string, asOf time.Time) ([]json.RawMessage, error) {
+	query := `
+        SELECT snapshot
+        FROM item_versions
+        WHERE item_type = ? AND valid_from <= ? AND (valid_to IS NULL OR valid_to > ?)
+        ORDER BY name
+    `
+
+	rows, err := dao.db.Query(query, itemType, asOf, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []json.RawMessage
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, json.RawMessage(data))
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+// closeVersionsForDeletionTx 在事务中结束即将标记删除的记录的当前版本，须在更新deleted_at之前调用
+// table、itemType和idColumn由调用方给定常量，不来自外部输入
+func closeVersionsForDeletionTx(tx *sql.Tx, table, itemType, idColumn string, provider, subscriptionID string, syncedBefore time.Time, now time.Time) error {
+	query := `
+        UPDATE item_versions v
+        JOIN ` + table + ` t ON v.item_id = t.` + idColumn + `
+        SET v.valid_to = ?
+        WHERE v.item_type = ? AND v.valid_to IS NULL
+          AND t.provider = ? AND t.subscription_id = ? AND t.last_sync_at < ? AND t.deleted_at IS NULL
+    `
+
+	_, err := tx.Exec(query, now, itemType, provider, subscriptionID, syncedBefore)
+	return err
+}
+
+// snapshotColumn 资源快照v中字段对应的SQL表达式
+func snapshotColumn(field string) string {
+	return "JSON_UNQUOTE(JSON_EXTRACT(v.snapshot, '$." + field + "'))"
+}
+
+// snapshotWhere 构造查询指定时刻有效的资源快照的WHERE子句，版本表的别名为v
+func snapshotWhere(filter model.ResourceFilter, asOf time.Time) (string, []interface{}) {
+	tagCondition := func(key string, value *string) (string, []interface{}) {
+		path := "CONCAT('$.tags.', JSON_QUOTE(?))"
+		if value == nil {
+			return "JSON_CONTAINS_PATH(v.snapshot, 'one', " + path + ")", []interface{}{key}
+		}
+		return "JSON_UNQUOTE(JSON_EXTRACT(v.snapshot, " + path + ")) = ?", []interface{}{key, *value}
+	}
+
+	where := " WHERE v.item_type = ? AND v.valid_from <= ? AND (v.valid_to IS NULL OR v.valid_to > ?)"
+	args := []interface{}{model.ItemTypeResource, asOf, asOf}
+	conditions, conditionArgs := resourceConditions(filter, snapshotColumn, tagCondition)
+	return where + conditions, append(args, conditionArgs...)
+}
+
+// ListResourceKeysAsOf 列出指定时刻满足条件的资源快照中出现过的全部标签键和属性键，按字母排序
+func (dao *ItemVersionDAO) ListResourceKeysAsOf(filter model.ResourceFilter, asOf time.Time) ([]string, []string, error) {
+	where, args := snapshotWhere(filter, asOf)
+
+	tagKeys, err := queryJSONKeys(dao.db, "SELECT DISTINCT JSON_KEYS(v.snapshot, '$.tags') FROM item_versions v"+where, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	attributeKeys, err := queryJSONKeys(dao.db, "SELECT DISTINCT JSON_KEYS(v.snapshot, '$.attributes') FROM item_versions v"+where, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tagKeys, attributeKeys, nil
+}
+
+// ListResourceSnapshotsAsOf 按条件分页查询指定时刻有效的资源快照，返回当前页的快照和满足条件的总数
+// 查询条件作用于快照JSON中的字段，与ResourceDAO.ListResources的语义一致
+func (dao *ItemVersionDAO) ListResourceSnapshotsAsOf(filter model.ResourceFilter, asOf time.Time) ([]json.RawMessage, int, error) {
+	column := snapshotColumn
+	where, args := snapshotWhere(filter, asOf)
+
+	var total int
+	if err := dao.db.QueryRow("SELECT COUNT(*) FROM item_versions v"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT v.snapshot FROM item_versions v" + where + resourceOrderBy(filter, column) + " LIMIT ? OFFSET ?"
+	rows, err := dao.db.Query(query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var snapshots []json.RawMessage
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, 0, err
+		}
+		snapshots = append(snapshots, json.RawMessage(data))
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return snapshots, total, nil
+}
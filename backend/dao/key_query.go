@@ -0,0 +1,63 @@
+package dao
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sort"
+)
+
+// queryStrings 执行只返回一个字符串列的查询
+func queryStrings(db *sql.DB, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
+
+// queryJSONKeys 执行返回一列JSON_KEYS结果的查询，合并各行的键并按字母排序，NULL行被忽略
+func queryJSONKeys(db *sql.DB, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		if data == nil {
+			continue
+		}
+		var keys []string
+		if err := json.Unmarshal(data, &keys); err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			seen[key] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
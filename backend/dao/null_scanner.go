@@ -0,0 +1,80 @@
+package dao
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// nullTime 将可为NULL的时间列扫描到 *time.Time，NULL对应nil
+type nullTime struct {
+	dst **time.Time
+}
+
+func (n nullTime) Scan(value interface{}) error {
+	var t sql.NullTime
+	if err := t.Scan(value); err != nil {
+		return err
+	}
+	*n.dst = nil
+	if t.Valid {
+		*n.dst = &t.Time
+	}
+	return nil
+}
+
+// nullInt64 将可为NULL的整数列扫描到 *int64，NULL对应nil
+type nullInt64 struct {
+	dst **int64
+}
+
+func (n nullInt64) Scan(value interface{}) error {
+	var i sql.NullInt64
+	if err := i.Scan(value); err != nil {
+		return err
+	}
+	*n.dst = nil
+	if i.Valid {
+		*n.dst = &i.Int64
+	}
+	return nil
+}
+
+// nullableInt64 将0视为NULL写入数据库
+func nullableInt64(value int64) interface{} {
+	if value == 0 {
+		return nil
+	}
+	return value
+}
+
+// jsonMap 将可为NULL的JSON对象列扫描到 map[string]string，NULL对应nil
+type jsonMap struct {
+	dst *map[string]string
+}
+
+func (j jsonMap) Scan(value interface{}) error {
+	*j.dst = nil
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, j.dst)
+	case string:
+		return json.Unmarshal([]byte(v), j.dst)
+	}
+	return fmt.Errorf("无法将 %T 扫描为JSON对象", value)
+}
+
+// nullableJSONMap 将map序列化为JSON写入数据库，空map视为NULL
+func nullableJSONMap(value map[string]string) (interface{}, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,196 @@
+// dao/owner_dao.go
+package dao
+
+import (
+	"database/sql"
+	"strings"
+
+	"CMDB/model"
+)
+
+// ownerLookupBatch 按所有者批量查询时每条SQL的最大取值数
+const ownerLookupBatch = 500
+
+// OwnerDAO 所有者目录缓存数据访问对象
+type OwnerDAO struct {
+	db *sql.DB
+}
+
+// NewOwnerDAO 创建新的OwnerDAO实例
+func NewOwnerDAO(db *sql.DB) *OwnerDAO {
+	return &OwnerDAO{db: db}
+}
+
+// UpsertOwner 插入或更新所有者的解析结果
+func (dao *OwnerDAO) UpsertOwner(owner *model.OwnerInfo) error {
+	query := `
+        INSERT INTO owner_directory (owner_value, status, kind, object_id, display_name, user_principal_name, mail, department, manager_id, manager_name, resolved_at, expires_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        ON DUPLICATE KEY UPDATE
+            status = VALUES(status),
+            kind = VALUES(kind),
+            object_id = VALUES(object_id),
+            display_name = VALUES(display_name),
+            user_principal_name = VALUES(user_principal_name),
+            mail = VALUES(mail),
+            department = VALUES(department),
+            manager_id = VALUES(manager_id),
+            manager_name = VALUES(manager_name),
+            resolved_at = VALUES(resolved_at),
+            expires_at = VALUES(expires_at)
+    `
+
+	_, err := dao.db.Exec(
+		query,
+		owner.Value,
+		owner.Status,
+		owner.Kind,
+		owner.ObjectID,
+		owner.DisplayName,
+		owner.UserPrincipalName,
+		owner.Mail,
+		owner.Department,
+		owner.ManagerID,
+		owner.ManagerName,
+		owner.ResolvedAt,
+		owner.ExpiresAt,
+	)
+	return err
+}
+
+const ownerColumns = `owner_value, status, kind, object_id, display_name, user_principal_name, mail, department, manager_id, manager_name, resolved_at, expires_at`
+
+// scanOwner 扫描一行所有者信息，Flagged由状态推出
+func scanOwner(scanner interface{ Scan(dest ...any) error }, extra ...any) (*model.OwnerInfo, error) {
+	owner := &model.OwnerInfo{}
+	dest := []any{
+		&owner.Value,
+		&owner.Status,
+		&owner.Kind,
+		&owner.ObjectID,
+		&owner.DisplayName,
+		&owner.UserPrincipalName,
+		&owner.Mail,
+		&owner.Department,
+		&owner.ManagerID,
+		&owner.ManagerName,
+		&owner.ResolvedAt,
+		&owner.ExpiresAt,
+	}
+	if err := scanner.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	owner.Flagged = owner.Status == model.OwnerStatusDisabled || owner.Status == model.OwnerStatusNotFound
+	return owner, nil
+}
+
+// GetOwners 批量获取所有者的缓存结果（包括已过期的），返回值以小写的所有者取值为键
+func (dao *OwnerDAO) GetOwners(values []string) (map[string]*model.OwnerInfo, error) {
+	owners := make(map[string]*model.OwnerInfo)
+	for start := 0; start < len(values); start += ownerLookupBatch {
+		end := start + ownerLookupBatch
+		if end > len(values) {
+			end = len(values)
+		}
+		batch := values[start:end]
+
+		args := make([]interface{}, len(batch))
+		for i, value := range batch {
+			args[i] = value
+		}
+		query := "SELECT " + ownerColumns + " FROM owner_directory WHERE owner_value IN (?" + strings.Repeat(", ?", len(batch)-1) + ")"
+
+		rows, err := dao.db.Query(query, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			owner, err := scanOwner(rows)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			owners[strings.ToLower(owner.Value)] = owner
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return owners, nil
+}
+
+// ListDistinctOwners 列出未删除的资源、虚拟机和数据库上出现的全部所有者取值
+func (dao *OwnerDAO) ListDistinctOwners() ([]string, error) {
+	query := `
+        SELECT owner FROM resources WHERE deleted_at IS NULL AND owner <> ''
+        UNION
+        SELECT owner FROM vms WHERE deleted_at IS NULL AND owner <> ''
+        UNION
+        SELECT owner FROM cmdb_databases WHERE deleted_at IS NULL AND owner <> ''
+        UNION
+        SELECT owner FROM applications WHERE owner <> ''
+    `
+
+	rows, err := dao.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var owners []string
+	for rows.Next() {
+		var owner string
+		if err := rows.Scan(&owner); err != nil {
+			return nil, err
+		}
+		owners = append(owners, owner)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return owners, nil
+}
+
+// ListOwners 列出已解析的所有者及其名下未删除的资源数，status不为空时只列出该状态，按资源数倒序
+func (dao *OwnerDAO) ListOwners(status string) ([]*model.OwnerInfo, error) {
+	query := `
+        SELECT ` + ownerColumns + `, COALESCE(r.resource_count, 0)
+        FROM owner_directory o
+        LEFT JOIN (
+            SELECT owner, COUNT(*) AS resource_count FROM resources WHERE deleted_at IS NULL GROUP BY owner
+        ) r ON r.owner = o.owner_value
+    `
+	var args []interface{}
+	if status != "" {
+		query += " WHERE o.status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY COALESCE(r.resource_count, 0) DESC, o.owner_value"
+
+	rows, err := dao.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var owners []*model.OwnerInfo
+	for rows.Next() {
+		var resourceCount int
+		owner, err := scanOwner(rows, &resourceCount)
+		if err != nil {
+			return nil, err
+		}
+		owner.ResourceCount = resourceCount
+		owners = append(owners, owner)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return owners, nil
+}
@@ -0,0 +1,198 @@
+// dao/relationship_dao.go
+package dao
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"CMDB/model"
+)
+
+// relationshipLookupBatch 按资源ID批量查询关系时每条SQL的最大ID数
+const relationshipLookupBatch = 500
+
+// RelationshipDAO 资源关系数据访问对象
+type RelationshipDAO struct {
+	db *sql.DB
+}
+
+// NewRelationshipDAO 创建新的RelationshipDAO实例
+func NewRelationshipDAO(db *sql.DB) *RelationshipDAO {
+	return &RelationshipDAO{db: db}
+}
+
+// UpsertRelationships 在事务中插入或更新关系，并将其最后同步时间设为当前时间
+func (dao *RelationshipDAO) UpsertRelationships(relationships []*model.Relationship) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+        INSERT INTO resource_relationships (provider, subscription_id, source_id, source_type, target_id, target_type, relationship_type, last_sync_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+        ON DUPLICATE KEY UPDATE
+            provider = VALUES(provider),
+            subscription_id = VALUES(subscription_id),
+            source_type = VALUES(source_type),
+            target_type = VALUES(target_type),
+            last_sync_at = VALUES(last_sync_at)
+    `)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, relationship := range relationships {
+		_, err := stmt.Exec(
+			relationship.Provider,
+			relationship.SubscriptionID,
+			relationship.SourceID,
+			relationship.SourceType,
+			relationship.TargetID,
+			relationship.TargetType,
+			relationship.Type,
+			now,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteStaleRelationships 删除同步范围内在syncedBefore之后未再发现的关系，返回删除的条数
+func (dao *RelationshipDAO) DeleteStaleRelationships(provider, subscriptionID string, syncedBefore time.Time) (int64, error) {
+	result, err := dao.db.Exec(
+		"DELETE FROM resource_relationships WHERE provider = ? AND subscription_id = ? AND last_sync_at < ?",
+		provider, subscriptionID, syncedBefore,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ListRelationships 查询与给定资源相连的关系，direction为outgoing时只查以其为起点的关系，incoming时只查以其为终点的关系
+// types不为空时只查这些类型的关系
+func (dao *RelationshipDAO) ListRelationships(resourceIDs []string, direction string, types []string) ([]*model.Relationship, error) {
+	var relationships []*model.Relationship
+	for start := 0; start < len(resourceIDs); start += relationshipLookupBatch {
+		end := start + relationshipLookupBatch
+		if end > len(resourceIDs) {
+			end = len(resourceIDs)
+		}
+		batch, err := dao.listRelationships(resourceIDs[start:end], direction, types)
+		if err != nil {
+			return nil, err
+		}
+		relationships = append(relationships, batch...)
+	}
+	return relationships, nil
+}
+
+// listRelationships 查询一批资源的关系
+func (dao *RelationshipDAO) listRelationships(resourceIDs []string, direction string, types []string) ([]*model.Relationship, error) {
+	in := "(?" + strings.Repeat(", ?", len(resourceIDs)-1) + ")"
+	ids := make([]interface{}, len(resourceIDs))
+	for i, id := range resourceIDs {
+		ids[i] = id
+	}
+
+	var where string
+	var args []interface{}
+	switch direction {
+	case model.DirectionOutgoing:
+		where = " WHERE source_id IN " + in
+		args = append(args, ids...)
+	case model.DirectionIncoming:
+		where = " WHERE target_id IN " + in
+		args = append(args, ids...)
+	default:
+		where = " WHERE (source_id IN " + in + " OR target_id IN " + in + ")"
+		args = append(append(args, ids...), ids...)
+	}
+	if len(types) > 0 {
+		where += " AND relationship_type IN (?" + strings.Repeat(", ?", len(types)-1) + ")"
+		for _, relationshipType := range types {
+			args = append(args, relationshipType)
+		}
+	}
+
+	query := `
+        SELECT provider, subscription_id, source_id, source_type, target_id, target_type, relationship_type, last_sync_at
+        FROM resource_relationships` + where + `
+        ORDER BY relationship_type, source_id, target_id`
+
+	rows, err := dao.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var relationships []*model.Relationship
+	for rows.Next() {
+		relationship := &model.Relationship{}
+		err := rows.Scan(
+			&relationship.Provider,
+			&relationship.SubscriptionID,
+			&relationship.SourceID,
+			&relationship.SourceType,
+			&relationship.TargetID,
+			&relationship.TargetType,
+			&relationship.Type,
+			&relationship.LastSyncAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		relationships = append(relationships, relationship)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return relationships, nil
+}
+
+// GetNodes 获取资源清单中未删除的资源作为关系图节点，返回值以小写的资源ID为键，不在清单中的ID不出现
+func (dao *RelationshipDAO) GetNodes(resourceIDs []string) (map[string]*model.GraphNode, error) {
+	nodes := make(map[string]*model.GraphNode)
+	for start := 0; start < len(resourceIDs); start += relationshipLookupBatch {
+		end := start + relationshipLookupBatch
+		if end > len(resourceIDs) {
+			end = len(resourceIDs)
+		}
+		batch := resourceIDs[start:end]
+
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+		query := "SELECT resource_id, name, resource_type FROM resources WHERE deleted_at IS NULL AND resource_id IN (?" + strings.Repeat(", ?", len(batch)-1) + ")"
+
+		rows, err := dao.db.Query(query, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			node := &model.GraphNode{InInventory: true}
+			if err := rows.Scan(&node.ID, &node.Name, &node.Type); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			nodes[strings.ToLower(node.ID)] = node
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nodes, nil
+}
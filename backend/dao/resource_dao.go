@@ -2,9 +2,11 @@ package dao
 
 import (
 	"database/sql"
+	"strings"
 	"time"
 
 	"CMDB/model"
+	"CMDB/search"
 )
 
 type ResourceDAO struct {
@@ -23,26 +25,41 @@ func (dao *ResourceDAO) BeginTx() (*sql.Tx, error) {
 // UpsertResource 使用 MySQL 的 ON DUPLICATE KEY UPDATE 实现 Upsert
 func (dao *ResourceDAO) UpsertResource(resource *model.Resource) error {
 	query := `
-        INSERT INTO resources (resource_id, name, location, resource_type, owner, status, subscription_id, last_sync_at)
-        VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+        INSERT INTO resources (provider, resource_id, name, location, resource_type, owner, owner_source, source, attributes, status, subscription_id, last_sync_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
         ON DUPLICATE KEY UPDATE
+            provider = VALUES(provider),
             name = VALUES(name),
             location = VALUES(location),
             resource_type = VALUES(resource_type),
             owner = VALUES(owner),
+            owner_source = VALUES(owner_source),
+            source = VALUES(source),
+            attributes = VALUES(attributes),
             status = VALUES(status),
             subscription_id = VALUES(subscription_id),
-            last_sync_at = VALUES(last_sync_at)
+            last_sync_at = VALUES(last_sync_at),
+            deleted_at = NULL,
+            deleted_by_task_id = NULL
     `
 
+	attributes, err := nullableJSONMap(resource.Attributes)
+	if err != nil {
+		return err
+	}
+
 	now := time.Now()
-	_, err := dao.db.Exec(
+	_, err = dao.db.Exec(
 		query,
+		resource.Provider,
 		resource.ResourceID,
 		resource.Name,
 		resource.Location,
 		resource.ResourceType,
 		resource.Owner,
+		resource.OwnerSource,
+		resourceSource(resource),
+		attributes,
 		resource.Status,
 		resource.SubscriptionID,
 		now,
@@ -54,26 +71,41 @@ func (dao *ResourceDAO) UpsertResource(resource *model.Resource) error {
 // UpsertResourceTx 在事务中执行资源的 Upsert 操作
 func (dao *ResourceDAO) UpsertResourceTx(tx *sql.Tx, resource *model.Resource) error {
 	query := `
-        INSERT INTO resources (resource_id, name, location, resource_type, owner, status, subscription_id, last_sync_at)
-        VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+        INSERT INTO resources (provider, resource_id, name, location, resource_type, owner, owner_source, source, attributes, status, subscription_id, last_sync_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
         ON DUPLICATE KEY UPDATE
+            provider = VALUES(provider),
             name = VALUES(name),
             location = VALUES(location),
             resource_type = VALUES(resource_type),
             owner = VALUES(owner),
+            owner_source = VALUES(owner_source),
+            source = VALUES(source),
+            attributes = VALUES(attributes),
             status = VALUES(status),
             subscription_id = VALUES(subscription_id),
-            last_sync_at = VALUES(last_sync_at)
+            last_sync_at = VALUES(last_sync_at),
+            deleted_at = NULL,
+            deleted_by_task_id = NULL
     `
 
+	attributes, err := nullableJSONMap(resource.Attributes)
+	if err != nil {
+		return err
+	}
+
 	now := time.Now()
-	_, err := tx.Exec(
+	_, err = tx.Exec(
 		query,
+		resource.Provider,
 		resource.ResourceID,
 		resource.Name,
 		resource.Location,
 		resource.ResourceType,
 		resource.Owner,
+		resource.OwnerSource,
+		resourceSource(resource),
+		attributes,
 		resource.Status,
 		resource.SubscriptionID,
 		now,
@@ -82,6 +114,14 @@ func (dao *ResourceDAO) UpsertResourceTx(tx *sql.Tx, resource *model.Resource) e
 	return err
 }
 
+// resourceSource 资源的来源，未指定时为同步
+func resourceSource(resource *model.Resource) string {
+	if resource.Source == "" {
+		return model.ResourceSourceSync
+	}
+	return resource.Source
+}
+
 // UpsertResourceTags 批量更新资源标签
 func (dao *ResourceDAO) UpsertResourceTags(resourceID string, tags map[string]string) error {
 	// 先删除该资源的所有标签
@@ -140,21 +180,27 @@ func (dao *ResourceDAO) UpsertResourceTagsTx(tx *sql.Tx, resourceID string, tags
 // GetResourceByID 根据ID获取资源
 func (dao *ResourceDAO) GetResourceByID(resourceID string) (*model.Resource, error) {
 	query := `
-        SELECT r.resource_id, r.name, r.location, r.resource_type, r.owner, r.status, r.subscription_id, r.last_sync_at, r.created_at, r.updated_at
+        SELECT r.provider, r.resource_id, r.name, r.location, r.resource_type, r.owner, r.owner_source, r.source, r.attributes, r.status, r.subscription_id, r.last_sync_at, r.deleted_at, r.deleted_by_task_id, r.created_at, r.updated_at
         FROM resources r
         WHERE r.resource_id = ?
     `
 
 	var resource model.Resource
 	err := dao.db.QueryRow(query, resourceID).Scan(
+		&resource.Provider,
 		&resource.ResourceID,
 		&resource.Name,
 		&resource.Location,
 		&resource.ResourceType,
 		&resource.Owner,
+		&resource.OwnerSource,
+		&resource.Source,
+		jsonMap{&resource.Attributes},
 		&resource.Status,
 		&resource.SubscriptionID,
 		&resource.LastSyncAt,
+		nullTime{&resource.DeletedAt},
+		nullInt64{&resource.DeletedByTaskID},
 		&resource.CreatedAt,
 		&resource.UpdatedAt,
 	)
@@ -175,12 +221,75 @@ func (dao *ResourceDAO) GetResourceByID(resourceID string) (*model.Resource, err
 	return &resource, nil
 }
 
+// resourceLookupBatch 按资源ID批量查询或更新时每条SQL的最大ID数
+const resourceLookupBatch = 500
+
+// GetOwnerLookups 批量获取资源已保存的所有者及其来源和上次未找到创建者的时间，不存在的资源不出现在结果中
+func (dao *ResourceDAO) GetOwnerLookups(resourceIDs []string) (map[string]*model.OwnerLookup, error) {
+	lookups := make(map[string]*model.OwnerLookup)
+	for start := 0; start < len(resourceIDs); start += resourceLookupBatch {
+		end := start + resourceLookupBatch
+		if end > len(resourceIDs) {
+			end = len(resourceIDs)
+		}
+		batch := resourceIDs[start:end]
+
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+		query := "SELECT resource_id, COALESCE(owner, ''), owner_source, creator_checked_at FROM resources WHERE resource_id IN (?" + strings.Repeat(", ?", len(batch)-1) + ")"
+
+		rows, err := dao.db.Query(query, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var resourceID string
+			lookup := &model.OwnerLookup{}
+			if err := rows.Scan(&resourceID, &lookup.Owner, &lookup.OwnerSource, nullTime{&lookup.CreatorCheckedAt}); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			lookups[resourceID] = lookup
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return lookups, nil
+}
+
+// MarkCreatorChecked 记录在活动日志中未找到资源创建者的时间，不改变资源的更新时间
+func (dao *ResourceDAO) MarkCreatorChecked(resourceIDs []string, checkedAt time.Time) error {
+	for start := 0; start < len(resourceIDs); start += resourceLookupBatch {
+		end := start + resourceLookupBatch
+		if end > len(resourceIDs) {
+			end = len(resourceIDs)
+		}
+		batch := resourceIDs[start:end]
+
+		args := make([]interface{}, 0, len(batch)+1)
+		args = append(args, checkedAt)
+		for _, id := range batch {
+			args = append(args, id)
+		}
+		query := "UPDATE resources SET creator_checked_at = ?, updated_at = updated_at WHERE resource_id IN (?" + strings.Repeat(", ?", len(batch)-1) + ")"
+		if _, err := dao.db.Exec(query, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetResourcesByType 根据类型获取资源列表
 func (dao *ResourceDAO) GetResourcesByType(resourceType string) ([]*model.Resource, error) {
 	query := `
-        SELECT r.resource_id, r.name, r.location, r.resource_type, r.owner, r.status, r.subscription_id, r.last_sync_at, r.created_at, r.updated_at
+        SELECT r.provider, r.resource_id, r.name, r.location, r.resource_type, r.owner, r.owner_source, r.source, r.attributes, r.status, r.subscription_id, r.last_sync_at, r.deleted_at, r.deleted_by_task_id, r.created_at, r.updated_at
         FROM resources r
-        WHERE r.resource_type = ?
+        WHERE r.resource_type = ? AND r.deleted_at IS NULL
     `
 
 	rows, err := dao.db.Query(query, resourceType)
@@ -193,14 +302,20 @@ func (dao *ResourceDAO) GetResourcesByType(resourceType string) ([]*model.Resour
 	for rows.Next() {
 		var resource model.Resource
 		err := rows.Scan(
+			&resource.Provider,
 			&resource.ResourceID,
 			&resource.Name,
 			&resource.Location,
 			&resource.ResourceType,
 			&resource.Owner,
+			&resource.OwnerSource,
+			&resource.Source,
+			jsonMap{&resource.Attributes},
 			&resource.Status,
 			&resource.SubscriptionID,
 			&resource.LastSyncAt,
+			nullTime{&resource.DeletedAt},
+			nullInt64{&resource.DeletedByTaskID},
 			&resource.CreatedAt,
 			&resource.UpdatedAt,
 		)
@@ -224,12 +339,15 @@ func (dao *ResourceDAO) GetResourcesByType(resourceType string) ([]*model.Resour
 	return resources, nil
 }
 
-// GetAllResources 获取所有资源
-func (dao *ResourceDAO) GetAllResources() ([]*model.Resource, error) {
+// GetAllResources 获取所有资源，includeDeleted为false时排除已删除的资源
+func (dao *ResourceDAO) GetAllResources(includeDeleted bool) ([]*model.Resource, error) {
 	query := `
-        SELECT r.resource_id, r.name, r.location, r.resource_type, r.owner, r.status, r.subscription_id, r.last_sync_at, r.created_at, r.updated_at
+        SELECT r.provider, r.resource_id, r.name, r.location, r.resource_type, r.owner, r.owner_source, r.source, r.attributes, r.status, r.subscription_id, r.last_sync_at, r.deleted_at, r.deleted_by_task_id, r.created_at, r.updated_at
         FROM resources r
     `
+	if !includeDeleted {
+		query += " WHERE r.deleted_at IS NULL"
+	}
 
 	rows, err := dao.db.Query(query)
 	if err != nil {
@@ -241,14 +359,20 @@ func (dao *ResourceDAO) GetAllResources() ([]*model.Resource, error) {
 	for rows.Next() {
 		var resource model.Resource
 		err := rows.Scan(
+			&resource.Provider,
 			&resource.ResourceID,
 			&resource.Name,
 			&resource.Location,
 			&resource.ResourceType,
 			&resource.Owner,
+			&resource.OwnerSource,
+			&resource.Source,
+			jsonMap{&resource.Attributes},
 			&resource.Status,
 			&resource.SubscriptionID,
 			&resource.LastSyncAt,
+			nullTime{&resource.DeletedAt},
+			nullInt64{&resource.DeletedByTaskID},
 			&resource.CreatedAt,
 			&resource.UpdatedAt,
 		)
@@ -305,9 +429,9 @@ func (dao *ResourceDAO) getResourceTags(resourceID string) (map[string]string, e
 // GetResourcesByLocation 根据位置获取资源
 func (dao *ResourceDAO) GetResourcesByLocation(location string) ([]*model.Resource, error) {
 	query := `
-        SELECT r.resource_id, r.name, r.location, r.resource_type, r.owner, r.status, r.subscription_id, r.last_sync_at, r.created_at, r.updated_at
+        SELECT r.provider, r.resource_id, r.name, r.location, r.resource_type, r.owner, r.owner_source, r.source, r.attributes, r.status, r.subscription_id, r.last_sync_at, r.deleted_at, r.deleted_by_task_id, r.created_at, r.updated_at
         FROM resources r
-        WHERE r.location = ?
+        WHERE r.location = ? AND r.deleted_at IS NULL
     `
 
 	rows, err := dao.db.Query(query, location)
@@ -320,14 +444,20 @@ func (dao *ResourceDAO) GetResourcesByLocation(location string) ([]*model.Resour
 	for rows.Next() {
 		var resource model.Resource
 		err := rows.Scan(
+			&resource.Provider,
 			&resource.ResourceID,
 			&resource.Name,
 			&resource.Location,
 			&resource.ResourceType,
 			&resource.Owner,
+			&resource.OwnerSource,
+			&resource.Source,
+			jsonMap{&resource.Attributes},
 			&resource.Status,
 			&resource.SubscriptionID,
 			&resource.LastSyncAt,
+			nullTime{&resource.DeletedAt},
+			nullInt64{&resource.DeletedByTaskID},
 			&resource.CreatedAt,
 			&resource.UpdatedAt,
 		)
@@ -354,10 +484,10 @@ func (dao *ResourceDAO) GetResourcesByLocation(location string) ([]*model.Resour
 // GetResourcesByTag 根据标签获取资源
 func (dao *ResourceDAO) GetResourcesByTag(key string, value string) ([]*model.Resource, error) {
 	query := `
-        SELECT r.resource_id, r.name, r.location, r.resource_type, r.owner, r.status, r.subscription_id, r.last_sync_at, r.created_at, r.updated_at
+        SELECT r.provider, r.resource_id, r.name, r.location, r.resource_type, r.owner, r.owner_source, r.source, r.attributes, r.status, r.subscription_id, r.last_sync_at, r.deleted_at, r.deleted_by_task_id, r.created_at, r.updated_at
         FROM resources r
         JOIN resource_tags t ON r.resource_id = t.resource_id
-        WHERE t.tag_key = ? AND t.tag_value = ?
+        WHERE t.tag_key = ? AND t.tag_value = ? AND r.deleted_at IS NULL
     `
 
 	rows, err := dao.db.Query(query, key, value)
@@ -370,14 +500,20 @@ func (dao *ResourceDAO) GetResourcesByTag(key string, value string) ([]*model.Re
 	for rows.Next() {
 		var resource model.Resource
 		err := rows.Scan(
+			&resource.Provider,
 			&resource.ResourceID,
 			&resource.Name,
 			&resource.Location,
 			&resource.ResourceType,
 			&resource.Owner,
+			&resource.OwnerSource,
+			&resource.Source,
+			jsonMap{&resource.Attributes},
 			&resource.Status,
 			&resource.SubscriptionID,
 			&resource.LastSyncAt,
+			nullTime{&resource.DeletedAt},
+			nullInt64{&resource.DeletedByTaskID},
 			&resource.CreatedAt,
 			&resource.UpdatedAt,
 		)
@@ -400,3 +536,295 @@ func (dao *ResourceDAO) GetResourcesByTag(key string, value string) ([]*model.Re
 
 	return resources, nil
 }
+
+// MarkResourcesDeleted 将范围内本次同步未出现的资源标记为已删除
+// syncedBefore之前同步过且尚未删除的资源视为已在云平台删除，taskID为发现删除的同步任务
+// 同时追加删除变更记录、结束这些记录的当前版本并将其检索文档标记为已删除
+// 手动维护的CI不属于任何同步范围，只能通过MarkResourceDeleted删除
+func (dao *ResourceDAO) MarkResourcesDeleted(provider, subscriptionID string, syncedBefore time.Time, taskID int64) (int64, error) {
+	if provider == model.ProviderManual {
+		return 0, nil
+	}
+	query := `
+        UPDATE resources
+        SET deleted_at = ?, deleted_by_task_id = ?
+        WHERE provider = ? AND subscription_id = ? AND last_sync_at < ? AND deleted_at IS NULL
+    `
+
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err := recordDeletionsTx(tx, "resources", model.ItemTypeResource, "resource_id", provider, subscriptionID, syncedBefore, taskID, now); err != nil {
+		return 0, err
+	}
+	if err := closeVersionsForDeletionTx(tx, "resources", model.ItemTypeResource, "resource_id", provider, subscriptionID, syncedBefore, now); err != nil {
+		return 0, err
+	}
+	if err := markSearchDocumentsDeletedTx(tx, "resources", model.ItemTypeResource, "resource_id", provider, subscriptionID, syncedBefore); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(query, now, nullableInt64(taskID), provider, subscriptionID, syncedBefore)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return affected, tx.Commit()
+}
+
+// MarkResourceDeleted 将单个资源标记为已删除，用于删除手动维护的CI
+// 同时追加删除变更记录、结束其当前版本并将其检索文档标记为已删除，资源不存在或已删除时返回false
+func (dao *ResourceDAO) MarkResourceDeleted(resourceID string) (bool, error) {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec("UPDATE resources SET deleted_at = ? WHERE resource_id = ? AND deleted_at IS NULL", now, resourceID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO change_history (item_type, item_id, resource_id, change_type, field, old_value, new_value, sync_task_id, changed_at)
+        VALUES (?, ?, ?, ?, '', NULL, NULL, NULL, ?)
+    `, model.ItemTypeResource, resourceID, resourceID, model.ChangeTypeDeleted, now)
+	if err != nil {
+		return false, err
+	}
+	_, err = tx.Exec("UPDATE item_versions SET valid_to = ? WHERE item_type = ? AND item_id = ? AND valid_to IS NULL", now, model.ItemTypeResource, resourceID)
+	if err != nil {
+		return false, err
+	}
+	_, err = tx.Exec("UPDATE search_documents SET deleted = 1 WHERE item_type = ? AND item_id = ?", model.ItemTypeResource, resourceID)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// PurgeDeletedResources 永久删除在deletedBefore之前被标记删除的资源
+// 仍被虚拟机或数据库记录引用的资源会保留到引用记录被清理之后，检索文档随资源一起删除
+func (dao *ResourceDAO) PurgeDeletedResources(deletedBefore time.Time) (int64, error) {
+	query := `
+        DELETE FROM resources
+        WHERE deleted_at < ?
+          AND resource_id NOT IN (SELECT resource_id FROM vms)
+          AND resource_id NOT IN (SELECT resource_id FROM cmdb_databases)
+    `
+
+	result, err := dao.db.Exec(query, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	if err := deleteOrphanSearchDocuments(dao.db, "resources", model.ItemTypeResource, "resource_id"); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// resourceConditions 根据查询条件构造WHERE子句，column将字段名映射为SQL列表达式
+// tagCondition构造标签条件，value为nil时只要求存在该标签
+func resourceConditions(filter model.ResourceFilter, column func(field string) string,
+	tagCondition func(key string, value *string) (string, []interface{})) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(field, value string) {
+		if value != "" {
+			conditions = append(conditions, column(field)+" = ?")
+			args = append(args, value)
+		}
+	}
+
+	add("provider", filter.Provider)
+	add("resource_type", filter.ResourceType)
+	add("location", filter.Location)
+	add("subscription_id", filter.SubscriptionID)
+	add("owner", filter.Owner)
+	add("owner_source", filter.OwnerSource)
+	add("source", filter.Source)
+	add("status", filter.Status)
+	if filter.ResourceGroup != "" {
+		conditions = append(conditions, column("resource_id")+" LIKE ?")
+		args = append(args, "%/resourceGroups/"+search.EscapeLike(filter.ResourceGroup)+"/%")
+	}
+
+	if filter.TagKey != "" {
+		var value *string
+		if filter.TagValue != "" {
+			value = &filter.TagValue
+		}
+		condition, tagArgs := tagCondition(filter.TagKey, value)
+		conditions = append(conditions, condition)
+		args = append(args, tagArgs...)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " AND " + strings.Join(conditions, " AND "), args
+}
+
+// resourceOrderBy 构造ORDER BY子句，排序字段不在白名单中时按名称排序
+func resourceOrderBy(filter model.ResourceFilter, column func(field string) string) string {
+	sort := filter.Sort
+	if !model.ResourceSortFields[sort] {
+		sort = "name"
+	}
+	direction := "ASC"
+	if filter.Desc {
+		direction = "DESC"
+	}
+	// 以资源ID作为次要排序，保证分页结果稳定
+	return " ORDER BY " + column(sort) + " " + direction + ", " + column("resource_id") + " ASC"
+}
+
+// resourceColumn 资源表r中字段对应的列
+func resourceColumn(field string) string {
+	return "r." + field
+}
+
+// resourceWhere 构造资源列表的WHERE子句，资源表的别名为r
+func resourceWhere(filter model.ResourceFilter) (string, []interface{}) {
+	tagCondition := func(key string, value *string) (string, []interface{}) {
+		if value == nil {
+			return "EXISTS (SELECT 1 FROM resource_tags t WHERE t.resource_id = r.resource_id AND t.tag_key = ?)", []interface{}{key}
+		}
+		return "EXISTS (SELECT 1 FROM resource_tags t WHERE t.resource_id = r.resource_id AND t.tag_key = ? AND t.tag_value = ?)", []interface{}{key, *value}
+	}
+
+	where := " WHERE 1 = 1"
+	if !filter.IncludeDeleted {
+		where += " AND r.deleted_at IS NULL"
+	}
+	conditions, args := resourceConditions(filter, resourceColumn, tagCondition)
+	where += conditions
+	if filter.ApplicationID != 0 {
+		where += " AND " + applicationMembership("?")
+		args = append(args, filter.ApplicationID, filter.ApplicationID)
+	}
+	return where, args
+}
+
+// ListResourceKeys 列出满足条件的资源上出现过的全部标签键和属性键，按字母排序，分页参数被忽略
+// 用于分页导出时确定标签和属性展开的列
+func (dao *ResourceDAO) ListResourceKeys(filter model.ResourceFilter) ([]string, []string, error) {
+	where, args := resourceWhere(filter)
+
+	tagKeys, err := queryStrings(dao.db, "SELECT DISTINCT k.tag_key FROM resources r JOIN resource_tags k ON k.resource_id = r.resource_id"+where+" ORDER BY k.tag_key", args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	attributeKeys, err := queryJSONKeys(dao.db, "SELECT DISTINCT JSON_KEYS(r.attributes) FROM resources r"+where+" AND r.attributes IS NOT NULL", args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tagKeys, attributeKeys, nil
+}
+
+// ListResources 按条件分页查询资源，返回当前页的资源和满足条件的总数
+func (dao *ResourceDAO) ListResources(filter model.ResourceFilter) ([]*model.Resource, int, error) {
+	column := resourceColumn
+	where, args := resourceWhere(filter)
+
+	var total int
+	if err := dao.db.QueryRow("SELECT COUNT(*) FROM resources r"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+        SELECT r.provider, r.resource_id, r.name, r.location, r.resource_type, r.owner, r.owner_source, r.source, r.attributes, r.status, r.subscription_id, r.last_sync_at, r.deleted_at, r.deleted_by_task_id, r.created_at, r.updated_at
+        FROM resources r` + where + resourceOrderBy(filter, column) + " LIMIT ? OFFSET ?"
+
+	rows, err := dao.db.Query(query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	resources := []*model.Resource{}
+	for rows.Next() {
+		var resource model.Resource
+		err := rows.Scan(
+			&resource.Provider,
+			&resource.ResourceID,
+			&resource.Name,
+			&resource.Location,
+			&resource.ResourceType,
+			&resource.Owner,
+			&resource.OwnerSource,
+			&resource.Source,
+			jsonMap{&resource.Attributes},
+			&resource.Status,
+			&resource.SubscriptionID,
+			&resource.LastSyncAt,
+			nullTime{&resource.DeletedAt},
+			nullInt64{&resource.DeletedByTaskID},
+			&resource.CreatedAt,
+			&resource.UpdatedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		resources = append(resources, &resource)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if err := dao.fillResourceTags(resources); err != nil {
+		return nil, 0, err
+	}
+
+	return resources, total, nil
+}
+
+// fillResourceTags 一次查询填充多个资源的标签
+func (dao *ResourceDAO) fillResourceTags(resources []*model.Resource) error {
+	if len(resources) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*model.Resource, len(resources))
+	placeholders := make([]string, 0, len(resources))
+	args := make([]interface{}, 0, len(resources))
+	for _, resource := range resources {
+		resource.Tags = make(map[string]string)
+		byID[resource.ResourceID] = resource
+		placeholders = append(placeholders, "?")
+		args = append(args, resource.ResourceID)
+	}
+
+	query := "SELECT resource_id, tag_key, tag_value FROM resource_tags WHERE resource_id IN (" + strings.Join(placeholders, ", ") + ")"
+	rows, err := dao.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var resourceID, key, value string
+		if err := rows.Scan(&resourceID, &key, &value); err != nil {
+			return err
+		}
+		if resource, ok := byID[resourceID]; ok {
+			resource.Tags[key] = value
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,270 @@
+// dao/search_dao.go
+package dao
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"CMDB/model"
+	"CMDB/search"
+)
+
+// SearchDAO 搜索数据访问对象，在资源、虚拟机和数据库表上执行搜索查询
+type SearchDAO struct {
+	db *sql.DB
+}
+
+// NewSearchDAO 创建新的SearchDAO实例
+func NewSearchDAO(db *sql.DB) *SearchDAO {
+	return &SearchDAO{db: db}
+}
+
+// searchTable 参与搜索的表及其在搜索结果中的列
+type searchTable struct {
+	schema search.Schema
+	// selectColumns 搜索结果的列，依次为 item_id, resource_id, provider, name, type, location, owner, status, subscription_id
+	selectColumns string
+	from          string
+	deletedColumn string
+}
+
+var searchTables = []searchTable{
+	{
+		schema: search.Schema{
+			Kind: model.ItemTypeResource,
+			Columns: map[string]string{
+				search.FieldName:         "r.name",
+				search.FieldID:           "r.resource_id",
+				search.FieldType:         "r.resource_type",
+				search.FieldLocation:     "r.location",
+				search.FieldSubscription: "r.subscription_id",
+				search.FieldOwner:        "r.owner",
+				search.FieldStatus:       "r.status",
+				search.FieldProvider:     "r.provider",
+			},
+			TagTable:      "resource_tags",
+			TagItemColumn: "resource_id",
+			ItemColumn:    "r.resource_id",
+		},
+		selectColumns: "r.resource_id AS item_id, r.resource_id AS resource_id, r.provider AS provider, r.name AS name, r.resource_type AS type, r.location AS location, r.owner AS owner, r.status AS status, r.subscription_id AS subscription_id",
+		from:          "resources r",
+		deletedColumn: "r.deleted_at",
+	},
+	{
+		schema: search.Schema{
+			Kind: model.ItemTypeVM,
+			Columns: map[string]string{
+				search.FieldName:         "v.name",
+				search.FieldID:           "v.vm_id",
+				search.FieldType:         "v.type",
+				search.FieldLocation:     "v.location",
+				search.FieldSubscription: "v.subscription_id",
+				search.FieldOwner:        "v.owner",
+				search.FieldStatus:       "v.status",
+				search.FieldProvider:     "v.provider",
+			},
+			TagTable:      "vm_tags",
+			TagItemColumn: "vm_id",
+			ItemColumn:    "v.vm_id",
+		},
+		selectColumns: "v.vm_id AS item_id, v.resource_id AS resource_id, v.provider AS provider, v.name AS name, v.type AS type, v.location AS location, v.owner AS owner, v.status AS status, v.subscription_id AS subscription_id",
+		from:          "vms v",
+		deletedColumn: "v.deleted_at",
+	},
+	{
+		schema: search.Schema{
+			Kind: model.ItemTypeDatabase,
+			Columns: map[string]string{
+				search.FieldName:         "d.name",
+				search.FieldID:           "d.database_id",
+				search.FieldType:         "d.db_type",
+				search.FieldLocation:     "d.location",
+				search.FieldSubscription: "d.subscription_id",
+				search.FieldOwner:        "d.owner",
+				search.FieldStatus:       "d.status",
+				search.FieldProvider:     "d.provider",
+			},
+			TagTable:      "cmdb_database_tags",
+			TagItemColumn: "database_id",
+			ItemColumn:    "d.database_id",
+		},
+		selectColumns: "d.database_id AS item_id, d.resource_id AS resource_id, d.provider AS provider, d.name AS name, d.db_type AS type, d.location AS location, d.owner AS owner, d.status AS status, d.subscription_id AS subscription_id",
+		from:          "cmdb_databases d",
+		deletedColumn: "d.deleted_at",
+	},
+}
+
+// Search 在资源、虚拟机和数据库上执行查询，返回按名称排序的当前页结果和满足条件的总数
+func (dao *SearchDAO) Search(query *search.Query, includeDeleted bool, limit, offset int) ([]*model.SearchResult, int, error) {
+	var selects []string
+	var args []interface{}
+	for _, table := range searchTables {
+		condition, conditionArgs := search.Compile(query, table.schema)
+		if !includeDeleted {
+			condition = table.deletedColumn + " IS NULL AND " + condition
+		}
+		selects = append(selects, "SELECT '"+table.schema.Kind+"' AS item_type, "+table.selectColumns+
+			" FROM "+table.from+" WHERE "+condition)
+		args = append(args, conditionArgs...)
+	}
+	union := "(" + strings.Join(selects, " UNION ALL ") + ") s"
+
+	var total int
+	if err := dao.db.QueryRow("SELECT COUNT(*) FROM "+union, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := dao.db.Query("SELECT * FROM "+union+" ORDER BY name, item_type, item_id LIMIT ? OFFSET ?", append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	results := []*model.SearchResult{}
+	for rows.Next() {
+		result := &model.SearchResult{}
+		var owner sql.NullString
+		err := rows.Scan(
+			&result.ItemType,
+			&result.ItemID,
+			&result.ResourceID,
+			&result.Provider,
+			&result.Name,
+			&result.Type,
+			&result.Location,
+			&owner,
+			&result.Status,
+			&result.SubscriptionID,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		result.Owner = owner.String
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return results, total, nil
+}
+
+// UpsertSearchDocumentTx 在事务中更新配置项的全文检索文档，文档随配置项保存而更新
+func (dao *SearchDAO) UpsertSearchDocumentTx(tx *sql.Tx, itemType, itemID, resourceID, provider, name, owner string, tags map[string]string) error {
+	query := `
+        INSERT INTO search_documents (item_type, item_id, resource_id, provider, name, owner, tags_text, deleted)
+        VALUES (?, ?, ?, ?, ?, ?, ?, 0)
+        ON DUPLICATE KEY UPDATE
+            resource_id = VALUES(resource_id),
+            provider = VALUES(provider),
+            name = VALUES(name),
+            owner = VALUES(owner),
+            tags_text = VALUES(tags_text),
+            deleted = 0
+    `
+
+	tagsText, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(query, itemType, itemID, resourceID, provider, name, owner, string(tagsText))
+	return err
+}
+
+// textSearchWhere 构造全文检索search_documents的WHERE子句，唯一的参数为布尔模式查询
+func textSearchWhere(includeDeleted bool) string {
+	where := " WHERE MATCH(name, item_id, owner, tags_text) AGAINST(? IN BOOLEAN MODE)"
+	if !includeDeleted {
+		where += " AND deleted = 0"
+	}
+	return where
+}
+
+// TextSearchTagKeys 列出全文检索结果中出现过的全部标签键，按字母排序，用于分页导出时确定标签展开的列
+func (dao *SearchDAO) TextSearchTagKeys(booleanQuery string, includeDeleted bool) ([]string, error) {
+	return queryJSONKeys(dao.db, "SELECT DISTINCT JSON_KEYS(tags_text) FROM search_documents"+textSearchWhere(includeDeleted), booleanQuery)
+}
+
+// TextSearch 以MySQL布尔模式全文检索资源、虚拟机和数据库，按相关度倒序返回当前页和满足条件的总数
+// 名称匹配的权重高于资源ID、所有者和标签
+func (dao *SearchDAO) TextSearch(booleanQuery string, includeDeleted bool, limit, offset int) ([]*model.TextSearchResult, int, error) {
+	where := textSearchWhere(includeDeleted)
+
+	var total int
+	if err := dao.db.QueryRow("SELECT COUNT(*) FROM search_documents"+where, booleanQuery).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+        SELECT item_type, item_id, resource_id, provider, name, owner, tags_text,
+            MATCH(name) AGAINST(? IN BOOLEAN MODE) * 2 + MATCH(name, item_id, owner, tags_text) AGAINST(? IN BOOLEAN MODE) AS score
+        FROM search_documents` + where + `
+        ORDER BY score DESC, name, item_id
+        LIMIT ? OFFSET ?
+    `
+
+	rows, err := dao.db.Query(query, booleanQuery, booleanQuery, booleanQuery, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	results := []*model.TextSearchResult{}
+	for rows.Next() {
+		result := &model.TextSearchResult{}
+		var tagsText string
+		err := rows.Scan(
+			&result.ItemType,
+			&result.ItemID,
+			&result.ResourceID,
+			&result.Provider,
+			&result.Name,
+			&result.Owner,
+			&tagsText,
+			&result.Score,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := json.Unmarshal([]byte(tagsText), &result.Tags); err != nil {
+			return nil, 0, err
+		}
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return results, total, nil
+}
+
+// markSearchDocumentsDeletedTx 在事务中将即将标记删除的记录的检索文档标记为已删除，须在更新deleted_at之前调用
+// table、itemType和idColumn由调用方给定常量，不来自外部输入
+func markSearchDocumentsDeletedTx(tx *sql.Tx, table, itemType, idColumn string, provider, subscriptionID string, syncedBefore time.Time) error {
+	query := `
+        UPDATE search_documents s
+        JOIN ` + table + ` t ON s.item_id = t.` + idColumn + `
+        SET s.deleted = 1
+        WHERE s.item_type = ?
+          AND t.provider = ? AND t.subscription_id = ? AND t.last_sync_at < ? AND t.deleted_at IS NULL
+    `
+
+	_, err := tx.Exec(query, itemType, provider, subscriptionID, syncedBefore)
+	return err
+}
+
+// deleteOrphanSearchDocuments 删除配置项已被永久删除的检索文档
+func deleteOrphanSearchDocuments(db *sql.DB, table, itemType, idColumn string) error {
+	query := `
+        DELETE FROM search_documents
+        WHERE item_type = ? AND deleted = 1
+          AND item_id NOT IN (SELECT ` + idColumn + ` FROM ` + table + `)
+    `
+
+	_, err := db.Exec(query, itemType)
+	return err
+}
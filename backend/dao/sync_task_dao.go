@@ -3,6 +3,7 @@ package dao
 
 import (
 	"database/sql"
+	"strings"
 	"time"
 
 	"CMDB/model"
@@ -19,96 +20,130 @@ func NewSyncTaskDAO(db *sql.DB) *SyncTaskDAO {
 }
 
 // CreateSyncTask 创建同步任务记录
-func (dao *SyncTaskDAO) CreateSyncTask(taskType string) (int64, error) {
+func (dao *SyncTaskDAO) CreateSyncTask(provider, account, taskType, trigger string) (int64, error) {
 	query := `
-        INSERT INTO sync_tasks (task_type, status, start_time, created_at)
-        VALUES (?, ?, ?, ?)
+        INSERT INTO sync_tasks (provider, account, task_type, sync_trigger, status, start_time, item_count, error_msg, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, 0, '', ?)
     `
-	
+
 	now := time.Now()
-	result, err := dao.db.Exec(query, taskType, "RUNNING", now, now)
+	result, err := dao.db.Exec(query, provider, account, taskType, trigger, model.SyncTaskStatusRunning, now, now)
 	if err != nil {
 		return 0, err
 	}
-	
+
 	return result.LastInsertId()
 }
 
 // UpdateSyncTaskStatus 更新同步任务状态
-func (dao *SyncTaskDAO) UpdateSyncTaskStatus(taskID int64, status string, itemCount int, errorMsg string) error {
+func (dao *SyncTaskDAO) UpdateSyncTaskStatus(taskID int64, status string, itemCount, deletedCount int, errorMsg string) error {
 	query := `
         UPDATE sync_tasks
-        SET status = ?, end_time = ?, item_count = ?, error_msg = ?
+        SET status = ?, end_time = ?, item_count = ?, deleted_count = ?, error_msg = ?
         WHERE id = ?
     `
-	
-	_, err := dao.db.Exec(query, status, time.Now(), itemCount, errorMsg, taskID)
+
+	_, err := dao.db.Exec(query, status, time.Now(), itemCount, deletedCount, errorMsg, taskID)
 	return err
 }
 
-// GetSyncTaskByID 根据ID获取同步任务
-func (dao *SyncTaskDAO) GetSyncTaskByID(taskID int64) (*model.SyncTask, error) {
-	query := `
-        SELECT id, task_type, status, start_time, end_time, item_count, error_msg, created_at
-        FROM sync_tasks
-        WHERE id = ?
-    `
-	
+const syncTaskColumns = `id, provider, account, task_type, sync_trigger, status, start_time, end_time, item_count, deleted_count, error_msg, created_at`
+
+// scanSyncTask 扫描一行同步任务
+func scanSyncTask(scanner interface{ Scan(dest ...any) error }) (*model.SyncTask, error) {
 	task := &model.SyncTask{}
-	err := dao.db.QueryRow(query, taskID).Scan(
+	var endTime sql.NullTime
+	err := scanner.Scan(
 		&task.ID,
+		&task.Provider,
+		&task.Account,
 		&task.TaskType,
+		&task.Trigger,
 		&task.Status,
 		&task.StartTime,
-		&task.EndTime,
+		&endTime,
 		&task.ItemCount,
+		&task.DeletedCount,
 		&task.ErrorMsg,
 		&task.CreatedAt,
 	)
-	
+	if err != nil {
+		return nil, err
+	}
+	if endTime.Valid {
+		task.EndTime = &endTime.Time
+	}
+	return task, nil
+}
+
+// GetSyncTaskByID 根据ID获取同步任务
+func (dao *SyncTaskDAO) GetSyncTaskByID(taskID int64) (*model.SyncTask, error) {
+	query := `SELECT ` + syncTaskColumns + ` FROM sync_tasks WHERE id = ?`
+
+	task, err := scanSyncTask(dao.db.QueryRow(query, taskID))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
-	
+
 	return task, nil
 }
 
-// ListSyncTasks 列出同步任务
-func (dao *SyncTaskDAO) ListSyncTasks(limit int) ([]*model.SyncTask, error) {
-	query := `
-        SELECT id, task_type, status, start_time, end_time, item_count, error_msg, created_at
-        FROM sync_tasks
-        ORDER BY id DESC
-        LIMIT ?
-    `
-	
-	rows, err := dao.db.Query(query, limit)
+// ListSyncTasks 按条件列出同步任务，最新的在前
+func (dao *SyncTaskDAO) ListSyncTasks(filter model.SyncTaskFilter) ([]*model.SyncTask, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.Provider != "" {
+		conditions = append(conditions, "provider = ?")
+		args = append(args, filter.Provider)
+	}
+	if filter.Account != "" {
+		conditions = append(conditions, "account = ?")
+		args = append(args, filter.Account)
+	}
+	if filter.TaskType != "" {
+		conditions = append(conditions, "task_type = ?")
+		args = append(args, filter.TaskType)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+
+	query := `SELECT ` + syncTaskColumns + ` FROM sync_tasks`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, filter.Limit)
+
+	rows, err := dao.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	
-	var tasks []*model.SyncTask
+
+	tasks := []*model.SyncTask{}
 	for rows.Next() {
-		task := &model.SyncTask{}
-		err := rows.Scan(
-			&task.ID,
-			&task.TaskType,
-			&task.Status,
-			&task.StartTime,
-			&task.EndTime,
-			&task.ItemCount,
-			&task.ErrorMsg,
-			&task.CreatedAt,
-		)
+		task, err := scanSyncTask(rows)
 		if err != nil {
 			return nil, err
 		}
 		tasks = append(tasks, task)
 	}
-	
-	return tasks, nil
-}
\ No newline at end of file
+
+	return tasks, rows.Err()
+}
+
+// GetLastSuccessTime 获取最近一次成功完成的同步任务的结束时间，没有成功记录时返回零值
+func (dao *SyncTaskDAO) GetLastSuccessTime() (time.Time, error) {
+	query := `SELECT MAX(end_time) FROM sync_tasks WHERE status = ?`
+
+	var endTime sql.NullTime
+	if err := dao.db.QueryRow(query, model.SyncTaskStatusSuccess).Scan(&endTime); err != nil {
+		return time.Time{}, err
+	}
+	return endTime.Time, nil
+}
@@ -21,9 +21,10 @@ func NewVMDAO(db *sql.DB) *VMDAO {
 // UpsertVM 插入或更新虚拟机信息
 func (dao *VMDAO) UpsertVM(vm *model.VM) error {
 	query := `
-        INSERT INTO vms (vm_id, resource_id, name, location, type, status, owner, subscription_id, last_sync_at)
-        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+        INSERT INTO vms (provider, vm_id, resource_id, name, location, type, status, owner, subscription_id, last_sync_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
         ON DUPLICATE KEY UPDATE
+            provider = VALUES(provider),
             resource_id = VALUES(resource_id),
             name = VALUES(name),
             location = VALUES(location),
@@ -31,12 +32,15 @@ func (dao *VMDAO) UpsertVM(vm *model.VM) error {
             status = VALUES(status),
             owner = VALUES(owner),
             subscription_id = VALUES(subscription_id),
-            last_sync_at = VALUES(last_sync_at)
+            last_sync_at = VALUES(last_sync_at),
+            deleted_at = NULL,
+            deleted_by_task_id = NULL
     `
 
 	now := time.Now()
 	_, err := dao.db.Exec(
 		query,
+		vm.Provider,
 		vm.VMID,
 		vm.ResourceID,
 		vm.Name,
@@ -84,7 +88,7 @@ func (dao *VMDAO) UpsertVMTags(vmID string, tags map[string]string) error {
 // GetVMByID 根据ID获取虚拟机信息
 func (dao *VMDAO) GetVMByID(vmID string) (*model.VM, error) {
 	query := `
-        SELECT id, vm_id, resource_id, name, location, type, status, owner, subscription_id, last_sync_at, created_at, updated_at
+        SELECT id, provider, vm_id, resource_id, name, location, type, status, owner, subscription_id, last_sync_at, deleted_at, deleted_by_task_id, created_at, updated_at
         FROM vms
         WHERE vm_id = ?
     `
@@ -92,6 +96,7 @@ func (dao *VMDAO) GetVMByID(vmID string) (*model.VM, error) {
 	vm := &model.VM{}
 	err := dao.db.QueryRow(query, vmID).Scan(
 		&vm.ID,
+		&vm.Provider,
 		&vm.VMID,
 		&vm.ResourceID,
 		&vm.Name,
@@ -101,6 +106,8 @@ func (dao *VMDAO) GetVMByID(vmID string) (*model.VM, error) {
 		&vm.Owner,
 		&vm.SubscriptionID,
 		&vm.LastSyncAt,
+		nullTime{&vm.DeletedAt},
+		nullInt64{&vm.DeletedByTaskID},
 		&vm.CreatedAt,
 		&vm.UpdatedAt,
 	)
@@ -131,13 +138,16 @@ func (dao *VMDAO) GetVMByID(vmID string) (*model.VM, error) {
 	return vm, nil
 }
 
-// ListVMs 列出所有虚拟机
-func (dao *VMDAO) ListVMs() ([]*model.VM, error) {
+// ListVMs 列出所有虚拟机，includeDeleted为false时排除已删除的虚拟机
+func (dao *VMDAO) ListVMs(includeDeleted bool) ([]*model.VM, error) {
 	query := `
-        SELECT id, vm_id, resource_id, name, location, type, status, owner, subscription_id, last_sync_at, created_at, updated_at
+        SELECT id, provider, vm_id, resource_id, name, location, type, status, owner, subscription_id, last_sync_at, deleted_at, deleted_by_task_id, created_at, updated_at
         FROM vms
-        ORDER BY name
     `
+	if !includeDeleted {
+		query += " WHERE deleted_at IS NULL"
+	}
+	query += " ORDER BY name"
 
 	rows, err := dao.db.Query(query)
 	if err != nil {
@@ -150,6 +160,7 @@ func (dao *VMDAO) ListVMs() ([]*model.VM, error) {
 		vm := &model.VM{Tags: make(map[string]string)}
 		err := rows.Scan(
 			&vm.ID,
+			&vm.Provider,
 			&vm.VMID,
 			&vm.ResourceID,
 			&vm.Name,
@@ -159,6 +170,8 @@ func (dao *VMDAO) ListVMs() ([]*model.VM, error) {
 			&vm.Owner,
 			&vm.SubscriptionID,
 			&vm.LastSyncAt,
+			nullTime{&vm.DeletedAt},
+			nullInt64{&vm.DeletedByTaskID},
 			&vm.CreatedAt,
 			&vm.UpdatedAt,
 		)
@@ -197,9 +210,10 @@ func (dao *VMDAO) BeginTx() (*sql.Tx, error) {
 // UpsertVMTx 在事务中插入或更新虚拟机
 func (dao *VMDAO) UpsertVMTx(tx *sql.Tx, vm *model.VM) error {
 	query := `
-        INSERT INTO vms (vm_id, resource_id, name, location, type, status, owner, subscription_id, last_sync_at)
-        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+        INSERT INTO vms (provider, vm_id, resource_id, name, location, type, status, owner, subscription_id, last_sync_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
         ON DUPLICATE KEY UPDATE
+            provider = VALUES(provider),
             resource_id = VALUES(resource_id),
             name = VALUES(name),
             location = VALUES(location),
@@ -207,12 +221,15 @@ func (dao *VMDAO) UpsertVMTx(tx *sql.Tx, vm *model.VM) error {
             status = VALUES(status),
             owner = VALUES(owner),
             subscription_id = VALUES(subscription_id),
-            last_sync_at = VALUES(last_sync_at)
+            last_sync_at = VALUES(last_sync_at),
+            deleted_at = NULL,
+            deleted_by_task_id = NULL
     `
 
 	now := time.Now()
 	_, err := tx.Exec(
 		query,
+		vm.Provider,
 		vm.VMID,
 		vm.ResourceID,
 		vm.Name,
@@ -256,3 +273,52 @@ func (dao *VMDAO) UpsertVMTagsTx(tx *sql.Tx, vmID string, tags map[string]string
 
 	return nil
 }
+
+// MarkVMsDeleted 将范围内本次同步未出现的虚拟机标记为已删除
+// 同时追加删除变更记录、结束这些记录的当前版本并将其检索文档标记为已删除
+func (dao *VMDAO) MarkVMsDeleted(provider, subscriptionID string, syncedBefore time.Time, taskID int64) (int64, error) {
+	query := `
+        UPDATE vms
+        SET deleted_at = ?, deleted_by_task_id = ?
+        WHERE provider = ? AND subscription_id = ? AND last_sync_at < ? AND deleted_at IS NULL
+    `
+
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err := recordDeletionsTx(tx, "vms", model.ItemTypeVM, "vm_id", provider, subscriptionID, syncedBefore, taskID, now); err != nil {
+		return 0, err
+	}
+	if err := closeVersionsForDeletionTx(tx, "vms", model.ItemTypeVM, "vm_id", provider, subscriptionID, syncedBefore, now); err != nil {
+		return 0, err
+	}
+	if err := markSearchDocumentsDeletedTx(tx, "vms", model.ItemTypeVM, "vm_id", provider, subscriptionID, syncedBefore); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(query, now, nullableInt64(taskID), provider, subscriptionID, syncedBefore)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return affected, tx.Commit()
+}
+
+// PurgeDeletedVMs 永久删除在deletedBefore之前被标记删除的虚拟机，标签随外键级联删除，检索文档随之删除
+func (dao *VMDAO) PurgeDeletedVMs(deletedBefore time.Time) (int64, error) {
+	result, err := dao.db.Exec("DELETE FROM vms WHERE deleted_at < ?", deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	if err := deleteOrphanSearchDocuments(dao.db, "vms", model.ItemTypeVM, "vm_id"); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,65 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"net/http"
+)
+
+// csvFlushRows CSV每写出多少行刷新一次，使大列表边生成边发送给客户端
+const csvFlushRows = 500
+
+// csvWriter 逐行写出CSV，每页写完及每csvFlushRows行刷新一次
+type csvWriter struct {
+	w      io.Writer
+	writer *csv.Writer
+	layout *Layout
+	rows   int
+}
+
+// newCSVWriter 写出UTF-8 BOM（以便Excel正确识别中文）和表头
+func newCSVWriter(w io.Writer, layout *Layout) (*csvWriter, error) {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return nil, err
+	}
+	c := &csvWriter{w: w, writer: csv.NewWriter(w), layout: layout}
+	if err := c.writer.Write(layout.Columns()); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// WriteRows 写出一页记录并发送给客户端
+func (c *csvWriter) WriteRows(items interface{}) error {
+	err := c.layout.eachRow(items, func(row []string) error {
+		if err := c.writer.Write(row); err != nil {
+			return err
+		}
+		c.rows++
+		if c.rows%csvFlushRows == 0 {
+			return c.flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return c.flush()
+}
+
+// Close 写出缓冲的内容
+func (c *csvWriter) Close() error {
+	return c.flush()
+}
+
+// flush 将缓冲的CSV写入w，w为http.ResponseWriter时同时发送给客户端
+func (c *csvWriter) flush() error {
+	c.writer.Flush()
+	if err := c.writer.Error(); err != nil {
+		return err
+	}
+	if flusher, ok := c.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,378 @@
+// Package export 将列表接口的结果导出为CSV或XLSX表格
+//
+// 列由元素结构体的json标签决定，map类型的字段（如标签）按键展开为 <字段>.<键> 列，
+// 如 tags.env；time.Time 按RFC3339输出，空指针输出为空。
+package export
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 导出格式
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// 各导出格式的Content-Type
+const (
+	ContentTypeCSV  = "text/csv; charset=utf-8"
+	ContentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// ContentType 返回导出格式对应的Content-Type
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return ContentTypeXLSX
+	}
+	return ContentTypeCSV
+}
+
+// NegotiateFormat 确定响应格式，format参数优先，未指定时取Accept头中第一个支持的类型，默认为JSON
+func NegotiateFormat(format, accept string) (string, error) {
+	switch strings.ToLower(format) {
+	case FormatJSON, FormatCSV, FormatXLSX:
+		return strings.ToLower(format), nil
+	case "":
+	default:
+		return "", fmt.Errorf("不支持的导出格式: %s，可选 json、csv、xlsx", format)
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "application/json":
+			return FormatJSON, nil
+		case "text/csv":
+			return FormatCSV, nil
+		case ContentTypeXLSX:
+			return FormatXLSX, nil
+		}
+	}
+	return FormatJSON, nil
+}
+
+// Filename 生成导出文件名：<name>_<过滤条件>_<时间戳>.<format>
+// 过滤条件按参数名排序，只保留文件名中安全的字符，过长时截断
+func Filename(name string, filters url.Values, format string, now time.Time) string {
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := []string{name}
+	var conditions []string
+	for _, key := range keys {
+		for _, value := range filters[key] {
+			if value != "" {
+				conditions = append(conditions, sanitize(key)+"-"+sanitize(value))
+			}
+		}
+	}
+	if condition := strings.Join(conditions, "_"); condition != "" {
+		if len(condition) > 100 {
+			condition = condition[:100]
+		}
+		parts = append(parts, condition)
+	}
+	parts = append(parts, now.Format("20060102-150405"))
+	return strings.Join(parts, "_") + "." + format
+}
+
+// sanitize 将文件名中不安全的字符替换为"-"
+func sanitize(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		}
+		return '-'
+	}, value)
+}
+
+// field 元素结构体中导出的字段
+type field struct {
+	name  string
+	index int
+	// expand 为true表示map字段，按键展开为多列
+	expand bool
+}
+
+// Layout 导出表格的列，由元素结构体的字段和map字段的键确定，可以逐页输出各行
+type Layout struct {
+	fields  []field
+	columns []string
+}
+
+// NewLayout 由items的元素类型构造列，items只用于确定类型，可以是nil切片
+// keys给出map字段按键展开的列，如 {"tags": ["env", "team"]}，未给出键的map字段不展开；其余规则与NewTable相同
+// 用于分页导出：键由单独的查询得到，不需要先取出全部记录
+func NewLayout(items interface{}, columns []string, keys map[string][]string) (*Layout, error) {
+	elemType, err := elemStruct(reflect.TypeOf(items))
+	if err != nil {
+		return nil, err
+	}
+	return newLayout(elemType, columns, func(f field) []string {
+		return keys[f.name]
+	})
+}
+
+// Table 待导出的表格，由元素为结构体（或结构体指针）的切片构造
+type Table struct {
+	*Layout
+	items reflect.Value
+}
+
+// NewTable 由items构造表格，columns为要导出的列，为空时导出全部字段，map字段展开为items中出现过的全部键
+// columns中的map字段名（如tags）同样展开为全部键，重复的列只保留第一次出现，未知的列返回错误
+func NewTable(items interface{}, columns []string) (*Table, error) {
+	value := reflect.ValueOf(items)
+	elemType, err := elemStruct(value.Type())
+	if err != nil {
+		return nil, err
+	}
+	layout, err := newLayout(elemType, columns, func(f field) []string {
+		return mapKeys(value, f)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Table{Layout: layout, items: value}, nil
+}
+
+// elemStruct 返回切片类型t的元素结构体类型，元素可以是结构体指针
+func elemStruct(t reflect.Type) (reflect.Type, error) {
+	if t == nil || t.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("导出的数据必须是切片")
+	}
+	elemType := t.Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("导出的切片元素必须是结构体")
+	}
+	return elemType, nil
+}
+
+// newLayout 按columns构造列，keys返回map字段展开的键
+func newLayout(elemType reflect.Type, columns []string, keys func(f field) []string) (*Layout, error) {
+	layout := &Layout{fields: structFields(elemType)}
+	if len(columns) == 0 {
+		for _, f := range layout.fields {
+			columns = append(columns, f.name)
+		}
+	}
+
+	byName := make(map[string]field, len(layout.fields))
+	for _, f := range layout.fields {
+		byName[f.name] = f
+	}
+	seen := make(map[string]bool)
+	add := func(column string) {
+		if !seen[column] {
+			seen[column] = true
+			layout.columns = append(layout.columns, column)
+		}
+	}
+	for _, column := range columns {
+		if f, ok := byName[column]; ok {
+			if f.expand {
+				for _, key := range keys(f) {
+					add(f.name + "." + key)
+				}
+			} else {
+				add(column)
+			}
+			continue
+		}
+		name, _, found := strings.Cut(column, ".")
+		if f, ok := byName[name]; !ok || !found || !f.expand {
+			return nil, fmt.Errorf("未知的列: %s", column)
+		}
+		add(column)
+	}
+	return layout, nil
+}
+
+// Columns 返回表头
+func (l *Layout) Columns() []string {
+	return l.columns
+}
+
+// row 返回一个元素各列的值
+func (l *Layout) row(item reflect.Value) []string {
+	item = reflect.Indirect(item)
+	row := make([]string, len(l.columns))
+	if !item.IsValid() {
+		return row
+	}
+
+	for j, column := range l.columns {
+		name, key, found := strings.Cut(column, ".")
+		for _, f := range l.fields {
+			if f.expand && found && f.name == name {
+				m := item.Field(f.index)
+				value := m.MapIndex(reflect.ValueOf(key).Convert(m.Type().Key()))
+				if value.IsValid() {
+					row[j] = formatValue(value)
+				}
+				break
+			}
+			if !f.expand && f.name == column {
+				row[j] = formatValue(item.Field(f.index))
+				break
+			}
+		}
+	}
+	return row
+}
+
+// Len 返回数据行数
+func (t *Table) Len() int {
+	return t.items.Len()
+}
+
+// Row 返回第i行各列的值
+func (t *Table) Row(i int) []string {
+	return t.row(t.items.Index(i))
+}
+
+// mapKeys 返回items全部元素中map字段f出现过的键，按字母排序
+func mapKeys(items reflect.Value, f field) []string {
+	seen := make(map[string]bool)
+	for i := 0; i < items.Len(); i++ {
+		item := reflect.Indirect(items.Index(i))
+		if !item.IsValid() {
+			continue
+		}
+		iter := item.Field(f.index).MapRange()
+		for iter.Next() {
+			seen[iter.Key().String()] = true
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// structFields 按定义顺序返回结构体中带json标签且未被忽略的字段
+func structFields(t reflect.Type) []field {
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		expand := sf.Type.Kind() == reflect.Map && sf.Type.Key().Kind() == reflect.String
+		fields = append(fields, field{name: name, index: i, expand: expand})
+	}
+	return fields
+}
+
+// formatValue 将字段值格式化为单元格文本
+func formatValue(v reflect.Value) string {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Slice, reflect.Array:
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = formatValue(v.Index(i))
+		}
+		return strings.Join(parts, "; ")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// Writer 逐页写出导出文件，Close之后文件才完整
+type Writer interface {
+	// WriteRows 写出一页记录，items须是与构造Layout时元素类型相同的切片
+	WriteRows(items interface{}) error
+	// Close 写出剩余内容并释放资源
+	Close() error
+}
+
+// NewWriter 按format创建逐页写出的Writer，表头在创建时写出
+// CSV每页写完即发送给客户端；XLSX是zip文件，行数据边写边暂存，Close时才整体发送
+func NewWriter(w io.Writer, format string, layout *Layout) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, layout)
+	case FormatXLSX:
+		return newXLSXWriter(w, layout)
+	}
+	return nil, fmt.Errorf("不支持的导出格式: %s", format)
+}
+
+// Write 按format将表格写入w
+func Write(w io.Writer, format string, table *Table) error {
+	writer, err := NewWriter(w, format, table.Layout)
+	if err != nil {
+		return err
+	}
+	if err := writer.WriteRows(table.items.Interface()); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// eachRow 依次以items（切片）中各元素的行调用fn
+func (l *Layout) eachRow(items interface{}, fn func(row []string) error) error {
+	value := reflect.ValueOf(items)
+	if value.Kind() != reflect.Slice {
+		return fmt.Errorf("导出的数据必须是切片")
+	}
+	for i := 0; i < value.Len(); i++ {
+		if err := fn(l.row(value.Index(i))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
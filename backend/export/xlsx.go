@@ -0,0 +1,72 @@
+package export
+
+import (
+	"io"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxSheet 导出的工作表名称
+const xlsxSheet = "Sheet1"
+
+// xlsxWriter 使用excelize的流式写入逐页写入行，行数据超过内存阈值时暂存到临时文件
+// XLSX是zip文件，只能在全部行写入后生成，因此Close时才发送给客户端
+type xlsxWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	sw     *excelize.StreamWriter
+	layout *Layout
+	rows   int
+}
+
+// newXLSXWriter 创建工作簿并写入冻结的表头
+func newXLSXWriter(w io.Writer, layout *Layout) (*xlsxWriter, error) {
+	f := excelize.NewFile()
+	sw, err := f.NewStreamWriter(xlsxSheet)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	// 冻结表头，须在写入行之前设置
+	if err := sw.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := sw.SetRow("A1", toCells(layout.Columns())); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &xlsxWriter{w: w, file: f, sw: sw, layout: layout}, nil
+}
+
+// WriteRows 将一页记录写入工作表
+func (x *xlsxWriter) WriteRows(items interface{}) error {
+	return x.layout.eachRow(items, func(row []string) error {
+		cell, err := excelize.CoordinatesToCellName(1, x.rows+2)
+		if err != nil {
+			return err
+		}
+		x.rows++
+		return x.sw.SetRow(cell, toCells(row))
+	})
+}
+
+// Close 生成工作簿并写入w，之后删除暂存的临时文件
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+	if err := x.sw.Flush(); err != nil {
+		return err
+	}
+	_, err := x.file.WriteTo(x.w)
+	return err
+}
+
+// toCells 将一行文本转换为excelize需要的单元格值
+func toCells(values []string) []interface{} {
+	cells := make([]interface{}, len(values))
+	for i, value := range values {
+		cells[i] = value
+	}
+	return cells
+}
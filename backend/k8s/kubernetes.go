@@ -0,0 +1,221 @@
+package k8s
+
+import (
+	"CMDB/config"
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// 节点上的区域标签
+const regionLabel = "topology.kubernetes.io/region"
+
+// Inventory 集群内的清单对象
+type Inventory struct {
+	Context      string
+	Namespaces   []corev1.Namespace
+	Deployments  []appsv1.Deployment
+	StatefulSets []appsv1.StatefulSet
+	Services     []corev1.Service
+	Nodes        []corev1.Node
+}
+
+// KubernetesHelper 封装kubeconfig加载和集群清单查询
+type KubernetesHelper struct {
+	config config.KubernetesConfig
+	// clients 预先创建的客户端（如fake clientset），为空时从kubeconfig创建
+	clients map[string]kubernetes.Interface
+}
+
+// NewKubernetesHelper 创建新的KubernetesHelper实例
+func NewKubernetesHelper(cfg config.KubernetesConfig) *KubernetesHelper {
+	return &KubernetesHelper{config: cfg}
+}
+
+// NewKubernetesHelperWithClients 使用已有客户端创建KubernetesHelper，上下文 -> 客户端
+func NewKubernetesHelperWithClients(cfg config.KubernetesConfig, clients map[string]kubernetes.Interface) *KubernetesHelper {
+	return &KubernetesHelper{config: cfg, clients: clients}
+}
+
+// AccountName 账号名称
+func (h *KubernetesHelper) AccountName() string {
+	return h.config.Name
+}
+
+// AKSResourceID 获取配置中上下文对应的AKS资源ID
+func (h *KubernetesHelper) AKSResourceID(contextName string) string {
+	return h.config.AKSResourceIDs[contextName]
+}
+
+// loadingRules kubeconfig加载规则
+func (h *KubernetesHelper) loadingRules() *clientcmd.ClientConfigLoadingRules {
+	return &clientcmd.ClientConfigLoadingRules{
+		Precedence: filepath.SplitList(h.config.Kubeconfig),
+	}
+}
+
+// ListContexts 列出需要同步的上下文
+func (h *KubernetesHelper) ListContexts() ([]string, error) {
+	var contexts []string
+	if h.clients != nil {
+		for name := range h.clients {
+			contexts = append(contexts, name)
+		}
+	} else {
+		rawConfig, err := h.loadingRules().Load()
+		if err != nil {
+			return nil, fmt.Errorf("加载kubeconfig失败: %v", err)
+		}
+		for name := range rawConfig.Contexts {
+			contexts = append(contexts, name)
+		}
+	}
+	sort.Strings(contexts)
+
+	if len(h.config.Contexts) == 0 {
+		return contexts, nil
+	}
+
+	available := make(map[string]bool)
+	for _, name := range contexts {
+		available[name] = true
+	}
+	var selected []string
+	for _, name := range h.config.Contexts {
+		if !available[name] {
+			return nil, fmt.Errorf("kubeconfig中不存在上下文: %s", name)
+		}
+		selected = append(selected, name)
+	}
+	return selected, nil
+}
+
+// clientFor 获取上下文对应的客户端
+func (h *KubernetesHelper) clientFor(contextName string) (kubernetes.Interface, error) {
+	if h.clients != nil {
+		client, ok := h.clients[contextName]
+		if !ok {
+			return nil, fmt.Errorf("未知的上下文: %s", contextName)
+		}
+		return client, nil
+	}
+
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		h.loadingRules(),
+		&clientcmd.ConfigOverrides{CurrentContext: contextName},
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("加载上下文 %s 失败: %v", contextName, err)
+	}
+	restConfig.Timeout = 30 * time.Second
+
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("创建Kubernetes客户端失败: %v", err)
+	}
+	return client, nil
+}
+
+// GetInventory 获取集群内的命名空间、Deployment、StatefulSet、Service和节点
+func (h *KubernetesHelper) GetInventory(contextName string) (*Inventory, error) {
+	client, err := h.clientFor(contextName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	opts := metav1.ListOptions{}
+	inventory := &Inventory{Context: contextName}
+
+	namespaces, err := client.CoreV1().Namespaces().List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("获取命名空间失败: %v", err)
+	}
+	inventory.Namespaces = namespaces.Items
+
+	deployments, err := client.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("获取Deployment失败: %v", err)
+	}
+	inventory.Deployments = deployments.Items
+
+	statefulSets, err := client.AppsV1().StatefulSets(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("获取StatefulSet失败: %v", err)
+	}
+	inventory.StatefulSets = statefulSets.Items
+
+	services, err := client.CoreV1().Services(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("获取Service失败: %v", err)
+	}
+	inventory.Services = services.Items
+
+	nodes, err := client.CoreV1().Nodes().List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("获取节点失败: %v", err)
+	}
+	inventory.Nodes = nodes.Items
+
+	return inventory, nil
+}
+
+// Region 集群所在区域，取自节点的区域标签
+func (inv *Inventory) Region() string {
+	for _, node := range inv.Nodes {
+		if region := node.Labels[regionLabel]; region != "" {
+			return region
+		}
+	}
+	return ""
+}
+
+// InferAKSResourceID 根据节点的providerID推断AKS集群的Azure资源ID
+// AKS节点的providerID形如 azure:///subscriptions/<订阅>/resourceGroups/MC_<资源组>_<集群>_<区域>/providers/...
+// 仅适用于默认命名的节点资源组，自定义节点资源组需在配置中指定AKS资源ID
+func (inv *Inventory) InferAKSResourceID() string {
+	for _, node := range inv.Nodes {
+		if !strings.HasPrefix(node.Spec.ProviderID, "azure://") {
+			continue
+		}
+
+		parts := strings.Split(strings.TrimPrefix(node.Spec.ProviderID, "azure://"), "/")
+		var subscriptionID, nodeResourceGroup string
+		for i := 0; i+1 < len(parts); i++ {
+			switch strings.ToLower(parts[i]) {
+			case "subscriptions":
+				subscriptionID = parts[i+1]
+			case "resourcegroups":
+				nodeResourceGroup = parts[i+1]
+			}
+		}
+
+		region := node.Labels[regionLabel]
+		if subscriptionID == "" || region == "" || !strings.HasPrefix(strings.ToLower(nodeResourceGroup), "mc_") {
+			return ""
+		}
+
+		// MC_<资源组>_<集群>_<区域>，集群名称不含下划线时可以唯一拆分
+		name := nodeResourceGroup[len("mc_"):]
+		if !strings.HasSuffix(strings.ToLower(name), "_"+strings.ToLower(region)) {
+			return ""
+		}
+		name = name[:len(name)-len(region)-1]
+		i := strings.LastIndex(name, "_")
+		if i <= 0 || i == len(name)-1 {
+			return ""
+		}
+		return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.ContainerService/managedClusters/%s",
+			subscriptionID, name[:i], name[i+1:])
+	}
+	return ""
+}
@@ -0,0 +1,203 @@
+package k8s
+
+import (
+	"CMDB/config"
+	"CMDB/model"
+	"CMDB/provider"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ProviderName Kubernetes平台名称
+const ProviderName = "kubernetes"
+
+// Kubernetes资源类型
+const (
+	ResourceTypeCluster     = "Kubernetes/Cluster"
+	ResourceTypeNamespace   = "Kubernetes/Namespace"
+	ResourceTypeDeployment  = "Kubernetes/Deployment"
+	ResourceTypeStatefulSet = "Kubernetes/StatefulSet"
+	ResourceTypeService     = "Kubernetes/Service"
+	ResourceTypeNode        = "Kubernetes/Node"
+)
+
+func init() {
+	provider.RegisterFactory(ProviderName, newProviders)
+}
+
+// newProviders 为每个配置的kubeconfig创建Provider
+func newProviders(cfg *config.Config) ([]provider.Provider, error) {
+	var providers []provider.Provider
+	for _, account := range cfg.KubernetesAccounts {
+		providers = append(providers, NewKubernetesProvider(NewKubernetesHelper(account)))
+	}
+	return providers, nil
+}
+
+// KubernetesProvider Kubernetes集群清单发现
+type KubernetesProvider struct {
+	kubernetesHelper *KubernetesHelper
+}
+
+// NewKubernetesProvider 创建新的Kubernetes Provider
+func NewKubernetesProvider(kubernetesHelper *KubernetesHelper) *KubernetesProvider {
+	return &KubernetesProvider{kubernetesHelper: kubernetesHelper}
+}
+
+// Name 平台名称
+func (p *KubernetesProvider) Name() string {
+	return ProviderName
+}
+
+// Account 账号名称
+func (p *KubernetesProvider) Account() string {
+	return p.kubernetesHelper.AccountName()
+}
+
+// Scopes kubeconfig中需要同步的上下文，每个上下文对应一个集群
+func (p *KubernetesProvider) Scopes() ([]provider.Scope, error) {
+	contexts, err := p.kubernetesHelper.ListContexts()
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := make([]provider.Scope, 0, len(contexts))
+	for _, contextName := range contexts {
+		scopes = append(scopes, provider.Scope{ID: contextName, Name: contextName})
+	}
+	return scopes, nil
+}
+
+// DiscoverVMs Kubernetes集群不包含虚拟机，节点作为资源同步
+func (p *KubernetesProvider) DiscoverVMs(scope provider.Scope) ([]*model.VM, error) {
+	return nil, nil
+}
+
+// DiscoverDatabases Kubernetes集群不包含托管数据库
+func (p *KubernetesProvider) DiscoverDatabases(scope provider.Scope) ([]*model.Database, error) {
+	return nil, nil
+}
+
+// DiscoverResources 发现集群内的命名空间、Deployment、StatefulSet、Service和节点，标签作为tags
+// AKS集群内的资源ID以集群的Azure资源ID为前缀，其他集群以 k8s://<上下文> 为前缀并额外记录集群本身
+// 超过model.MaxResourceIDLength的资源ID会被截断并附加哈希，见limitResourceID
+func (p *KubernetesProvider) DiscoverResources(scope provider.Scope) ([]*model.Resource, error) {
+	inventory, err := p.kubernetesHelper.GetInventory(scope.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	region := inventory.Region()
+	clusterID := p.kubernetesHelper.AKSResourceID(scope.ID)
+	if clusterID == "" {
+		clusterID = inventory.InferAKSResourceID()
+	}
+
+	newResource := func(id, name, resourceType, status string, labels map[string]string) *model.Resource {
+		tags := make(map[string]string, len(labels))
+		for k, v := range labels {
+			tags[k] = v
+		}
+		return &model.Resource{
+			Provider:       ProviderName,
+			ResourceID:     limitResourceID(id),
+			Name:           name,
+			Location:       region,
+			ResourceType:   resourceType,
+			Owner:          tags["owner"],
+			Status:         status,
+			SubscriptionID: scope.ID,
+			Tags:           tags,
+		}
+	}
+
+	var resources []*model.Resource
+	if clusterID == "" {
+		// 非AKS集群本身不在任何云平台中，单独记录
+		clusterID = "k8s://" + scope.ID
+		resources = append(resources, newResource(clusterID, scope.Name, ResourceTypeCluster, "", nil))
+	}
+
+	for _, ns := range inventory.Namespaces {
+		resources = append(resources, newResource(
+			fmt.Sprintf("%s/namespaces/%s", clusterID, ns.Name),
+			ns.Name, ResourceTypeNamespace, string(ns.Status.Phase), ns.Labels,
+		))
+	}
+
+	for _, deployment := range inventory.Deployments {
+		resources = append(resources, newResource(
+			namespacedID(clusterID, deployment.ObjectMeta, "deployments"),
+			deployment.Name, ResourceTypeDeployment,
+			replicaStatus(deployment.Status.ReadyReplicas, deployment.Spec.Replicas),
+			deployment.Labels,
+		))
+	}
+
+	for _, statefulSet := range inventory.StatefulSets {
+		resources = append(resources, newResource(
+			namespacedID(clusterID, statefulSet.ObjectMeta, "statefulsets"),
+			statefulSet.Name, ResourceTypeStatefulSet,
+			replicaStatus(statefulSet.Status.ReadyReplicas, statefulSet.Spec.Replicas),
+			statefulSet.Labels,
+		))
+	}
+
+	for _, service := range inventory.Services {
+		resources = append(resources, newResource(
+			namespacedID(clusterID, service.ObjectMeta, "services"),
+			service.Name, ResourceTypeService, string(service.Spec.Type), service.Labels,
+		))
+	}
+
+	for _, node := range inventory.Nodes {
+		resources = append(resources, newResource(
+			fmt.Sprintf("%s/nodes/%s", clusterID, node.Name),
+			node.Name, ResourceTypeNode, nodeStatus(node), node.Labels,
+		))
+	}
+
+	return resources, nil
+}
+
+// namespacedID 构造命名空间内对象的资源ID
+func namespacedID(clusterID string, meta metav1.ObjectMeta, kind string) string {
+	return fmt.Sprintf("%s/namespaces/%s/%s/%s", clusterID, meta.Namespace, kind, meta.Name)
+}
+
+// limitResourceID 将超长的资源ID截断到model.MaxResourceIDLength以内
+// 截断后附加完整ID的哈希，保证不同对象的ID仍然唯一且每次同步结果一致
+func limitResourceID(id string) string {
+	if len(id) <= model.MaxResourceIDLength {
+		return id
+	}
+	sum := sha256.Sum256([]byte(id))
+	suffix := "~" + hex.EncodeToString(sum[:8])
+	return id[:model.MaxResourceIDLength-len(suffix)] + suffix
+}
+
+// replicaStatus 以 就绪副本数/期望副本数 表示工作负载状态
+func replicaStatus(ready int32, desired *int32) string {
+	want := int32(1)
+	if desired != nil {
+		want = *desired
+	}
+	return fmt.Sprintf("%d/%d", ready, want)
+}
+
+// nodeStatus 根据Ready条件判断节点状态
+func nodeStatus(node corev1.Node) string {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			if condition.Status == corev1.ConditionTrue {
+				return "Ready"
+			}
+			return "NotReady"
+		}
+	}
+	return "Unknown"
+}
@@ -0,0 +1,198 @@
+package k8s
+
+import (
+	"CMDB/config"
+	"CMDB/model"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testAKSID = "/subscriptions/sub-1/resourceGroups/rg-apps/providers/Microsoft.ContainerService/managedClusters/aks-prod"
+
+func int32Ptr(n int32) *int32 {
+	return &n
+}
+
+func node(name, providerID string, ready corev1.ConditionStatus) *corev1.Node {
+	n := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{regionLabel: "eastus"}},
+		Spec:       corev1.NodeSpec{ProviderID: providerID},
+	}
+	if ready != "" {
+		n.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}
+	}
+	return n
+}
+
+// clusterObjects 一个集群的清单：命名空间、Deployment、StatefulSet、Service和三个不同状态的节点
+func clusterObjects(providerID string) []runtime.Object {
+	return []runtime.Object{
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "shop", Labels: map[string]string{"owner": "team-shop"}},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop", Labels: map[string]string{"app": "web"}},
+			Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(3)},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 2},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "shop"},
+			Status:     appsv1.StatefulSetStatus{ReadyReplicas: 1},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+		},
+		node("node-ready", providerID, corev1.ConditionTrue),
+		node("node-notready", providerID, corev1.ConditionFalse),
+		node("node-unknown", providerID, ""),
+	}
+}
+
+func discover(t *testing.T, cfg config.KubernetesConfig, clients map[string]kubernetes.Interface, contextName string) map[string]*model.Resource {
+	p := NewKubernetesProvider(NewKubernetesHelperWithClients(cfg, clients))
+	scopes, err := p.Scopes()
+	if err != nil {
+		t.Fatalf("Scopes: %v", err)
+	}
+	for _, scope := range scopes {
+		if scope.ID != contextName {
+			continue
+		}
+		resources, err := p.DiscoverResources(scope)
+		if err != nil {
+			t.Fatalf("DiscoverResources: %v", err)
+		}
+		byID := make(map[string]*model.Resource)
+		for _, resource := range resources {
+			if len(resource.ResourceID) > model.MaxResourceIDLength {
+				t.Errorf("资源ID超过%d个字符: %s", model.MaxResourceIDLength, resource.ResourceID)
+			}
+			byID[resource.ResourceID] = resource
+		}
+		return byID
+	}
+	t.Fatalf("未找到上下文 %s: %+v", contextName, scopes)
+	return nil
+}
+
+func TestDiscoverResourcesNonAKS(t *testing.T) {
+	clients := map[string]kubernetes.Interface{"kind": fake.NewSimpleClientset(clusterObjects("kind://docker/kind/node")...)}
+	resources := discover(t, config.KubernetesConfig{Name: "test"}, clients, "kind")
+
+	want := map[string]struct {
+		resourceType string
+		status       string
+	}{
+		"k8s://kind":                                 {ResourceTypeCluster, ""},
+		"k8s://kind/namespaces/shop":                 {ResourceTypeNamespace, "Active"},
+		"k8s://kind/namespaces/shop/deployments/web": {ResourceTypeDeployment, "2/3"},
+		"k8s://kind/namespaces/shop/statefulsets/db": {ResourceTypeStatefulSet, "1/1"},
+		"k8s://kind/namespaces/shop/services/web":    {ResourceTypeService, "LoadBalancer"},
+		"k8s://kind/nodes/node-ready":                {ResourceTypeNode, "Ready"},
+		"k8s://kind/nodes/node-notready":             {ResourceTypeNode, "NotReady"},
+		"k8s://kind/nodes/node-unknown":              {ResourceTypeNode, "Unknown"},
+	}
+	if len(resources) != len(want) {
+		t.Errorf("得到%d个资源，期望%d个", len(resources), len(want))
+	}
+	for id, w := range want {
+		resource := resources[id]
+		if resource == nil {
+			t.Errorf("缺少资源 %s", id)
+			continue
+		}
+		if resource.ResourceType != w.resourceType || resource.Status != w.status {
+			t.Errorf("%s: 类型 %s 状态 %s，期望 %s %s", id, resource.ResourceType, resource.Status, w.resourceType, w.status)
+		}
+		if resource.Provider != ProviderName || resource.SubscriptionID != "kind" || resource.Location != "eastus" {
+			t.Errorf("%s = %+v", id, resource)
+		}
+	}
+	if ns := resources["k8s://kind/namespaces/shop"]; ns != nil && (ns.Owner != "team-shop" || ns.Tags["owner"] != "team-shop") {
+		t.Errorf("命名空间的标签应作为tags: %+v", ns)
+	}
+}
+
+func TestDiscoverResourcesAKS(t *testing.T) {
+	providerID := "azure:///subscriptions/sub-1/resourceGroups/MC_rg-apps_aks-prod_eastus/providers/Microsoft.Compute/virtualMachineScaleSets/aks-pool/virtualMachines/0"
+	clients := map[string]kubernetes.Interface{
+		"inferred":   fake.NewSimpleClientset(clusterObjects(providerID)...),
+		"configured": fake.NewSimpleClientset(clusterObjects("")...),
+	}
+	cfg := config.KubernetesConfig{Name: "test", AKSResourceIDs: map[string]string{"configured": testAKSID}}
+
+	for _, contextName := range []string{"inferred", "configured"} {
+		resources := discover(t, cfg, clients, contextName)
+		for id := range resources {
+			if !strings.HasPrefix(id, testAKSID+"/") {
+				t.Errorf("%s: AKS集群内的资源ID应以集群资源ID为前缀: %s", contextName, id)
+			}
+			if resources[id].ResourceType == ResourceTypeCluster {
+				t.Errorf("%s: AKS集群本身由Azure同步，不应单独记录", contextName)
+			}
+		}
+		if resources[testAKSID+"/namespaces/shop/deployments/web"] == nil {
+			t.Errorf("%s: 缺少Deployment web", contextName)
+		}
+	}
+}
+
+func TestInferAKSResourceID(t *testing.T) {
+	tests := []struct {
+		providerID string
+		want       string
+	}{
+		{"azure:///subscriptions/sub-1/resourceGroups/MC_rg-apps_aks-prod_eastus/providers/Microsoft.Compute/virtualMachines/vm0", testAKSID},
+		{"azure:///subscriptions/sub-1/resourceGroups/custom-nodes/providers/Microsoft.Compute/virtualMachines/vm0", ""},
+		{"aws:///us-east-1a/i-123", ""},
+	}
+	for _, tt := range tests {
+		inventory := &Inventory{Nodes: []corev1.Node{*node("n", tt.providerID, corev1.ConditionTrue)}}
+		if got := inventory.InferAKSResourceID(); got != tt.want {
+			t.Errorf("InferAKSResourceID(%s) = %s，期望 %s", tt.providerID, got, tt.want)
+		}
+	}
+}
+
+func TestLongResourceIDsAreLimited(t *testing.T) {
+	longName := strings.Repeat("a", 63)
+	objects := []runtime.Object{
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: longName + "-1", Namespace: longName}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: longName + "-2", Namespace: longName}},
+	}
+	longAKSID := "/subscriptions/sub-1/resourceGroups/" + strings.Repeat("r", 40) + "/providers/Microsoft.ContainerService/managedClusters/" + strings.Repeat("c", 63)
+	clients := map[string]kubernetes.Interface{"aks": fake.NewSimpleClientset(objects...)}
+	cfg := config.KubernetesConfig{Name: "test", AKSResourceIDs: map[string]string{"aks": longAKSID}}
+
+	resources := discover(t, cfg, clients, "aks")
+	if len(resources) != 2 {
+		t.Fatalf("两个Deployment截断后的资源ID应不同: %v", resources)
+	}
+	for id := range resources {
+		if len(id) != model.MaxResourceIDLength || !strings.HasPrefix(id, longAKSID) {
+			t.Errorf("截断后的资源ID = %s", id)
+		}
+	}
+	if again := discover(t, cfg, clients, "aks"); len(again) != 2 {
+		t.Errorf("再次同步应得到相同的资源ID")
+	} else {
+		for id := range resources {
+			if again[id] == nil {
+				t.Errorf("截断后的资源ID在两次同步之间不一致: %s", id)
+			}
+		}
+	}
+
+	if short := limitResourceID("k8s://kind/nodes/n1"); short != "k8s://kind/nodes/n1" {
+		t.Errorf("未超长的资源ID不应改变: %s", short)
+	}
+}
@@ -1,10 +1,18 @@
 package main
 
 import (
-	"CMDB/azure"
+	_ "CMDB/aliyun"  // 注册阿里云Provider
+	_ "CMDB/aws"     // 注册AWS Provider
+	_ "CMDB/azure"   // 注册Azure Provider
+	_ "CMDB/k8s"     // 注册Kubernetes Provider
+	_ "CMDB/vsphere" // 注册vSphere Provider
+	"CMDB/ciclass"
 	"CMDB/config"
 	"CMDB/controller"
 	"CMDB/dao"
+	"CMDB/model"
+	"CMDB/policy"
+	"CMDB/provider"
 	"CMDB/repository"
 	"CMDB/scheduler"
 	"CMDB/service"
@@ -64,27 +72,83 @@ func main() {
 	vmDAO := dao.NewVMDAO(db)
 	databaseDAO := dao.NewDatabaseDAO(db)
 	resourceDAO := dao.NewResourceDAO(db)
+	syncTaskDAO := dao.NewSyncTaskDAO(db)
+	historyDAO := dao.NewChangeHistoryDAO(db)
+	versionDAO := dao.NewItemVersionDAO(db)
+	searchDAO := dao.NewSearchDAO(db)
+	complianceDAO := dao.NewComplianceDAO(db)
+	ownerDAO := dao.NewOwnerDAO(db)
+	relationshipDAO := dao.NewRelationshipDAO(db)
+	impactDAO := dao.NewImpactDAO(db)
+	applicationDAO := dao.NewApplicationDAO(db)
 
 	// 初始化Repository
-	vmRepo := repository.NewVMRepository(vmDAO)
-	databaseRepo := repository.NewDatabaseRepository(databaseDAO)
-	resourceRepo := repository.NewResourceRepository(resourceDAO)
-
-	// 初始化Azure Helper
-	azureHelper := azure.NewAzureHelper()
-	if err := azureHelper.Initialize(); err != nil {
-		log.Fatalf("初始化Azure Helper失败: %v", err)
+	vmRepo := repository.NewVMRepository(vmDAO, historyDAO, versionDAO, searchDAO)
+	databaseRepo := repository.NewDatabaseRepository(databaseDAO, historyDAO, versionDAO, searchDAO)
+	resourceRepo := repository.NewResourceRepository(resourceDAO, historyDAO, versionDAO, searchDAO)
+	syncTaskRepo := repository.NewSyncTaskRepository(syncTaskDAO)
+	historyRepo := repository.NewChangeHistoryRepository(historyDAO)
+	searchRepo := repository.NewSearchRepository(searchDAO)
+	complianceRepo := repository.NewComplianceRepository(complianceDAO)
+	ownerRepo := repository.NewOwnerRepository(ownerDAO)
+	relationshipRepo := repository.NewRelationshipRepository(relationshipDAO)
+	impactRepo := repository.NewImpactRepository(impactDAO)
+	applicationRepo := repository.NewApplicationRepository(applicationDAO)
+
+	// 根据配置创建所有已注册平台的Provider
+	registry, err := provider.BuildRegistry(cfg)
+	if err != nil {
+		log.Fatalf("初始化Provider失败: %v", err)
+	}
+	if len(registry.Providers()) == 0 {
+		log.Fatalf("未配置任何云平台账号")
+	}
+
+	// 加载标签策略，未配置时不评估标签合规
+	var evaluator *policy.Evaluator
+	if cfg.TagPolicyFile != "" {
+		policies, err := policy.LoadFile(cfg.TagPolicyFile)
+		if err != nil {
+			log.Fatalf("加载标签策略失败: %v", err)
+		}
+		evaluator, err = policy.NewEvaluator(policies)
+		if err != nil {
+			log.Fatalf("标签策略无效: %v", err)
+		}
+		log.Printf("已加载标签策略 %d 条", len(policies))
 	}
 
-	// 初始化Azure Service
-	azureService := azure.NewAzureService(azureHelper, vmRepo, databaseRepo, resourceRepo)
+	// 加载CI类定义，未配置时不能创建手动维护的CI
+	var classes []model.CIClass
+	if cfg.CIClassFile != "" {
+		classes, err = ciclass.LoadFile(cfg.CIClassFile)
+		if err != nil {
+			log.Fatalf("加载CI类定义失败: %v", err)
+		}
+	}
+	ciClassRegistry, err := ciclass.NewRegistry(classes)
+	if err != nil {
+		log.Fatalf("CI类定义无效: %v", err)
+	}
+	if len(classes) > 0 {
+		log.Printf("已加载CI类 %d 个", len(classes))
+	}
 
 	// 初始化Service
-	syncService := service.NewSyncService(azureService, resourceRepo, vmRepo, databaseRepo)
+	complianceService := service.NewComplianceService(evaluator, resourceRepo, complianceRepo)
+	ownerService := service.NewOwnerService(registry, ownerRepo, time.Duration(cfg.OwnerCacheTTLHours)*time.Hour)
+	syncService := service.NewSyncService(registry, resourceRepo, vmRepo, databaseRepo, syncTaskRepo, relationshipRepo, ownerService, complianceService,
+		time.Duration(cfg.DeletedRetentionDays)*24*time.Hour)
+	tagService := service.NewTagService(registry, resourceRepo, vmRepo, databaseRepo, searchRepo)
+	relationshipService := service.NewRelationshipService(relationshipRepo)
+	topologyService := service.NewTopologyService(resourceRepo, relationshipService)
+	impactService := service.NewImpactService(impactRepo, relationshipRepo, ownerService)
+	applicationService := service.NewApplicationService(applicationRepo, resourceRepo)
+	manualCIService := service.NewManualCIService(ciClassRegistry, resourceRepo)
 	// 删除未使用的queryService变量
 
 	// 初始化Controller
-	apiController := controller.NewAPIController(vmRepo, databaseRepo, azureService)
+	apiController := controller.NewAPIController(vmRepo, databaseRepo, resourceRepo, historyRepo, searchRepo, syncService, tagService, complianceService, ownerService, relationshipService, topologyService, impactService, applicationService, manualCIService)
 
 	// 注册路由
 	mux := http.NewServeMux()
@@ -0,0 +1,80 @@
+// model/application.go
+package model
+
+import (
+	"time"
+)
+
+// 应用CI的种类
+const (
+	ApplicationKindApplication     = "application"
+	ApplicationKindBusinessService = "business_service"
+)
+
+// ApplicationKinds 全部应用种类，用于校验
+var ApplicationKinds = map[string]bool{
+	ApplicationKindApplication:     true,
+	ApplicationKindBusinessService: true,
+}
+
+// 应用的重要程度
+const (
+	CriticalityLow      = "low"
+	CriticalityMedium   = "medium"
+	CriticalityHigh     = "high"
+	CriticalityCritical = "critical"
+)
+
+// Criticalities 全部重要程度，用于校验
+var Criticalities = map[string]bool{
+	CriticalityLow:      true,
+	CriticalityMedium:   true,
+	CriticalityHigh:     true,
+	CriticalityCritical: true,
+}
+
+// ApplicationTagRule 应用的标签规则，带有该标签的资源属于应用，Value为空时只要求存在该标签
+type ApplicationTagRule struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// String 标签规则的简要描述，用于导出表格
+func (r ApplicationTagRule) String() string {
+	if r.Value == "" {
+		return r.Key
+	}
+	return r.Key + "=" + r.Value
+}
+
+// Application 应用或业务服务CI，成员为手动指定的资源和满足任一标签规则的资源
+type Application struct {
+	ID           int64  `json:"id"`
+	Name         string `json:"name"`
+	Kind         string `json:"kind"`
+	Description  string `json:"description"`
+	Owner        string `json:"owner"`
+	Criticality  string `json:"criticality"`
+	SupportGroup string `json:"support_group"`
+	// TagRules 标签规则，满足任一规则的资源属于应用
+	TagRules []ApplicationTagRule `json:"tag_rules"`
+	// Members 手动指定的成员资源ID
+	Members []string `json:"members"`
+	// ResourceCount 属于应用的未删除资源数，包括手动指定和按标签规则匹配的资源
+	ResourceCount int        `json:"resource_count"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	OwnerInfo     *OwnerInfo `json:"owner_info,omitempty"` // 仅在查询响应中填充
+}
+
+// ApplicationFilter 应用查询条件，空字段表示不过滤
+type ApplicationFilter struct {
+	Kind        string
+	Criticality string
+	Owner       string
+}
+
+// ApplicationMembersRequest 添加或移除应用手动成员的请求
+type ApplicationMembersRequest struct {
+	ResourceIDs []string `json:"resource_ids"`
+}
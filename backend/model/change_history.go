@@ -0,0 +1,42 @@
+// model/change_history.go
+package model
+
+import (
+	"time"
+)
+
+// 配置项类型
+const (
+	ItemTypeResource = "resource"
+	ItemTypeVM       = "vm"
+	ItemTypeDatabase = "database"
+)
+
+// 变更类型
+const (
+	ChangeTypeCreated  = "created"
+	ChangeTypeUpdated  = "updated"
+	ChangeTypeDeleted  = "deleted"
+	ChangeTypeRestored = "restored"
+)
+
+// TagFieldPrefix 标签变更的字段名前缀，如 tags.owner
+const TagFieldPrefix = "tags."
+
+// AttributeFieldPrefix 手动维护的CI属性变更的字段名前缀，如 attributes.vendor
+const AttributeFieldPrefix = "attributes."
+
+// ChangeRecord 配置项变更记录，只追加不修改
+// 更新时每个变化的字段对应一条记录；创建、删除、恢复各对应一条不带字段的记录
+type ChangeRecord struct {
+	ID         int64     `json:"id"`
+	ItemType   string    `json:"item_type"`
+	ItemID     string    `json:"item_id"`
+	ResourceID string    `json:"resource_id"`
+	ChangeType string    `json:"change_type"`
+	Field      string    `json:"field,omitempty"`
+	OldValue   *string   `json:"old_value"`
+	NewValue   *string   `json:"new_value"`
+	SyncTaskID *int64    `json:"sync_task_id"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
@@ -0,0 +1,99 @@
+// model/compliance.go
+package model
+
+import (
+	"time"
+)
+
+// TagPolicy 标签策略，要求范围内的资源带有标签Key且取值符合要求
+// ResourceTypes和Subscriptions为空表示不限制；AllowedValues和Pattern都为空时不检查取值
+type TagPolicy struct {
+	Name     string `json:"name"`
+	Key      string `json:"key"`
+	Required bool   `json:"required"`
+	// AllowedValues 允许的取值
+	AllowedValues []string `json:"allowed_values,omitempty"`
+	// Pattern 取值须匹配的正则表达式，与AllowedValues同时指定时满足其一即可
+	Pattern string `json:"pattern,omitempty"`
+	// ResourceTypes 适用的资源类型，可以是完整类型或最后一段，如 virtualMachines
+	ResourceTypes []string `json:"resource_types,omitempty"`
+	// Subscriptions 适用的订阅（同步范围）ID
+	Subscriptions []string `json:"subscriptions,omitempty"`
+}
+
+// 违规原因
+const (
+	ViolationMissing      = "missing"
+	ViolationInvalidValue = "invalid_value"
+)
+
+// TagViolation 资源违反的一条标签策略
+type TagViolation struct {
+	Policy string `json:"policy"`
+	TagKey string `json:"tag_key"`
+	Reason string `json:"reason"`
+	Value  string `json:"value,omitempty"`
+}
+
+// ResourceCompliance 资源在最近一次评估中的合规结果
+type ResourceCompliance struct {
+	ResourceID     string          `json:"resource_id"`
+	Name           string          `json:"name"`
+	ResourceType   string          `json:"resource_type"`
+	SubscriptionID string          `json:"subscription_id"`
+	Owner          string          `json:"owner"`
+	OwnerSource    string          `json:"owner_source"`
+	Compliant      bool            `json:"compliant"`
+	Violations     []*TagViolation `json:"violations"`
+	EvaluatedAt    time.Time       `json:"evaluated_at"`
+}
+
+// ComplianceGroup 按所有者、所有者来源或订阅汇总的合规情况
+type ComplianceGroup struct {
+	Key                string  `json:"key"`
+	TotalResources     int     `json:"total_resources"`
+	CompliantResources int     `json:"compliant_resources"`
+	ViolationCount     int     `json:"violation_count"`
+	ComplianceRate     float64 `json:"compliance_rate"`
+}
+
+// ComplianceSnapshot 一次评估的总体合规情况，按时间排列即为合规趋势
+type ComplianceSnapshot struct {
+	EvaluatedAt        time.Time `json:"evaluated_at"`
+	TotalResources     int       `json:"total_resources"`
+	CompliantResources int       `json:"compliant_resources"`
+	ViolationCount     int       `json:"violation_count"`
+	ComplianceRate     float64   `json:"compliance_rate"`
+}
+
+// ComplianceReport 合规报告，ByResource只包含不合规的资源并分页
+type ComplianceReport struct {
+	Policies       []TagPolicy           `json:"policies"`
+	Summary        *ComplianceSnapshot   `json:"summary"`
+	ByResource     []*ResourceCompliance `json:"by_resource"`
+	ResourceTotal  int                   `json:"resource_total"`
+	Limit          int                   `json:"limit"`
+	Offset         int                   `json:"offset"`
+	ByOwner        []*ComplianceGroup    `json:"by_owner"`
+	ByOwnerSource  []*ComplianceGroup    `json:"by_owner_source"` // 区分打了owner标签和推断出所有者的资源
+	BySubscription []*ComplianceGroup    `json:"by_subscription"`
+	Trend          []*ComplianceSnapshot `json:"trend"`
+}
+
+// ComplianceRate 计算合规率（0到1），没有资源时视为全部合规
+func ComplianceRate(compliant, total int) float64 {
+	if total == 0 {
+		return 1
+	}
+	return float64(compliant) / float64(total)
+}
+
+// ComplianceFilter 合规报告查询条件，空字段表示不过滤
+type ComplianceFilter struct {
+	SubscriptionID string
+	Owner          string
+	OwnerSource    string
+	// Limit 和 Offset 为不合规资源列表的分页参数
+	Limit  int
+	Offset int
+}
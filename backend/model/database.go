@@ -7,31 +7,70 @@ import (
 
 // Database 数据库模型
 type Database struct {
-	ID             int64             `json:"-"`
-	DatabaseID     string            `json:"database_id"`
-	ResourceID     string            `json:"resource_id"`
-	Name           string            `json:"name"`
-	Location       string            `json:"location"`
-	Server         string            `json:"server"`
-	DBType         string            `json:"db_type"`
-	Version        string            `json:"version"`
-	Status         string            `json:"status"`
-	Owner          string            `json:"owner"`
-	SubscriptionID string            `json:"subscription_id"`
-	Tags           map[string]string `json:"tags"`
-	LastSyncAt     time.Time         `json:"last_sync_at"`
-	CreatedAt      time.Time         `json:"created_at"`
-	UpdatedAt      time.Time         `json:"updated_at"`
+	ID              int64             `json:"-"`
+	Provider        string            `json:"provider"`
+	DatabaseID      string            `json:"database_id"`
+	ResourceID      string            `json:"resource_id"`
+	Name            string            `json:"name"`
+	Location        string            `json:"location"`
+	Server          string            `json:"server"`
+	DBType          string            `json:"db_type"`
+	Version         string            `json:"version"`
+	Status          string            `json:"status"`
+	Owner           string            `json:"owner"`
+	SubscriptionID  string            `json:"subscription_id"`
+	Tags            map[string]string `json:"tags"`
+	LastSyncAt      time.Time         `json:"last_sync_at"`
+	DeletedAt       *time.Time        `json:"deleted_at,omitempty"`
+	DeletedByTaskID *int64            `json:"deleted_by_task_id,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+	OwnerInfo       *OwnerInfo        `json:"owner_info,omitempty"` // 仅在查询响应中填充
 }
 
-// SyncTask 同步任务模型
+// 同步任务状态
+const (
+	SyncTaskStatusRunning = "RUNNING"
+	SyncTaskStatusSuccess = "SUCCESS"
+	SyncTaskStatusFailed  = "FAILED"
+)
+
+// 同步任务类型，对应同步的资源种类
+const (
+	SyncTaskTypeResources = "resources"
+	SyncTaskTypeVMs       = "vms"
+	SyncTaskTypeDatabases = "databases"
+	// SyncTaskTypeRelationships 资源关系，只对支持发现关系的Provider执行
+	SyncTaskTypeRelationships = "relationships"
+)
+
+// 同步触发方式
+const (
+	SyncTriggerScheduled = "scheduled"
+	SyncTriggerManual    = "manual"
+)
+
+// SyncTask 同步任务模型，每次同步中每个Provider账号的每种资源对应一条记录
 type SyncTask struct {
-	ID          int64     `json:"id"`
-	TaskType    string    `json:"task_type"`
-	Status      string    `json:"status"`
-	StartTime   time.Time `json:"start_time"`
-	EndTime     time.Time `json:"end_time"`
-	ItemCount   int       `json:"item_count"`
-	ErrorMsg    string    `json:"error_msg"`
-	CreatedAt   time.Time `json:"created_at"`
-}
\ No newline at end of file
+	ID           int64      `json:"id"`
+	Provider     string     `json:"provider"`
+	Account      string     `json:"account"`
+	TaskType     string     `json:"task_type"`
+	Trigger      string     `json:"trigger"`
+	Status       string     `json:"status"`
+	StartTime    time.Time  `json:"start_time"`
+	EndTime      *time.Time `json:"end_time,omitempty"`
+	ItemCount    int        `json:"item_count"`
+	DeletedCount int        `json:"deleted_count"`
+	ErrorMsg     string     `json:"error_msg"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// SyncTaskFilter 同步任务查询条件，空字段表示不过滤
+type SyncTaskFilter struct {
+	Provider string
+	Account  string
+	TaskType string
+	Status   string
+	Limit    int
+}
@@ -0,0 +1,84 @@
+// model/impact.go
+package model
+
+import (
+	"time"
+)
+
+// 受影响CI的分类
+const (
+	ImpactCategoryVM       = "vm"
+	ImpactCategoryDatabase = "database"
+	ImpactCategoryOther    = "other"
+)
+
+// 判定CI受影响的依据
+const (
+	// ImpactReasonRelationship 同步时发现的关系中依赖于上游CI，如网络接口位于子网、专用终结点连接到目标资源
+	ImpactReasonRelationship = "relationship"
+	// ImpactReasonChild 资源ID位于上游CI的资源ID之下，如SQL服务器下的数据库
+	ImpactReasonChild = "child"
+	// ImpactReasonServer 数据库的server字段为上游CI（数据库服务器）
+	ImpactReasonServer = "server"
+)
+
+// ImpactedItem 影响分析中受影响的CI
+type ImpactedItem struct {
+	ResourceID     string `json:"resource_id"`
+	Name           string `json:"name"`
+	Type           string `json:"type"`
+	Category       string `json:"category"`
+	Provider       string `json:"provider"`
+	SubscriptionID string `json:"subscription_id"`
+	Status         string `json:"status"`
+	Owner          string `json:"owner"`
+	// Depth 与分析起点之间的依赖层数，起点为0
+	Depth int `json:"depth"`
+	// Reason 判定受影响的依据，见ImpactReason*常量；Relationship为依据是关系时的关系类型
+	Reason       string `json:"reason"`
+	Relationship string `json:"relationship,omitempty"`
+	// DependsOn 依赖路径上的上一个CI
+	DependsOn string `json:"depends_on"`
+	// InInventory 是否为资源清单中的资源，子网等只出现在关系中
+	InInventory bool       `json:"in_inventory"`
+	OwnerInfo   *OwnerInfo `json:"owner_info,omitempty"`
+}
+
+// ImpactedOwner 受影响CI的所有者及其名下受影响的CI数
+type ImpactedOwner struct {
+	Owner         string     `json:"owner"`
+	OwnerInfo     *OwnerInfo `json:"owner_info,omitempty"`
+	ItemCount     int        `json:"item_count"`
+	VMCount       int        `json:"vm_count"`
+	DatabaseCount int        `json:"database_count"`
+}
+
+// ImpactSummary 影响分析的汇总
+type ImpactSummary struct {
+	Total     int `json:"total"`
+	VMs       int `json:"vms"`
+	Databases int `json:"databases"`
+	Others    int `json:"others"`
+	Owners    int `json:"owners"`
+}
+
+// ImpactReport 对某个CI做维护时的影响分析报告，列出直接或间接依赖它的CI
+type ImpactReport struct {
+	Root        *ImpactedItem    `json:"root"`
+	Depth       int              `json:"depth"`
+	GeneratedAt time.Time        `json:"generated_at"`
+	Summary     ImpactSummary    `json:"summary"`
+	VMs         []*ImpactedItem  `json:"vms"`
+	Databases   []*ImpactedItem  `json:"databases"`
+	Others      []*ImpactedItem  `json:"others"`
+	Owners      []*ImpactedOwner `json:"owners"`
+	Truncated   bool             `json:"truncated"` // 受影响的CI数超过上限，未完整分析
+}
+
+// Items 全部受影响的CI，依次为虚拟机、数据库和其他，用于导出报告
+func (r *ImpactReport) Items() []*ImpactedItem {
+	items := make([]*ImpactedItem, 0, len(r.VMs)+len(r.Databases)+len(r.Others))
+	items = append(items, r.VMs...)
+	items = append(items, r.Databases...)
+	return append(items, r.Others...)
+}
@@ -0,0 +1,53 @@
+// model/manual_ci.go
+package model
+
+// CI属性的取值类型，属性值统一以字符串保存
+const (
+	AttributeTypeString  = "string"
+	AttributeTypeNumber  = "number"
+	AttributeTypeBoolean = "boolean"
+	AttributeTypeDate    = "date" // YYYY-MM-DD
+)
+
+// AttributeTypes 全部属性类型，用于校验
+var AttributeTypes = map[string]bool{
+	AttributeTypeString:  true,
+	AttributeTypeNumber:  true,
+	AttributeTypeBoolean: true,
+	AttributeTypeDate:    true,
+}
+
+// CIAttribute CI类定义的属性，Type为空时为string
+type CIAttribute struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Required    bool   `json:"required"`
+	Description string `json:"description,omitempty"`
+	// AllowedValues 允许的取值
+	AllowedValues []string `json:"allowed_values,omitempty"`
+	// Pattern 取值须匹配的正则表达式，与AllowedValues同时指定时须都满足
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// MaxCIClassNameLength 类名的最大长度，类名作为资源类型保存，与resources.resource_type列一致
+const MaxCIClassNameLength = 50
+
+// CIClass 手动维护的CI的类，定义CI可以带有的属性和必须带有的标签
+type CIClass struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Attributes  []CIAttribute `json:"attributes"`
+	// RequiredTags 该类的CI必须带有的标签键
+	RequiredTags []string `json:"required_tags,omitempty"`
+}
+
+// ManualCIRequest 创建或更新手动维护的CI的请求，更新时整体替换属性和标签
+type ManualCIRequest struct {
+	Class      string            `json:"class"`
+	Name       string            `json:"name"`
+	Location   string            `json:"location"`
+	Owner      string            `json:"owner"`
+	Status     string            `json:"status"` // 默认为active
+	Attributes map[string]string `json:"attributes"`
+	Tags       map[string]string `json:"tags"`
+}
@@ -0,0 +1,70 @@
+// model/owner.go
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// 所有者在目录中的类型
+const (
+	OwnerKindUser  = "user"
+	OwnerKindGroup = "group"
+)
+
+// 所有者状态
+const (
+	OwnerStatusActive   = "active"
+	OwnerStatusDisabled = "disabled"
+	OwnerStatusNotFound = "not_found"
+	// OwnerStatusUnresolvable 所有者不是UPN、邮箱或对象ID，无法在目录中查找
+	OwnerStatusUnresolvable = "unresolvable"
+)
+
+// 资源所有者的来源，没有所有者时为空
+const (
+	// OwnerSourceTag 取自资源的owner标签
+	OwnerSourceTag = "tag"
+	// OwnerSourceRoleAssignment 推断自资源或其资源组上Owner/Contributor角色的分配对象
+	OwnerSourceRoleAssignment = "role_assignment"
+	// OwnerSourceCreator 推断自活动日志中创建资源的主体
+	OwnerSourceCreator = "creator"
+	// OwnerSourceManual 手动维护的CI上直接指定
+	OwnerSourceManual = "manual"
+)
+
+// OwnerLookup 资源已保存的所有者，以及上次在活动日志中未找到其创建者的时间，同步推断所有者时使用
+type OwnerLookup struct {
+	Owner            string
+	OwnerSource      string
+	CreatorCheckedAt *time.Time
+}
+
+// OwnerInfo 所有者在Entra ID中对应的用户或组，按所有者取值缓存到ExpiresAt
+type OwnerInfo struct {
+	// Value 资源上记录的所有者取值（owner标签）
+	Value             string `json:"value"`
+	Status            string `json:"status"`
+	Kind              string `json:"kind,omitempty"`
+	ObjectID          string `json:"object_id,omitempty"`
+	DisplayName       string `json:"display_name,omitempty"`
+	UserPrincipalName string `json:"user_principal_name,omitempty"`
+	Mail              string `json:"mail,omitempty"`
+	Department        string `json:"department,omitempty"`
+	ManagerID         string `json:"manager_id,omitempty"`
+	ManagerName       string `json:"manager_name,omitempty"`
+	// Flagged 所有者账号已禁用或已不存在
+	Flagged    bool      `json:"flagged"`
+	ResolvedAt time.Time `json:"resolved_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// ResourceCount 所有者名下未删除的资源数，仅在所有者列表中返回
+	ResourceCount int `json:"resource_count,omitempty"`
+}
+
+// String 所有者的简要描述，用于导出表格
+func (o OwnerInfo) String() string {
+	if o.DisplayName == "" {
+		return fmt.Sprintf("%s (%s)", o.Value, o.Status)
+	}
+	return fmt.Sprintf("%s <%s> (%s)", o.DisplayName, o.Value, o.Status)
+}
@@ -0,0 +1,86 @@
+// model/relationship.go
+package model
+
+import (
+	"time"
+)
+
+// 资源关系类型，关系由依赖方（Source）指向被依赖方（Target）
+const (
+	// RelationshipHasNIC 虚拟机或专用终结点使用网络接口
+	RelationshipHasNIC = "has_nic"
+	// RelationshipHasDisk 虚拟机挂载托管磁盘
+	RelationshipHasDisk = "has_disk"
+	// RelationshipInSubnet 网络接口或专用终结点位于子网
+	RelationshipInSubnet = "in_subnet"
+	// RelationshipInVNet 子网属于虚拟网络
+	RelationshipInVNet = "in_vnet"
+	// RelationshipHostedOn 数据库托管在数据库服务器上
+	RelationshipHostedOn = "hosted_on"
+	// RelationshipPrivateEndpointFor 专用终结点连接的目标资源
+	RelationshipPrivateEndpointFor = "private_endpoint_for"
+	// RelationshipInResourceGroup 资源属于资源组
+	RelationshipInResourceGroup = "in_resource_group"
+	// RelationshipInSubscription 资源组属于订阅
+	RelationshipInSubscription = "in_subscription"
+)
+
+// RelationshipTypes 全部关系类型，用于校验查询参数
+var RelationshipTypes = map[string]bool{
+	RelationshipHasNIC:             true,
+	RelationshipHasDisk:            true,
+	RelationshipInSubnet:           true,
+	RelationshipInVNet:             true,
+	RelationshipHostedOn:           true,
+	RelationshipPrivateEndpointFor: true,
+	RelationshipInResourceGroup:    true,
+	RelationshipInSubscription:     true,
+}
+
+// 查询关系的方向，相对于查询的资源
+const (
+	DirectionOutgoing = "outgoing"
+	DirectionIncoming = "incoming"
+	DirectionBoth     = "both"
+)
+
+// Relationship 两个资源之间的有类型关系，端点可以是子网、资源组、订阅等不在资源清单中的对象
+type Relationship struct {
+	SourceID   string `json:"source_id"`
+	SourceType string `json:"source_type"`
+	TargetID   string `json:"target_id"`
+	TargetType string `json:"target_type"`
+	Type       string `json:"type"`
+	Provider   string `json:"provider"`
+	// SubscriptionID 发现关系的同步范围
+	SubscriptionID string    `json:"subscription_id"`
+	LastSyncAt     time.Time `json:"last_sync_at"`
+}
+
+// RelationshipFilter 关系查询条件
+type RelationshipFilter struct {
+	// Direction 相对于查询资源的方向，见Direction*常量，为空时按both处理
+	Direction string
+	// Types 关系类型，为空时不过滤
+	Types []string
+}
+
+// GraphNode 关系图中的节点
+type GraphNode struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Depth 与起点之间的最少关系数
+	Depth int `json:"depth"`
+	// InInventory 节点是否为资源清单中的资源，子网、资源组等只出现在关系中
+	InInventory bool `json:"in_inventory"`
+}
+
+// ResourceGraph 从某个资源出发遍历得到的关系图
+type ResourceGraph struct {
+	Root      string          `json:"root"`
+	Depth     int             `json:"depth"`
+	Nodes     []*GraphNode    `json:"nodes"`
+	Edges     []*Relationship `json:"edges"`
+	Truncated bool            `json:"truncated"` // 节点数超过上限，未完整遍历
+}
@@ -7,16 +7,82 @@ import (
 
 // Resource 资源基本模型
 type Resource struct {
-	ID             int64             `json:"-"`
-	ResourceID     string            `json:"resource_id"`
-	Name           string            `json:"name"`
-	Location       string            `json:"location"`
-	ResourceType   string            `json:"resource_type"`
-	Owner          string            `json:"owner"`
-	Status         string            `json:"status"`
-	SubscriptionID string            `json:"subscription_id"`
-	Tags           map[string]string `json:"tags"`
-	LastSyncAt     time.Time         `json:"last_sync_at"`
-	CreatedAt      time.Time         `json:"created_at"`
-	UpdatedAt      time.Time         `json:"updated_at"`
-}
\ No newline at end of file
+	ID              int64             `json:"-"`
+	Provider        string            `json:"provider"`
+	ResourceID      string            `json:"resource_id"`
+	Name            string            `json:"name"`
+	Location        string            `json:"location"`
+	ResourceType    string            `json:"resource_type"`
+	Owner           string            `json:"owner"`
+	OwnerSource     string            `json:"owner_source"` // 所有者的来源，见OwnerSource*常量
+	Source          string            `json:"source"`       // 资源的来源，见ResourceSource*常量
+	Status          string            `json:"status"`
+	SubscriptionID  string            `json:"subscription_id"`
+	Tags            map[string]string `json:"tags"`
+	Attributes      map[string]string `json:"attributes,omitempty"` // 手动维护的CI按其类定义的属性
+	LastSyncAt      time.Time         `json:"last_sync_at"`
+	DeletedAt       *time.Time        `json:"deleted_at,omitempty"`
+	DeletedByTaskID *int64            `json:"deleted_by_task_id,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+	OwnerInfo       *OwnerInfo        `json:"owner_info,omitempty"` // 仅在查询响应中填充
+}
+
+// 资源的来源
+const (
+	// ResourceSourceSync 由同步从云平台发现
+	ResourceSourceSync = "sync"
+	// ResourceSourceManual 通过接口手动维护，同步不会覆盖或标记删除
+	ResourceSourceManual = "manual"
+)
+
+// ProviderManual 手动维护的CI使用的平台名称，不对应任何同步Provider
+const ProviderManual = "manual"
+
+// MaxResourceIDLength 资源ID的最大长度，与resources.resource_id列一致
+const MaxResourceIDLength = 255
+
+// ResourceSortFields 资源列表允许的排序字段
+var ResourceSortFields = map[string]bool{
+	"name":            true,
+	"resource_type":   true,
+	"location":        true,
+	"subscription_id": true,
+	"owner":           true,
+	"status":          true,
+	"provider":        true,
+	"last_sync_at":    true,
+	"created_at":      true,
+}
+
+// ResourceFilter 资源查询条件，空字段表示不过滤
+type ResourceFilter struct {
+	Provider       string
+	ResourceType   string
+	Location       string
+	SubscriptionID string
+	ResourceGroup  string // 资源组名称，按资源ID中的resourceGroups段匹配（忽略大小写）
+	Owner          string
+	OwnerSource    string
+	Source         string
+	Status         string
+	// TagKey 标签键，TagValue为空时只要求存在该标签
+	TagKey         string
+	TagValue       string
+	IncludeDeleted bool
+	ApplicationID  int64 // 只查属于该应用的资源，不为0时只用于当前资源清单
+	// Sort 排序字段，取值见ResourceSortFields，默认为name
+	Sort string
+	Desc bool
+	// Limit 和 Offset 分页参数
+	Limit  int
+	Offset int
+}
+
+// ResourcePage 分页的资源列表，Total为满足条件的资源总数
+type ResourcePage struct {
+	Items  []*Resource `json:"items"`
+	Total  int         `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
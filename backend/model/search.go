@@ -0,0 +1,45 @@
+// model/search.go
+package model
+
+// SearchResult 搜索结果中的一个配置项，可以是资源、虚拟机或数据库
+type SearchResult struct {
+	ItemType       string `json:"item_type"`
+	ItemID         string `json:"item_id"`
+	ResourceID     string `json:"resource_id"`
+	Provider       string `json:"provider"`
+	Name           string `json:"name"`
+	Type           string `json:"type"`
+	Location       string `json:"location"`
+	Owner          string `json:"owner"`
+	Status         string `json:"status"`
+	SubscriptionID string `json:"subscription_id"`
+}
+
+// SearchPage 分页的搜索结果，Total为满足条件的配置项总数
+type SearchPage struct {
+	Items  []*SearchResult `json:"items"`
+	Total  int             `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+// TextSearchResult 全文搜索结果，Highlights为匹配字段 -> 带高亮标记的内容，标签字段为 tags.<键>
+type TextSearchResult struct {
+	ItemType   string            `json:"item_type"`
+	ItemID     string            `json:"item_id"`
+	ResourceID string            `json:"resource_id"`
+	Provider   string            `json:"provider"`
+	Name       string            `json:"name"`
+	Owner      string            `json:"owner"`
+	Tags       map[string]string `json:"tags"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// TextSearchPage 分页的全文搜索结果，按相关度倒序
+type TextSearchPage struct {
+	Items  []*TextSearchResult `json:"items"`
+	Total  int                 `json:"total"`
+	Limit  int                 `json:"limit"`
+	Offset int                 `json:"offset"`
+}
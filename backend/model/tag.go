@@ -0,0 +1,44 @@
+// model/tag.go
+package model
+
+// 批量编辑标签时单个资源的处理状态
+const (
+	// BulkTagStatusPending 预览时表示标签将被修改
+	BulkTagStatusPending   = "pending"
+	BulkTagStatusUnchanged = "unchanged"
+	BulkTagStatusUpdated   = "updated"
+	BulkTagStatusFailed    = "failed"
+	// BulkTagStatusPartial 平台上的标签已部分修改，如添加成功但删除失败
+	BulkTagStatusPartial = "partial"
+)
+
+// BulkTagRequest 批量编辑标签请求
+// 选择资源的方式为ResourceIDs或Query（搜索查询语言）二选一，Set中的标签被添加或修改，Remove中的标签键被删除
+type BulkTagRequest struct {
+	ResourceIDs []string          `json:"resource_ids"`
+	Query       string            `json:"query"`
+	Set         map[string]string `json:"set"`
+	Remove      []string          `json:"remove"`
+	// DryRun 为true时只预览受影响的资源及修改后的标签，不写回平台
+	DryRun bool `json:"dry_run"`
+}
+
+// BulkTagItem 批量编辑标签中单个资源的结果
+type BulkTagItem struct {
+	ResourceID string            `json:"resource_id"`
+	Name       string            `json:"name"`
+	Provider   string            `json:"provider"`
+	OldTags    map[string]string `json:"old_tags"`
+	NewTags    map[string]string `json:"new_tags"`
+	Status     string            `json:"status"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// BulkTagResult 批量编辑标签结果，部分成功的资源同时计入Changed和Failed
+type BulkTagResult struct {
+	DryRun  bool           `json:"dry_run"`
+	Total   int            `json:"total"`
+	Changed int            `json:"changed"`
+	Failed  int            `json:"failed"`
+	Items   []*BulkTagItem `json:"items"`
+}
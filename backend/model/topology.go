@@ -0,0 +1,26 @@
+// model/topology.go
+package model
+
+// TopologyFilter 拓扑图的查询条件，指定Root时从该资源出发遍历，否则以满足范围条件的资源为起点
+type TopologyFilter struct {
+	// Root 起点资源ID，Depth 为从起点遍历的层数
+	Root  string
+	Depth int
+	// 范围条件，未指定Root时至少需要其中一项
+	Provider       string
+	SubscriptionID string
+	ResourceGroup  string
+	ResourceType   string
+	TagKey         string
+	TagValue       string
+	// Relationship 遍历时的关系方向和类型
+	Relationship RelationshipFilter
+	// GroupByResourceGroup 和 GroupByVNet 是否按资源组、虚拟网络分组，分组需要的归属关系会一并查出
+	GroupByResourceGroup bool
+	GroupByVNet          bool
+}
+
+// HasScope 是否指定了范围条件
+func (f TopologyFilter) HasScope() bool {
+	return f.Provider != "" || f.SubscriptionID != "" || f.ResourceGroup != "" || f.ResourceType != "" || f.TagKey != ""
+}
@@ -7,17 +7,21 @@ import (
 
 // VM 虚拟机模型
 type VM struct {
-	ID             int64             `json:"-"`
-	VMID           string            `json:"vm_id"`
-	ResourceID     string            `json:"resource_id"`
-	Name           string            `json:"name"`
-	Location       string            `json:"location"`
-	Type           string            `json:"type"`
-	Status         string            `json:"status"`
-	Owner          string            `json:"owner"`
-	SubscriptionID string            `json:"subscription_id"`
-	Tags           map[string]string `json:"tags"`
-	LastSyncAt     time.Time         `json:"last_sync_at"`
-	CreatedAt      time.Time         `json:"created_at"`
-	UpdatedAt      time.Time         `json:"updated_at"`
+	ID              int64             `json:"-"`
+	Provider        string            `json:"provider"`
+	VMID            string            `json:"vm_id"`
+	ResourceID      string            `json:"resource_id"`
+	Name            string            `json:"name"`
+	Location        string            `json:"location"`
+	Type            string            `json:"type"`
+	Status          string            `json:"status"`
+	Owner           string            `json:"owner"`
+	SubscriptionID  string            `json:"subscription_id"`
+	Tags            map[string]string `json:"tags"`
+	LastSyncAt      time.Time         `json:"last_sync_at"`
+	DeletedAt       *time.Time        `json:"deleted_at,omitempty"`
+	DeletedByTaskID *int64            `json:"deleted_by_task_id,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+	OwnerInfo       *OwnerInfo        `json:"owner_info,omitempty"` // 仅在查询响应中填充
 }
@@ -0,0 +1,161 @@
+// Package policy 加载并评估标签策略
+//
+// 策略文件为JSON数组，每个元素对应一条model.TagPolicy，例如：
+//
+//	[
+//	  {"name": "owner-required", "key": "owner", "required": true},
+//	  {"name": "env-values", "key": "env", "required": true, "allowed_values": ["prod", "test", "dev"]},
+//	  {"name": "cost-center", "key": "cost-center", "required": true, "pattern": "^CC-[0-9]{4}$",
+//	   "subscriptions": ["00000000-0000-0000-0000-000000000000"]}
+//	]
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"CMDB/model"
+)
+
+// LoadFile 从JSON文件加载标签策略
+func LoadFile(path string) ([]model.TagPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取标签策略文件失败: %v", err)
+	}
+	var policies []model.TagPolicy
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("解析标签策略文件 %s 失败: %v", path, err)
+	}
+	return policies, nil
+}
+
+// compiledPolicy 预编译正则并建立索引后的策略
+type compiledPolicy struct {
+	model.TagPolicy
+	pattern       *regexp.Regexp
+	allowed       map[string]bool
+	resourceTypes map[string]bool
+	subscriptions map[string]bool
+}
+
+// Evaluator 标签策略评估器
+type Evaluator struct {
+	policies []*compiledPolicy
+}
+
+// NewEvaluator 校验并编译策略，策略名为空时使用标签键
+func NewEvaluator(policies []model.TagPolicy) (*Evaluator, error) {
+	evaluator := &Evaluator{}
+	names := make(map[string]bool)
+	for i, p := range policies {
+		if strings.TrimSpace(p.Key) == "" {
+			return nil, fmt.Errorf("第%d条标签策略缺少key", i+1)
+		}
+		if p.Name == "" {
+			p.Name = p.Key
+		}
+		if names[p.Name] {
+			return nil, fmt.Errorf("标签策略 %s 重复", p.Name)
+		}
+		names[p.Name] = true
+
+		compiled := &compiledPolicy{
+			TagPolicy:     p,
+			allowed:       toSet(p.AllowedValues, false),
+			resourceTypes: toSet(p.ResourceTypes, true),
+			subscriptions: toSet(p.Subscriptions, true),
+		}
+		if p.Pattern != "" {
+			pattern, err := regexp.Compile(p.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("标签策略 %s 的pattern无效: %v", p.Name, err)
+			}
+			compiled.pattern = pattern
+		}
+		evaluator.policies = append(evaluator.policies, compiled)
+	}
+	return evaluator, nil
+}
+
+// Policies 返回评估器使用的策略
+func (e *Evaluator) Policies() []model.TagPolicy {
+	policies := make([]model.TagPolicy, 0, len(e.policies))
+	for _, p := range e.policies {
+		policies = append(policies, p.TagPolicy)
+	}
+	return policies
+}
+
+// Evaluate 评估资源违反的策略，没有违反任何策略时返回空列表
+func (e *Evaluator) Evaluate(resource *model.Resource) []*model.TagViolation {
+	var violations []*model.TagViolation
+	for _, p := range e.policies {
+		if !p.appliesTo(resource) {
+			continue
+		}
+
+		value, ok := lookupTag(resource.Tags, p.Key)
+		if !ok {
+			if p.Required {
+				violations = append(violations, &model.TagViolation{Policy: p.Name, TagKey: p.Key, Reason: model.ViolationMissing})
+			}
+			continue
+		}
+		if !p.valueAllowed(value) {
+			violations = append(violations, &model.TagViolation{Policy: p.Name, TagKey: p.Key, Reason: model.ViolationInvalidValue, Value: value})
+		}
+	}
+	return violations
+}
+
+// appliesTo 判断策略是否适用于资源
+func (p *compiledPolicy) appliesTo(resource *model.Resource) bool {
+	if len(p.subscriptions) > 0 && !p.subscriptions[strings.ToLower(resource.SubscriptionID)] {
+		return false
+	}
+	if len(p.resourceTypes) > 0 {
+		resourceType := strings.ToLower(resource.ResourceType)
+		lastSegment := resourceType[strings.LastIndex(resourceType, "/")+1:]
+		if !p.resourceTypes[resourceType] && !p.resourceTypes[lastSegment] {
+			return false
+		}
+	}
+	return true
+}
+
+// valueAllowed 判断标签取值是否满足允许的取值或正则，两者都未指定时总是满足
+func (p *compiledPolicy) valueAllowed(value string) bool {
+	if len(p.allowed) == 0 && p.pattern == nil {
+		return true
+	}
+	return p.allowed[value] || (p.pattern != nil && p.pattern.MatchString(value))
+}
+
+// lookupTag 查找标签，Azure标签键不区分大小写，精确匹配失败时忽略大小写查找
+func lookupTag(tags map[string]string, key string) (string, bool) {
+	if value, ok := tags[key]; ok {
+		return value, true
+	}
+	for k, value := range tags {
+		if strings.EqualFold(k, key) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// toSet 将列表转换为集合，lower为true时统一转为小写
+func toSet(values []string, lower bool) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		if lower {
+			value = strings.ToLower(value)
+		}
+		set[value] = true
+	}
+	return set
+}
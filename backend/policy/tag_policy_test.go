@@ -0,0 +1,252 @@
+package policy
+
+import (
+	"CMDB/model"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	testSubscription  = "11111111-1111-1111-1111-111111111111"
+	otherSubscription = "22222222-2222-2222-2222-222222222222"
+	vmType            = "Microsoft.Compute/virtualMachines"
+)
+
+func resource(resourceType, subscriptionID string, tags map[string]string) *model.Resource {
+	return &model.Resource{ResourceType: resourceType, SubscriptionID: subscriptionID, Tags: tags}
+}
+
+func missing(policy, key string) *model.TagViolation {
+	return &model.TagViolation{Policy: policy, TagKey: key, Reason: model.ViolationMissing}
+}
+
+func invalid(policy, key, value string) *model.TagViolation {
+	return &model.TagViolation{Policy: policy, TagKey: key, Reason: model.ViolationInvalidValue, Value: value}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies []model.TagPolicy
+		resource *model.Resource
+		want     []*model.TagViolation
+	}{
+		// 必需的标签
+		{
+			name:     "缺少必需标签",
+			policies: []model.TagPolicy{{Key: "owner", Required: true}},
+			resource: resource(vmType, testSubscription, nil),
+			want:     []*model.TagViolation{missing("owner", "owner")},
+		},
+		{
+			name:     "带有必需标签",
+			policies: []model.TagPolicy{{Key: "owner", Required: true}},
+			resource: resource(vmType, testSubscription, map[string]string{"owner": "alice"}),
+		},
+		{
+			name:     "非必需标签缺少时不违规",
+			policies: []model.TagPolicy{{Key: "env", AllowedValues: []string{"prod"}}},
+			resource: resource(vmType, testSubscription, map[string]string{}),
+		},
+		{
+			name:     "空值视为存在",
+			policies: []model.TagPolicy{{Key: "owner", Required: true}},
+			resource: resource(vmType, testSubscription, map[string]string{"owner": ""}),
+		},
+
+		// 标签键不区分大小写
+		{
+			name:     "忽略大小写查找标签键",
+			policies: []model.TagPolicy{{Key: "Owner", Required: true}},
+			resource: resource(vmType, testSubscription, map[string]string{"OWNER": "alice"}),
+		},
+		{
+			name:     "精确匹配的键优先",
+			policies: []model.TagPolicy{{Key: "env", AllowedValues: []string{"prod"}}},
+			resource: resource(vmType, testSubscription, map[string]string{"ENV": "staging", "env": "prod"}),
+		},
+		{
+			name:     "忽略大小写找到的值同样校验",
+			policies: []model.TagPolicy{{Name: "env-values", Key: "env", AllowedValues: []string{"prod"}}},
+			resource: resource(vmType, testSubscription, map[string]string{"Env": "staging"}),
+			want:     []*model.TagViolation{invalid("env-values", "env", "staging")},
+		},
+
+		// 允许的取值和正则
+		{
+			name:     "取值在允许的取值中",
+			policies: []model.TagPolicy{{Key: "env", AllowedValues: []string{"prod", "test"}}},
+			resource: resource(vmType, testSubscription, map[string]string{"env": "test"}),
+		},
+		{
+			name:     "允许的取值区分大小写",
+			policies: []model.TagPolicy{{Key: "env", AllowedValues: []string{"prod"}}},
+			resource: resource(vmType, testSubscription, map[string]string{"env": "Prod"}),
+			want:     []*model.TagViolation{invalid("env", "env", "Prod")},
+		},
+		{
+			name:     "匹配正则",
+			policies: []model.TagPolicy{{Key: "cost-center", Pattern: "^CC-[0-9]{4}$"}},
+			resource: resource(vmType, testSubscription, map[string]string{"cost-center": "CC-1234"}),
+		},
+		{
+			name:     "不匹配正则",
+			policies: []model.TagPolicy{{Key: "cost-center", Pattern: "^CC-[0-9]{4}$"}},
+			resource: resource(vmType, testSubscription, map[string]string{"cost-center": "CC-12"}),
+			want:     []*model.TagViolation{invalid("cost-center", "cost-center", "CC-12")},
+		},
+		{
+			name:     "同时指定时满足允许的取值即可",
+			policies: []model.TagPolicy{{Key: "cost-center", AllowedValues: []string{"shared"}, Pattern: "^CC-[0-9]{4}$"}},
+			resource: resource(vmType, testSubscription, map[string]string{"cost-center": "shared"}),
+		},
+		{
+			name:     "同时指定时满足正则即可",
+			policies: []model.TagPolicy{{Key: "cost-center", AllowedValues: []string{"shared"}, Pattern: "^CC-[0-9]{4}$"}},
+			resource: resource(vmType, testSubscription, map[string]string{"cost-center": "CC-0001"}),
+		},
+		{
+			name:     "同时指定时都不满足",
+			policies: []model.TagPolicy{{Key: "cost-center", AllowedValues: []string{"shared"}, Pattern: "^CC-[0-9]{4}$"}},
+			resource: resource(vmType, testSubscription, map[string]string{"cost-center": "none"}),
+			want:     []*model.TagViolation{invalid("cost-center", "cost-center", "none")},
+		},
+		{
+			name:     "未限制取值时任意值都满足",
+			policies: []model.TagPolicy{{Key: "owner", Required: true}},
+			resource: resource(vmType, testSubscription, map[string]string{"owner": "anything"}),
+		},
+
+		// 按资源类型和订阅限定范围
+		{
+			name:     "按完整资源类型限定",
+			policies: []model.TagPolicy{{Key: "owner", Required: true, ResourceTypes: []string{"microsoft.compute/virtualmachines"}}},
+			resource: resource(vmType, testSubscription, nil),
+			want:     []*model.TagViolation{missing("owner", "owner")},
+		},
+		{
+			name:     "按资源类型最后一段限定",
+			policies: []model.TagPolicy{{Key: "owner", Required: true, ResourceTypes: []string{"VirtualMachines"}}},
+			resource: resource(vmType, testSubscription, nil),
+			want:     []*model.TagViolation{missing("owner", "owner")},
+		},
+		{
+			name:     "其他资源类型不适用",
+			policies: []model.TagPolicy{{Key: "owner", Required: true, ResourceTypes: []string{"virtualMachines"}}},
+			resource: resource("Microsoft.Storage/storageAccounts", testSubscription, nil),
+		},
+		{
+			name:     "按订阅限定且忽略大小写",
+			policies: []model.TagPolicy{{Key: "owner", Required: true, Subscriptions: []string{strings.ToUpper(testSubscription)}}},
+			resource: resource(vmType, testSubscription, nil),
+			want:     []*model.TagViolation{missing("owner", "owner")},
+		},
+		{
+			name:     "其他订阅不适用",
+			policies: []model.TagPolicy{{Key: "owner", Required: true, Subscriptions: []string{testSubscription}}},
+			resource: resource(vmType, otherSubscription, nil),
+		},
+		{
+			name:     "资源类型和订阅须同时满足",
+			policies: []model.TagPolicy{{Key: "owner", Required: true, ResourceTypes: []string{"virtualMachines"}, Subscriptions: []string{otherSubscription}}},
+			resource: resource(vmType, testSubscription, nil),
+		},
+
+		// 多条策略按定义顺序给出违规
+		{
+			name: "多条策略",
+			policies: []model.TagPolicy{
+				{Name: "owner-required", Key: "owner", Required: true},
+				{Name: "env-values", Key: "env", Required: true, AllowedValues: []string{"prod", "test"}},
+				{Name: "storage-only", Key: "tier", Required: true, ResourceTypes: []string{"storageAccounts"}},
+			},
+			resource: resource(vmType, testSubscription, map[string]string{"env": "dev"}),
+			want:     []*model.TagViolation{missing("owner-required", "owner"), invalid("env-values", "env", "dev")},
+		},
+	}
+
+	for _, tt := range tests {
+		evaluator, err := NewEvaluator(tt.policies)
+		if err != nil {
+			t.Fatalf("%s: 创建评估器失败: %v", tt.name, err)
+		}
+		got := evaluator.Evaluate(tt.resource)
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: 违规为 %s，期望 %s", tt.name, formatViolations(got), formatViolations(tt.want))
+		}
+	}
+}
+
+func formatViolations(violations []*model.TagViolation) string {
+	parts := make([]string, 0, len(violations))
+	for _, v := range violations {
+		parts = append(parts, v.Policy+"/"+v.TagKey+"/"+v.Reason+"/"+v.Value)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func TestNewEvaluatorErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies []model.TagPolicy
+		msg      string
+	}{
+		{"缺少key", []model.TagPolicy{{Name: "p", Key: " "}}, "缺少key"},
+		{"策略名重复", []model.TagPolicy{{Name: "p", Key: "a"}, {Name: "p", Key: "b"}}, "重复"},
+		{"默认名与其他策略重复", []model.TagPolicy{{Key: "owner"}, {Name: "owner", Key: "team"}}, "重复"},
+		{"正则无效", []model.TagPolicy{{Key: "env", Pattern: "("}}, "pattern无效"},
+	}
+
+	for _, tt := range tests {
+		_, err := NewEvaluator(tt.policies)
+		if err == nil || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("%s: 错误为 %v，期望包含 %q", tt.name, err, tt.msg)
+		}
+	}
+}
+
+func TestPoliciesDefaultName(t *testing.T) {
+	evaluator, err := NewEvaluator([]model.TagPolicy{{Key: "owner"}, {Name: "env-values", Key: "env"}})
+	if err != nil {
+		t.Fatalf("创建评估器失败: %v", err)
+	}
+	var names []string
+	for _, p := range evaluator.Policies() {
+		names = append(names, p.Name)
+	}
+	if got := strings.Join(names, ","); got != "owner,env-values" {
+		t.Errorf("策略名为 %s", got)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.json")
+	data := `[{"name": "env-values", "key": "env", "required": true, "allowed_values": ["prod"], "resource_types": ["virtualMachines"]}]`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	policies, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("加载策略失败: %v", err)
+	}
+	want := []model.TagPolicy{{Name: "env-values", Key: "env", Required: true, AllowedValues: []string{"prod"}, ResourceTypes: []string{"virtualMachines"}}}
+	if !reflect.DeepEqual(policies, want) {
+		t.Errorf("策略为 %+v，期望 %+v", policies, want)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path); err == nil {
+		t.Error("无效的JSON应返回错误")
+	}
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("文件不存在时应返回错误")
+	}
+}
@@ -0,0 +1,158 @@
+package provider
+
+import (
+	"CMDB/config"
+	"CMDB/model"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+)
+
+// Provider 云平台资源发现接口，每个实例对应平台上的一个账号
+type Provider interface {
+	// Name 平台名称，如 azure、aws
+	Name() string
+	// Account 账号名称，用于区分同一平台下的多个账号
+	Account() string
+	// Scopes 列出需要同步的范围，如Azure订阅、AWS账号
+	Scopes() ([]Scope, error)
+	// DiscoverResources 发现指定范围内的通用资源
+	DiscoverResources(scope Scope) ([]*model.Resource, error)
+	// DiscoverVMs 发现指定范围内的虚拟机
+	DiscoverVMs(scope Scope) ([]*model.VM, error)
+	// DiscoverDatabases 发现指定范围内的数据库
+	DiscoverDatabases(scope Scope) ([]*model.Database, error)
+}
+
+// Diagnosable 可选接口，Provider实现后可通过诊断接口报告所用身份等信息
+type Diagnosable interface {
+	Diagnostics() interface{}
+}
+
+// Scope 同步范围，ID会写入资源的SubscriptionID字段
+type Scope struct {
+	ID       string `json:"id"`
+	Name     string `json:"name,omitempty"`
+	TenantID string `json:"tenant_id,omitempty"`
+	// Err 列举该范围时的错误，如某个租户的订阅列表获取失败；不为空时不同步该范围，直接记为失败
+	Err error `json:"-"`
+}
+
+// Factory 根据配置创建某个平台的全部Provider，未配置该平台时返回空列表
+type Factory func(cfg *config.Config) ([]Provider, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// RegisterFactory 注册平台的Provider工厂，通常在平台包的init中调用
+func RegisterFactory(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if _, exists := factories[name]; exists {
+		panic(fmt.Sprintf("provider %s 重复注册", name))
+	}
+	factories[name] = factory
+}
+
+// Registry 已启用的Provider集合
+type Registry struct {
+	mu        sync.RWMutex
+	providers []Provider
+}
+
+// NewRegistry 创建空的Provider集合
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// BuildRegistry 使用所有已注册的工厂按配置创建Provider
+func BuildRegistry(cfg *config.Config) (*Registry, error) {
+	factoriesMu.RLock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	factoriesMu.RUnlock()
+	sort.Strings(names)
+
+	registry := NewRegistry()
+	for _, name := range names {
+		factoriesMu.RLock()
+		factory := factories[name]
+		factoriesMu.RUnlock()
+
+		providers, err := factory(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("创建 %s Provider失败: %v", name, err)
+		}
+		for _, p := range providers {
+			log.Printf("已启用Provider: %s/%s", p.Name(), p.Account())
+			registry.Register(p)
+		}
+	}
+
+	return registry, nil
+}
+
+// Register 添加Provider
+func (r *Registry) Register(p Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers = append(r.providers, p)
+}
+
+// Providers 获取全部Provider
+func (r *Registry) Providers() []Provider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	providers := make([]Provider, len(r.providers))
+	copy(providers, r.providers)
+	return providers
+}
+
+// Get 根据平台名称和账号获取Provider
+func (r *Registry) Get(name, account string) (Provider, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, p := range r.providers {
+		if p.Name() == name && p.Account() == account {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// TagWriter 可选接口，Provider实现后可将标签变更写回平台
+type TagWriter interface {
+	// UpdateTags 在资源上添加或修改set中的标签并删除remove中的标签键，返回修改后资源的全部标签
+	// 平台上的标签已部分修改后出错时，同时返回当时资源的全部标签和错误；未做任何修改时返回的标签为nil
+	UpdateTags(scopeID, resourceID string, set map[string]string, remove []string) (map[string]string, error)
+}
+
+// OwnerResolver 可选接口，Provider实现后可在平台目录中解析资源所有者
+type OwnerResolver interface {
+	// ResolveOwner 按UPN、邮箱或对象ID查找所有者，目录中不存在时返回nil
+	// 取值无法作为目录标识查找时返回状态为model.OwnerStatusUnresolvable的结果
+	ResolveOwner(value string) (*model.OwnerInfo, error)
+}
+
+// OwnerInferrer 可选接口，Provider实现后可为未打owner标签的资源推断所有者
+// 两个方法都只返回能推断出所有者的资源，结果以资源ID为键，取值为可在目录中解析的UPN、邮箱或对象ID
+type OwnerInferrer interface {
+	// OwnersFromRoleAssignments 按资源及其资源组上的Owner/Contributor角色分配推断所有者
+	OwnersFromRoleAssignments(scope Scope, resourceIDs []string) (map[string]string, error)
+	// CreatorsFromActivityLog 按活动日志中创建资源的主体推断所有者，只能找到日志保留期内创建的资源
+	CreatorsFromActivityLog(scope Scope, resourceIDs []string) (map[string]string, error)
+}
+
+// RelationshipDiscoverer 可选接口，Provider实现后在同步时发现资源之间的关系
+type RelationshipDiscoverer interface {
+	// DiscoverRelationships 发现同步范围内资源之间的关系
+	DiscoverRelationships(scope Scope) ([]*model.Relationship, error)
+}
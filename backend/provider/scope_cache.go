@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"sync"
+)
+
+// ScopeCacher 可选接口，Provider实现后同步服务在每个范围的全部同步步骤前后通知Provider，
+// Provider可以在此期间缓存清单查询结果，避免发现资源、虚拟机、数据库时重复查询平台
+type ScopeCacher interface {
+	// BeginScope 范围开始同步
+	BeginScope(scope Scope)
+	// EndScope 范围同步结束，丢弃该范围的缓存
+	EndScope(scope Scope)
+}
+
+// ScopeCache ScopeCacher的通用实现，Provider嵌入后即可按范围缓存查询结果，零值可用
+// 只缓存正在同步的范围，范围之外的查询每次都重新加载
+type ScopeCache struct {
+	mu     sync.Mutex
+	scopes map[string]map[string]interface{}
+}
+
+// BeginScope 开始缓存范围内的查询结果
+func (c *ScopeCache) BeginScope(scope Scope) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.scopes == nil {
+		c.scopes = make(map[string]map[string]interface{})
+	}
+	c.scopes[scope.ID] = make(map[string]interface{})
+}
+
+// EndScope 丢弃范围内缓存的查询结果
+func (c *ScopeCache) EndScope(scope Scope) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.scopes, scope.ID)
+}
+
+// Load 返回范围内key对应的缓存结果，未缓存时调用load加载，加载失败时不缓存
+func (c *ScopeCache) Load(scopeID, key string, load func() (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	cached, active := c.scopes[scopeID]
+	if active {
+		if value, ok := cached[key]; ok {
+			c.mu.Unlock()
+			return value, nil
+		}
+	}
+	c.mu.Unlock()
+
+	value, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		c.mu.Lock()
+		if cached, ok := c.scopes[scopeID]; ok {
+			cached[key] = value
+		}
+		c.mu.Unlock()
+	}
+	return value, nil
+}
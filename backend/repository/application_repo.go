@@ -0,0 +1,71 @@
+// repository/application_repo.go
+package repository
+
+import (
+	"CMDB/dao"
+	"CMDB/model"
+)
+
+// ApplicationRepository 应用和业务服务仓库
+type ApplicationRepository struct {
+	applicationDAO *dao.ApplicationDAO
+}
+
+// NewApplicationRepository 创建应用和业务服务仓库
+func NewApplicationRepository(applicationDAO *dao.ApplicationDAO) *ApplicationRepository {
+	return &ApplicationRepository{applicationDAO: applicationDAO}
+}
+
+// CreateApplication 创建应用
+func (repo *ApplicationRepository) CreateApplication(application *model.Application) error {
+	return repo.applicationDAO.CreateApplication(application)
+}
+
+// UpdateApplication 更新应用，tagRules、members不为nil时整体替换
+func (repo *ApplicationRepository) UpdateApplication(application *model.Application, tagRules []model.ApplicationTagRule, members []string) error {
+	return repo.applicationDAO.UpdateApplication(application, tagRules, members)
+}
+
+// DeleteApplication 删除应用，应用不存在时返回false
+func (repo *ApplicationRepository) DeleteApplication(id int64) (bool, error) {
+	return repo.applicationDAO.DeleteApplication(id)
+}
+
+// GetApplication 根据ID获取应用，不存在时返回nil
+func (repo *ApplicationRepository) GetApplication(id int64) (*model.Application, error) {
+	return repo.applicationDAO.GetApplication(id)
+}
+
+// ListApplications 按条件列出应用
+func (repo *ApplicationRepository) ListApplications(filter model.ApplicationFilter) ([]*model.Application, error) {
+	return repo.applicationDAO.ListApplications(filter)
+}
+
+// AddMembers 添加应用的手动成员
+func (repo *ApplicationRepository) AddMembers(applicationID int64, resourceIDs []string) error {
+	if len(resourceIDs) == 0 {
+		return nil
+	}
+	return repo.applicationDAO.AddMembers(applicationID, resourceIDs)
+}
+
+// RemoveMembers 移除应用的手动成员
+func (repo *ApplicationRepository) RemoveMembers(applicationID int64, resourceIDs []string) error {
+	if len(resourceIDs) == 0 {
+		return nil
+	}
+	return repo.applicationDAO.RemoveMembers(applicationID, resourceIDs)
+}
+
+// ApplicationNameExists 名称是否已被其他应用使用
+func (repo *ApplicationRepository) ApplicationNameExists(name string, excludeID int64) (bool, error) {
+	return repo.applicationDAO.ApplicationNameExists(name, excludeID)
+}
+
+// MissingResourceIDs 返回给定ID中不在资源清单中的ID
+func (repo *ApplicationRepository) MissingResourceIDs(resourceIDs []string) ([]string, error) {
+	if len(resourceIDs) == 0 {
+		return nil, nil
+	}
+	return repo.applicationDAO.MissingResourceIDs(resourceIDs)
+}
@@ -0,0 +1,176 @@
+// repository/change_history_repo.go
+package repository
+
+import (
+	"CMDB/dao"
+	"CMDB/model"
+	"sort"
+	"time"
+)
+
+// ChangeHistoryRepository 变更历史仓库
+type ChangeHistoryRepository struct {
+	historyDAO *dao.ChangeHistoryDAO
+}
+
+// NewChangeHistoryRepository 创建变更历史仓库
+func NewChangeHistoryRepository(historyDAO *dao.ChangeHistoryDAO) *ChangeHistoryRepository {
+	return &ChangeHistoryRepository{historyDAO: historyDAO}
+}
+
+// ListByResourceID 获取资源的变更历史，包含同一资源ID下虚拟机和数据库记录的变更
+func (repo *ChangeHistoryRepository) ListByResourceID(resourceID string, limit int) ([]*model.ChangeRecord, error) {
+	return repo.historyDAO.ListChangesByResourceID(resourceID, limit)
+}
+
+// changeItem 待比较的配置项，fields为字段名到取值的映射，标签以 tags.<键> 作为字段名
+type changeItem struct {
+	itemType   string
+	itemID     string
+	resourceID string
+	fields     map[string]string
+}
+
+// withTags 将标签合并到字段映射中
+func withTags(fields map[string]string, tags map[string]string) map[string]string {
+	for key, value := range tags {
+		fields[model.TagFieldPrefix+key] = value
+	}
+	return fields
+}
+
+// diffChanges 比较已存储的配置项与同步得到的配置项，生成变更时间为now的变更记录
+// stored为nil表示新建；deleted表示已存储的记录处于已删除状态，本次同步将其恢复
+func diffChanges(stored map[string]string, deleted bool, incoming changeItem, taskID int64, now time.Time) []*model.ChangeRecord {
+	newRecord := func(changeType, field string, oldValue, newValue *string) *model.ChangeRecord {
+		record := &model.ChangeRecord{
+			ItemType:   incoming.itemType,
+			ItemID:     incoming.itemID,
+			ResourceID: incoming.resourceID,
+			ChangeType: changeType,
+			Field:      field,
+			OldValue:   oldValue,
+			NewValue:   newValue,
+			ChangedAt:  now,
+		}
+		if taskID != 0 {
+			record.SyncTaskID = &taskID
+		}
+		return record
+	}
+
+	if stored == nil {
+		return []*model.ChangeRecord{newRecord(model.ChangeTypeCreated, "", nil, nil)}
+	}
+
+	var changes []*model.ChangeRecord
+	if deleted {
+		changes = append(changes, newRecord(model.ChangeTypeRestored, "", nil, nil))
+	}
+
+	fields := make([]string, 0, len(stored)+len(incoming.fields))
+	for field := range stored {
+		fields = append(fields, field)
+	}
+	for field := range incoming.fields {
+		if _, ok := stored[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		oldValue, hadOld := stored[field]
+		newValue, hasNew := incoming.fields[field]
+		if hadOld == hasNew && oldValue == newValue {
+			continue
+		}
+
+		var oldPtr, newPtr *string
+		if hadOld {
+			oldPtr = &oldValue
+		}
+		if hasNew {
+			newPtr = &newValue
+		}
+		changes = append(changes, newRecord(model.ChangeTypeUpdated, field, oldPtr, newPtr))
+	}
+	return changes
+}
+
+// resourceFields 资源参与变更比较的字段
+func resourceFields(resource *model.Resource) map[string]string {
+	fields := withTags(map[string]string{
+		"name":            resource.Name,
+		"location":        resource.Location,
+		"resource_type":   resource.ResourceType,
+		"owner":           resource.Owner,
+		"owner_source":    resource.OwnerSource,
+		"source":          resource.Source,
+		"status":          resource.Status,
+		"subscription_id": resource.SubscriptionID,
+	}, resource.Tags)
+	for key, value := range resource.Attributes {
+		fields[model.AttributeFieldPrefix+key] = value
+	}
+	return fields
+}
+
+// vmFields 虚拟机参与变更比较的字段
+func vmFields(vm *model.VM) map[string]string {
+	return withTags(map[string]string{
+		"name":            vm.Name,
+		"location":        vm.Location,
+		"type":            vm.Type,
+		"status":          vm.Status,
+		"owner":           vm.Owner,
+		"subscription_id": vm.SubscriptionID,
+	}, vm.Tags)
+}
+
+// databaseFields 数据库参与变更比较的字段
+func databaseFields(database *model.Database) map[string]string {
+	return withTags(map[string]string{
+		"name":            database.Name,
+		"location":        database.Location,
+		"server":          database.Server,
+		"db_type":         database.DBType,
+		"version":         database.Version,
+		"status":          database.Status,
+		"owner":           database.Owner,
+		"subscription_id": database.SubscriptionID,
+	}, database.Tags)
+}
+
+// resourceSnapshot 资源保存后的版本快照
+func resourceSnapshot(resource, stored *model.Resource, now time.Time) *model.Resource {
+	snapshot := *resource
+	snapshot.LastSyncAt, snapshot.UpdatedAt, snapshot.CreatedAt = now, now, now
+	snapshot.DeletedAt, snapshot.DeletedByTaskID, snapshot.OwnerInfo = nil, nil, nil
+	if stored != nil {
+		snapshot.CreatedAt = stored.CreatedAt
+	}
+	return &snapshot
+}
+
+// vmSnapshot 虚拟机保存后的版本快照
+func vmSnapshot(vm, stored *model.VM, now time.Time) *model.VM {
+	snapshot := *vm
+	snapshot.LastSyncAt, snapshot.UpdatedAt, snapshot.CreatedAt = now, now, now
+	snapshot.DeletedAt, snapshot.DeletedByTaskID, snapshot.OwnerInfo = nil, nil, nil
+	if stored != nil {
+		snapshot.CreatedAt = stored.CreatedAt
+	}
+	return &snapshot
+}
+
+// databaseSnapshot 数据库保存后的版本快照
+func databaseSnapshot(database, stored *model.Database, now time.Time) *model.Database {
+	snapshot := *database
+	snapshot.LastSyncAt, snapshot.UpdatedAt, snapshot.CreatedAt = now, now, now
+	snapshot.DeletedAt, snapshot.DeletedByTaskID, snapshot.OwnerInfo = nil, nil, nil
+	if stored != nil {
+		snapshot.CreatedAt = stored.CreatedAt
+	}
+	return &snapshot
+}
@@ -0,0 +1,53 @@
+// repository/compliance_repo.go
+package repository
+
+import (
+	"CMDB/dao"
+	"CMDB/model"
+	"time"
+)
+
+// ComplianceRepository 标签合规仓库
+type ComplianceRepository struct {
+	complianceDAO *dao.ComplianceDAO
+}
+
+// NewComplianceRepository 创建标签合规仓库
+func NewComplianceRepository(complianceDAO *dao.ComplianceDAO) *ComplianceRepository {
+	return &ComplianceRepository{complianceDAO: complianceDAO}
+}
+
+// SaveEvaluation 保存一次评估的结果和合规快照
+func (repo *ComplianceRepository) SaveEvaluation(results []*model.ResourceCompliance, snapshot *model.ComplianceSnapshot) error {
+	return repo.complianceDAO.SaveEvaluation(results, snapshot)
+}
+
+// Summarize 汇总满足条件的资源的合规情况
+func (repo *ComplianceRepository) Summarize(filter model.ComplianceFilter) (*model.ComplianceSnapshot, error) {
+	return repo.complianceDAO.Summarize(filter)
+}
+
+// ListNonCompliant 分页查询不合规的资源
+func (repo *ComplianceRepository) ListNonCompliant(filter model.ComplianceFilter) ([]*model.ResourceCompliance, int, error) {
+	return repo.complianceDAO.ListNonCompliant(filter)
+}
+
+// SummarizeByOwner 按所有者汇总合规情况
+func (repo *ComplianceRepository) SummarizeByOwner(filter model.ComplianceFilter) ([]*model.ComplianceGroup, error) {
+	return repo.complianceDAO.SummarizeBy("owner", filter)
+}
+
+// SummarizeByOwnerSource 按所有者来源汇总合规情况
+func (repo *ComplianceRepository) SummarizeByOwnerSource(filter model.ComplianceFilter) ([]*model.ComplianceGroup, error) {
+	return repo.complianceDAO.SummarizeBy("owner_source", filter)
+}
+
+// SummarizeBySubscription 按订阅汇总合规情况
+func (repo *ComplianceRepository) SummarizeBySubscription(filter model.ComplianceFilter) ([]*model.ComplianceGroup, error) {
+	return repo.complianceDAO.SummarizeBy("subscription_id", filter)
+}
+
+// ListSnapshots 获取since之后的合规快照
+func (repo *ComplianceRepository) ListSnapshots(since time.Time) ([]*model.ComplianceSnapshot, error) {
+	return repo.complianceDAO.ListSnapshots(since)
+}
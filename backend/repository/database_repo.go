@@ -3,16 +3,21 @@ package repository
 import (
 	"CMDB/dao"
 	"CMDB/model"
+	"encoding/json"
+	"time"
 )
 
 // DatabaseRepository 数据库资源仓库
 type DatabaseRepository struct {
 	databaseDAO *dao.DatabaseDAO
+	historyDAO  *dao.ChangeHistoryDAO
+	versionDAO  *dao.ItemVersionDAO
+	searchDAO   *dao.SearchDAO
 }
 
 // NewDatabaseRepository 创建数据库资源仓库
-func NewDatabaseRepository(databaseDAO *dao.DatabaseDAO) *DatabaseRepository {
-	return &DatabaseRepository{databaseDAO: databaseDAO}
+func NewDatabaseRepository(databaseDAO *dao.DatabaseDAO, historyDAO *dao.ChangeHistoryDAO, versionDAO *dao.ItemVersionDAO, searchDAO *dao.SearchDAO) *DatabaseRepository {
+	return &DatabaseRepository{databaseDAO: databaseDAO, historyDAO: historyDAO, versionDAO: versionDAO, searchDAO: searchDAO}
 }
 
 // SaveDatabaseResource 保存数据库资源
@@ -35,31 +40,131 @@ func (repo *DatabaseRepository) GetDatabaseByResourceID(resourceID string) (*mod
 	return repo.databaseDAO.GetDatabaseByID(resourceID)
 }
 
-// GetDatabasesByType 根据数据库类型获取数据库资源
-func (repo *DatabaseRepository) GetDatabasesByType(dbType string) ([]*model.Database, error) {
-	// 获取所有数据库资源
-	allDatabases, err := repo.databaseDAO.ListDatabases()
+// GetDatabasesByType 根据数据库类型获取数据库资源，includeDeleted为true时包含已删除的数据库
+func (repo *DatabaseRepository) GetDatabasesByType(dbType string, includeDeleted bool) ([]*model.Database, error) {
+	return repo.databaseDAO.ListDatabasesByType(dbType, includeDeleted)
+}
+
+// GetAllDatabases 获取所有数据库资源，includeDeleted为true时包含已删除的数据库
+func (repo *DatabaseRepository) GetAllDatabases(includeDeleted bool) ([]*model.Database, error) {
+	return repo.databaseDAO.ListDatabases(includeDeleted)
+}
+
+// SaveDatabase 保存数据库资源及其标签，与已存储的记录比较后追加变更历史
+// taskID为本次同步任务，为0时变更记录不关联同步任务
+func (repo *DatabaseRepository) SaveDatabase(database *model.Database, taskID int64) error {
+	now := time.Now()
+	stored, err := repo.databaseDAO.GetDatabaseByID(database.DatabaseID)
+	if err != nil {
+		return err
+	}
+	var storedFields map[string]string
+	if stored != nil {
+		storedFields = databaseFields(stored)
+	}
+	changes := diffChanges(storedFields, stored != nil && stored.DeletedAt != nil, changeItem{
+		itemType:   model.ItemTypeDatabase,
+		itemID:     database.DatabaseID,
+		resourceID: database.ResourceID,
+		fields:     databaseFields(database),
+	}, taskID, now)
+
+	// 开始事务
+	tx, err := repo.databaseDAO.BeginTx()
+	if err != nil {
+		return err
+	}
+
+	// 保存数据库基本信息
+	err = repo.databaseDAO.UpsertDatabaseTx(tx, database)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 保存数据库标签
+	err = repo.databaseDAO.UpsertDatabaseTagsTx(tx, database.DatabaseID, database.Tags)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 追加变更历史
+	err = repo.historyDAO.InsertChangesTx(tx, changes)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 保存版本快照，字段有变化时开始新版本
+	err = repo.versionDAO.SaveVersionTx(tx, model.ItemTypeDatabase, database.DatabaseID, database.ResourceID, database.Name,
+		databaseSnapshot(database, stored, now), len(changes) > 0, now)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 更新全文检索文档
+	err = repo.searchDAO.UpsertSearchDocumentTx(tx, model.ItemTypeDatabase, database.DatabaseID, database.ResourceID, database.Provider, database.Name, database.Owner, database.Tags)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 提交事务
+	return tx.Commit()
+}
+
+// BatchSaveDatabases 批量保存数据库资源，taskID为本次同步任务
+func (repo *DatabaseRepository) BatchSaveDatabases(databases []*model.Database, taskID int64) error {
+	for _, database := range databases {
+		if err := repo.SaveDatabase(database, taskID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListDatabasesAsOf 获取指定时刻的数据库清单，包含之后已删除的数据库
+func (repo *DatabaseRepository) ListDatabasesAsOf(asOf time.Time) ([]*model.Database, error) {
+	snapshots, err := repo.versionDAO.ListSnapshotsAsOf(model.ItemTypeDatabase, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	databases := make([]*model.Database, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		var database model.Database
+		if err := json.Unmarshal(snapshot, &database); err != nil {
+			return nil, err
+		}
+		databases = append(databases, &database)
+	}
+	return databases, nil
+}
+
+// GetDatabasesByTypeAsOf 获取指定时刻指定类型的数据库清单
+func (repo *DatabaseRepository) GetDatabasesByTypeAsOf(dbType string, asOf time.Time) ([]*model.Database, error) {
+	allDatabases, err := repo.ListDatabasesAsOf(asOf)
 	if err != nil {
 		return nil, err
 	}
 
-	// 筛选指定类型的数据库资源
 	var result []*model.Database
 	for _, db := range allDatabases {
 		if db.DBType == dbType {
 			result = append(result, db)
 		}
 	}
-
 	return result, nil
 }
 
-// GetAllDatabases 获取所有数据库资源
-func (repo *DatabaseRepository) GetAllDatabases() ([]*model.Database, error) {
-	return repo.databaseDAO.ListDatabases()
+// MarkDeleted 将范围内syncedBefore之后未再同步到的数据库标记为已删除
+func (repo *DatabaseRepository) MarkDeleted(provider, subscriptionID string, syncedBefore time.Time, taskID int64) (int64, error) {
+	return repo.databaseDAO.MarkDatabasesDeleted(provider, subscriptionID, syncedBefore, taskID)
 }
 
-// BatchSaveDatabases 批量保存数据库资源
-func (repo *DatabaseRepository) BatchSaveDatabases(databases []*model.Database) error {
-	return repo.BatchSaveDatabaseResources(databases)
+// PurgeDeleted 永久删除deletedBefore之前标记删除的数据库
+func (repo *DatabaseRepository) PurgeDeleted(deletedBefore time.Time) (int64, error) {
+	return repo.databaseDAO.PurgeDeletedDatabases(deletedBefore)
 }
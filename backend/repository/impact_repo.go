@@ -0,0 +1,35 @@
+// repository/impact_repo.go
+package repository
+
+import (
+	"CMDB/dao"
+	"CMDB/model"
+)
+
+// ImpactRepository 影响分析仓库
+type ImpactRepository struct {
+	impactDAO *dao.ImpactDAO
+}
+
+// NewImpactRepository 创建影响分析仓库
+func NewImpactRepository(impactDAO *dao.ImpactDAO) *ImpactRepository {
+	return &ImpactRepository{impactDAO: impactDAO}
+}
+
+// ListChildResourceIDs 列出资源ID位于parentID之下的资源，最多返回limit个
+func (repo *ImpactRepository) ListChildResourceIDs(parentID string, limit int) ([]string, error) {
+	return repo.impactDAO.ListChildResourceIDs(parentID, limit)
+}
+
+// ListDatabaseIDsByServer 列出托管在指定数据库服务器上的数据库
+func (repo *ImpactRepository) ListDatabaseIDsByServer(server, subscriptionID string) ([]string, error) {
+	return repo.impactDAO.ListDatabaseIDsByServer(server, subscriptionID)
+}
+
+// GetImpactedItems 获取资源清单中的资源作为受影响的CI，以小写的资源ID为键
+func (repo *ImpactRepository) GetImpactedItems(resourceIDs []string) (map[string]*model.ImpactedItem, error) {
+	if len(resourceIDs) == 0 {
+		return map[string]*model.ImpactedItem{}, nil
+	}
+	return repo.impactDAO.GetImpactedItems(resourceIDs)
+}
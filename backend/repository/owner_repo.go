@@ -0,0 +1,37 @@
+// repository/owner_repo.go
+package repository
+
+import (
+	"CMDB/dao"
+	"CMDB/model"
+)
+
+// OwnerRepository 所有者目录缓存仓库
+type OwnerRepository struct {
+	ownerDAO *dao.OwnerDAO
+}
+
+// NewOwnerRepository 创建所有者目录缓存仓库
+func NewOwnerRepository(ownerDAO *dao.OwnerDAO) *OwnerRepository {
+	return &OwnerRepository{ownerDAO: ownerDAO}
+}
+
+// SaveOwner 保存所有者的解析结果
+func (repo *OwnerRepository) SaveOwner(owner *model.OwnerInfo) error {
+	return repo.ownerDAO.UpsertOwner(owner)
+}
+
+// GetOwners 批量获取所有者的缓存结果，以小写的所有者取值为键
+func (repo *OwnerRepository) GetOwners(values []string) (map[string]*model.OwnerInfo, error) {
+	return repo.ownerDAO.GetOwners(values)
+}
+
+// ListDistinctOwners 列出资源上出现的全部所有者取值
+func (repo *OwnerRepository) ListDistinctOwners() ([]string, error) {
+	return repo.ownerDAO.ListDistinctOwners()
+}
+
+// ListOwners 列出已解析的所有者，status为空时列出全部
+func (repo *OwnerRepository) ListOwners(status string) ([]*model.OwnerInfo, error) {
+	return repo.ownerDAO.ListOwners(status)
+}
@@ -0,0 +1,47 @@
+// repository/relationship_repo.go
+package repository
+
+import (
+	"CMDB/dao"
+	"CMDB/model"
+	"time"
+)
+
+// RelationshipRepository 资源关系仓库
+type RelationshipRepository struct {
+	relationshipDAO *dao.RelationshipDAO
+}
+
+// NewRelationshipRepository 创建资源关系仓库
+func NewRelationshipRepository(relationshipDAO *dao.RelationshipDAO) *RelationshipRepository {
+	return &RelationshipRepository{relationshipDAO: relationshipDAO}
+}
+
+// SaveRelationships 保存本次同步发现的关系
+func (repo *RelationshipRepository) SaveRelationships(relationships []*model.Relationship) error {
+	if len(relationships) == 0 {
+		return nil
+	}
+	return repo.relationshipDAO.UpsertRelationships(relationships)
+}
+
+// DeleteStale 删除同步范围内本次同步未再发现的关系
+func (repo *RelationshipRepository) DeleteStale(provider, subscriptionID string, syncedBefore time.Time) (int64, error) {
+	return repo.relationshipDAO.DeleteStaleRelationships(provider, subscriptionID, syncedBefore)
+}
+
+// ListRelationships 查询与给定资源相连的关系
+func (repo *RelationshipRepository) ListRelationships(resourceIDs []string, filter model.RelationshipFilter) ([]*model.Relationship, error) {
+	if len(resourceIDs) == 0 {
+		return nil, nil
+	}
+	return repo.relationshipDAO.ListRelationships(resourceIDs, filter.Direction, filter.Types)
+}
+
+// GetNodes 获取资源清单中的资源作为关系图节点，以小写的资源ID为键
+func (repo *RelationshipRepository) GetNodes(resourceIDs []string) (map[string]*model.GraphNode, error) {
+	if len(resourceIDs) == 0 {
+		return map[string]*model.GraphNode{}, nil
+	}
+	return repo.relationshipDAO.GetNodes(resourceIDs)
+}
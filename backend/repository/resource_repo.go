@@ -4,21 +4,51 @@ package repository
 import (
 	"CMDB/dao"
 	"CMDB/model"
+	"encoding/json"
 	"log"
+	"time"
 )
 
 // ResourceRepository 资源仓库
 type ResourceRepository struct {
 	resourceDAO *dao.ResourceDAO
+	historyDAO  *dao.ChangeHistoryDAO
+	versionDAO  *dao.ItemVersionDAO
+	searchDAO   *dao.SearchDAO
 }
 
 // NewResourceRepository 创建资源仓库
-func NewResourceRepository(resourceDAO *dao.ResourceDAO) *ResourceRepository {
-	return &ResourceRepository{resourceDAO: resourceDAO}
+func NewResourceRepository(resourceDAO *dao.ResourceDAO, historyDAO *dao.ChangeHistoryDAO, versionDAO *dao.ItemVersionDAO, searchDAO *dao.SearchDAO) *ResourceRepository {
+	return &ResourceRepository{resourceDAO: resourceDAO, historyDAO: historyDAO, versionDAO: versionDAO, searchDAO: searchDAO}
 }
 
-// SaveResource 保存资源及其标签
-func (repo *ResourceRepository) SaveResource(resource *model.Resource) error {
+// SaveResource 保存资源及其标签，与已存储的记录比较后追加变更历史
+// taskID为本次同步任务，为0时变更记录不关联同步任务；来源为空时视为同步得到的资源
+func (repo *ResourceRepository) SaveResource(resource *model.Resource, taskID int64) error {
+	if resource.Source == "" {
+		resource.Source = model.ResourceSourceSync
+	}
+	now := time.Now()
+	stored, err := repo.resourceDAO.GetResourceByID(resource.ResourceID)
+	if err != nil {
+		return err
+	}
+	// 手动维护的CI只能通过手动CI接口修改，不被同步覆盖
+	if stored != nil && stored.Source == model.ResourceSourceManual && resource.Source != model.ResourceSourceManual {
+		log.Printf("资源 %s 为手动维护的CI，跳过同步写入", resource.ResourceID)
+		return nil
+	}
+	var storedFields map[string]string
+	if stored != nil {
+		storedFields = resourceFields(stored)
+	}
+	changes := diffChanges(storedFields, stored != nil && stored.DeletedAt != nil, changeItem{
+		itemType:   model.ItemTypeResource,
+		itemID:     resource.ResourceID,
+		resourceID: resource.ResourceID,
+		fields:     resourceFields(resource),
+	}, taskID, now)
+
 	// 开始事务
 	tx, err := repo.resourceDAO.BeginTx()
 	if err != nil {
@@ -39,17 +69,39 @@ func (repo *ResourceRepository) SaveResource(resource *model.Resource) error {
 		return err
 	}
 
+	// 追加变更历史
+	err = repo.historyDAO.InsertChangesTx(tx, changes)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 保存版本快照，字段有变化时开始新版本
+	err = repo.versionDAO.SaveVersionTx(tx, model.ItemTypeResource, resource.ResourceID, resource.ResourceID, resource.Name,
+		resourceSnapshot(resource, stored, now), len(changes) > 0, now)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 更新全文检索文档
+	err = repo.searchDAO.UpsertSearchDocumentTx(tx, model.ItemTypeResource, resource.ResourceID, resource.ResourceID, resource.Provider, resource.Name, resource.Owner, resource.Tags)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	// 提交事务
 	return tx.Commit()
 }
 
-// BatchSaveResources 批量保存资源
-func (repo *ResourceRepository) BatchSaveResources(resources []*model.Resource) error {
+// BatchSaveResources 批量保存资源，taskID为本次同步任务
+func (repo *ResourceRepository) BatchSaveResources(resources []*model.Resource, taskID int64) error {
 	var lastErr error
 	successCount := 0
 
 	for _, resource := range resources {
-		if err := repo.SaveResource(resource); err != nil {
+		if err := repo.SaveResource(resource, taskID); err != nil {
 			log.Printf("保存资源 %s 失败: %v", resource.ResourceID, err)
 			lastErr = err
 		} else {
@@ -67,12 +119,74 @@ func (repo *ResourceRepository) GetResourceByID(resourceID string) (*model.Resou
 	return repo.resourceDAO.GetResourceByID(resourceID)
 }
 
+// GetOwnerLookups 批量获取资源已保存的所有者和上次未找到创建者的时间，以资源ID为键
+func (repo *ResourceRepository) GetOwnerLookups(resourceIDs []string) (map[string]*model.OwnerLookup, error) {
+	return repo.resourceDAO.GetOwnerLookups(resourceIDs)
+}
+
+// MarkCreatorChecked 记录在活动日志中未找到这些资源的创建者
+func (repo *ResourceRepository) MarkCreatorChecked(resourceIDs []string, checkedAt time.Time) error {
+	return repo.resourceDAO.MarkCreatorChecked(resourceIDs, checkedAt)
+}
+
 // GetResourcesByType 根据类型获取资源列表
 func (repo *ResourceRepository) GetResourcesByType(resourceType string) ([]*model.Resource, error) {
 	return repo.resourceDAO.GetResourcesByType(resourceType)
 }
 
-// GetAllResources 获取所有资源
-func (repo *ResourceRepository) GetAllResources() ([]*model.Resource, error) {
-	return repo.resourceDAO.GetAllResources()
+// GetAllResources 获取所有资源，includeDeleted为true时包含已删除的资源
+func (repo *ResourceRepository) GetAllResources(includeDeleted bool) ([]*model.Resource, error) {
+	return repo.resourceDAO.GetAllResources(includeDeleted)
+}
+
+// ListResources 按条件分页查询资源
+func (repo *ResourceRepository) ListResources(filter model.ResourceFilter) (*model.ResourcePage, error) {
+	resources, total, err := repo.resourceDAO.ListResources(filter)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ResourcePage{Items: resources, Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
+}
+
+// ListResourcesAsOf 按条件分页查询指定时刻的资源清单，包含之后已删除的资源
+func (repo *ResourceRepository) ListResourcesAsOf(filter model.ResourceFilter, asOf time.Time) (*model.ResourcePage, error) {
+	snapshots, total, err := repo.versionDAO.ListResourceSnapshotsAsOf(filter, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]*model.Resource, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		var resource model.Resource
+		if err := json.Unmarshal(snapshot, &resource); err != nil {
+			return nil, err
+		}
+		resources = append(resources, &resource)
+	}
+	return &model.ResourcePage{Items: resources, Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
+}
+
+// ListResourceKeys 列出满足条件的资源上出现过的全部标签键和属性键
+func (repo *ResourceRepository) ListResourceKeys(filter model.ResourceFilter) ([]string, []string, error) {
+	return repo.resourceDAO.ListResourceKeys(filter)
+}
+
+// ListResourceKeysAsOf 列出指定时刻满足条件的资源上出现过的全部标签键和属性键
+func (repo *ResourceRepository) ListResourceKeysAsOf(filter model.ResourceFilter, asOf time.Time) ([]string, []string, error) {
+	return repo.versionDAO.ListResourceKeysAsOf(filter, asOf)
+}
+
+// MarkDeleted 将范围内syncedBefore之后未再同步到的资源标记为已删除
+func (repo *ResourceRepository) MarkDeleted(provider, subscriptionID string, syncedBefore time.Time, taskID int64) (int64, error) {
+	return repo.resourceDAO.MarkResourcesDeleted(provider, subscriptionID, syncedBefore, taskID)
+}
+
+// MarkResourceDeleted 将单个资源标记为已删除，资源不存在或已删除时返回false
+func (repo *ResourceRepository) MarkResourceDeleted(resourceID string) (bool, error) {
+	return repo.resourceDAO.MarkResourceDeleted(resourceID)
+}
+
+// PurgeDeleted 永久删除deletedBefore之前标记删除的资源
+func (repo *ResourceRepository) PurgeDeleted(deletedBefore time.Time) (int64, error) {
+	return repo.resourceDAO.PurgeDeletedResources(deletedBefore)
 }
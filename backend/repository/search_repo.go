@@ -0,0 +1,69 @@
+// repository/search_repo.go
+package repository
+
+import (
+	"CMDB/dao"
+	"CMDB/model"
+	"CMDB/search"
+)
+
+// SearchRepository 搜索仓库
+type SearchRepository struct {
+	searchDAO *dao.SearchDAO
+}
+
+// NewSearchRepository 创建搜索仓库
+func NewSearchRepository(searchDAO *dao.SearchDAO) *SearchRepository {
+	return &SearchRepository{searchDAO: searchDAO}
+}
+
+// Search 在资源、虚拟机和数据库上执行查询，includeDeleted为true时包含已删除的配置项
+func (repo *SearchRepository) Search(query *search.Query, includeDeleted bool, limit, offset int) (*model.SearchPage, error) {
+	results, total, err := repo.searchDAO.Search(query, includeDeleted, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &model.SearchPage{Items: results, Total: total, Limit: limit, Offset: offset}, nil
+}
+
+// TextSearch 全文检索资源、虚拟机和数据库，并为名称、ID、所有者和标签中匹配的部分生成高亮
+func (repo *SearchRepository) TextSearch(input string, includeDeleted bool, limit, offset int) (*model.TextSearchPage, error) {
+	terms := search.TextTerms(input)
+	page := &model.TextSearchPage{Items: []*model.TextSearchResult{}, Limit: limit, Offset: offset}
+	if len(terms) == 0 {
+		return page, nil
+	}
+
+	results, total, err := repo.searchDAO.TextSearch(search.BooleanQuery(terms), includeDeleted, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		result.Highlights = make(map[string]string)
+		highlight := func(field, text string) {
+			if highlighted, ok := search.Highlight(text, terms); ok {
+				result.Highlights[field] = highlighted
+			}
+		}
+		highlight("name", result.Name)
+		highlight("item_id", result.ItemID)
+		highlight("owner", result.Owner)
+		for key, value := range result.Tags {
+			highlight(model.TagFieldPrefix+key, key+"="+value)
+		}
+	}
+
+	page.Items = results
+	page.Total = total
+	return page, nil
+}
+
+// TextSearchTagKeys 列出全文检索结果中出现过的全部标签键
+func (repo *SearchRepository) TextSearchTagKeys(input string, includeDeleted bool) ([]string, error) {
+	terms := search.TextTerms(input)
+	if len(terms) == 0 {
+		return []string{}, nil
+	}
+	return repo.searchDAO.TextSearchTagKeys(search.BooleanQuery(terms), includeDeleted)
+}
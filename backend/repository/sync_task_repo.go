@@ -0,0 +1,47 @@
+// repository/sync_task_repo.go
+package repository
+
+import (
+	"CMDB/dao"
+	"CMDB/model"
+	"time"
+)
+
+// SyncTaskRepository 同步任务仓库
+type SyncTaskRepository struct {
+	syncTaskDAO *dao.SyncTaskDAO
+}
+
+// NewSyncTaskRepository 创建同步任务仓库
+func NewSyncTaskRepository(syncTaskDAO *dao.SyncTaskDAO) *SyncTaskRepository {
+	return &SyncTaskRepository{syncTaskDAO: syncTaskDAO}
+}
+
+// StartTask 记录开始执行的同步任务，返回任务ID
+func (repo *SyncTaskRepository) StartTask(provider, account, taskType, trigger string) (int64, error) {
+	return repo.syncTaskDAO.CreateSyncTask(provider, account, taskType, trigger)
+}
+
+// FinishTask 记录同步任务的结果，errorMsg为空表示成功
+func (repo *SyncTaskRepository) FinishTask(taskID int64, itemCount, deletedCount int, errorMsg string) error {
+	status := model.SyncTaskStatusSuccess
+	if errorMsg != "" {
+		status = model.SyncTaskStatusFailed
+	}
+	return repo.syncTaskDAO.UpdateSyncTaskStatus(taskID, status, itemCount, deletedCount, errorMsg)
+}
+
+// GetTaskByID 根据ID获取同步任务
+func (repo *SyncTaskRepository) GetTaskByID(taskID int64) (*model.SyncTask, error) {
+	return repo.syncTaskDAO.GetSyncTaskByID(taskID)
+}
+
+// ListTasks 按条件列出同步任务
+func (repo *SyncTaskRepository) ListTasks(filter model.SyncTaskFilter) ([]*model.SyncTask, error) {
+	return repo.syncTaskDAO.ListSyncTasks(filter)
+}
+
+// GetLastSuccessTime 获取最近一次成功同步的时间
+func (repo *SyncTaskRepository) GetLastSuccessTime() (time.Time, error) {
+	return repo.syncTaskDAO.GetLastSuccessTime()
+}
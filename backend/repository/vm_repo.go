@@ -4,16 +4,21 @@ package repository
 import (
 	"CMDB/dao"
 	"CMDB/model"
+	"encoding/json"
+	"time"
 )
 
 // VMRepository 虚拟机仓库
 type VMRepository struct {
-	vmDAO *dao.VMDAO
+	vmDAO      *dao.VMDAO
+	historyDAO *dao.ChangeHistoryDAO
+	versionDAO *dao.ItemVersionDAO
+	searchDAO  *dao.SearchDAO
 }
 
 // NewVMRepository 创建虚拟机仓库
-func NewVMRepository(vmDAO *dao.VMDAO) *VMRepository {
-	return &VMRepository{vmDAO: vmDAO}
+func NewVMRepository(vmDAO *dao.VMDAO, historyDAO *dao.ChangeHistoryDAO, versionDAO *dao.ItemVersionDAO, searchDAO *dao.SearchDAO) *VMRepository {
+	return &VMRepository{vmDAO: vmDAO, historyDAO: historyDAO, versionDAO: versionDAO, searchDAO: searchDAO}
 }
 
 // SaveVirtualMachine 保存虚拟机
@@ -34,7 +39,7 @@ func (repo *VMRepository) BatchSaveVirtualMachines(vms []*model.VM) error {
 // GetVMByResourceID 根据资源ID获取虚拟机
 func (repo *VMRepository) GetVMByResourceID(resourceID string) (*model.VM, error) {
 	// 由于DAO中没有直接通过ResourceID获取VM的方法，我们需要获取所有VM然后筛选
-	vms, err := repo.ListVMs()
+	vms, err := repo.ListVMs(true)
 	if err != nil {
 		return nil, err
 	}
@@ -48,13 +53,30 @@ func (repo *VMRepository) GetVMByResourceID(resourceID string) (*model.VM, error
 	return nil, nil
 }
 
-// GetAllVMs 获取所有虚拟机
+// GetAllVMs 获取所有未删除的虚拟机
 func (repo *VMRepository) GetAllVMs() ([]*model.VM, error) {
-	return repo.vmDAO.ListVMs()
+	return repo.vmDAO.ListVMs(false)
 }
 
-// SaveVM 保存虚拟机及其标签
-func (repo *VMRepository) SaveVM(vm *model.VM) error {
+// SaveVM 保存虚拟机及其标签，与已存储的记录比较后追加变更历史
+// taskID为本次同步任务，为0时变更记录不关联同步任务
+func (repo *VMRepository) SaveVM(vm *model.VM, taskID int64) error {
+	now := time.Now()
+	stored, err := repo.vmDAO.GetVMByID(vm.VMID)
+	if err != nil {
+		return err
+	}
+	var storedFields map[string]string
+	if stored != nil {
+		storedFields = vmFields(stored)
+	}
+	changes := diffChanges(storedFields, stored != nil && stored.DeletedAt != nil, changeItem{
+		itemType:   model.ItemTypeVM,
+		itemID:     vm.VMID,
+		resourceID: vm.ResourceID,
+		fields:     vmFields(vm),
+	}, taskID, now)
+
 	// 开始事务
 	tx, err := repo.vmDAO.BeginTx()
 	if err != nil {
@@ -75,14 +97,36 @@ func (repo *VMRepository) SaveVM(vm *model.VM) error {
 		return err
 	}
 
+	// 追加变更历史
+	err = repo.historyDAO.InsertChangesTx(tx, changes)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 保存版本快照，字段有变化时开始新版本
+	err = repo.versionDAO.SaveVersionTx(tx, model.ItemTypeVM, vm.VMID, vm.ResourceID, vm.Name,
+		vmSnapshot(vm, stored, now), len(changes) > 0, now)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 更新全文检索文档
+	err = repo.searchDAO.UpsertSearchDocumentTx(tx, model.ItemTypeVM, vm.VMID, vm.ResourceID, vm.Provider, vm.Name, vm.Owner, vm.Tags)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	// 提交事务
 	return tx.Commit()
 }
 
-// BatchSaveVMs 批量保存虚拟机
-func (repo *VMRepository) BatchSaveVMs(vms []*model.VM) error {
+// BatchSaveVMs 批量保存虚拟机，taskID为本次同步任务
+func (repo *VMRepository) BatchSaveVMs(vms []*model.VM, taskID int64) error {
 	for _, vm := range vms {
-		if err := repo.SaveVM(vm); err != nil {
+		if err := repo.SaveVM(vm, taskID); err != nil {
 			return err
 		}
 	}
@@ -94,7 +138,35 @@ func (repo *VMRepository) GetVMByID(vmID string) (*model.VM, error) {
 	return repo.vmDAO.GetVMByID(vmID)
 }
 
-// ListVMs 列出所有虚拟机
-func (repo *VMRepository) ListVMs() ([]*model.VM, error) {
-	return repo.vmDAO.ListVMs()
+// ListVMs 列出所有虚拟机，includeDeleted为true时包含已删除的虚拟机
+func (repo *VMRepository) ListVMs(includeDeleted bool) ([]*model.VM, error) {
+	return repo.vmDAO.ListVMs(includeDeleted)
+}
+
+// ListVMsAsOf 获取指定时刻的虚拟机清单，包含之后已删除的虚拟机
+func (repo *VMRepository) ListVMsAsOf(asOf time.Time) ([]*model.VM, error) {
+	snapshots, err := repo.versionDAO.ListSnapshotsAsOf(model.ItemTypeVM, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	vms := make([]*model.VM, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		var vm model.VM
+		if err := json.Unmarshal(snapshot, &vm); err != nil {
+			return nil, err
+		}
+		vms = append(vms, &vm)
+	}
+	return vms, nil
+}
+
+// MarkDeleted 将范围内syncedBefore之后未再同步到的虚拟机标记为已删除
+func (repo *VMRepository) MarkDeleted(provider, subscriptionID string, syncedBefore time.Time, taskID int64) (int64, error) {
+	return repo.vmDAO.MarkVMsDeleted(provider, subscriptionID, syncedBefore, taskID)
+}
+
+// PurgeDeleted 永久删除deletedBefore之前标记删除的虚拟机
+func (repo *VMRepository) PurgeDeleted(deletedBefore time.Time) (int64, error) {
+	return repo.vmDAO.PurgeDeletedVMs(deletedBefore)
 }
@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"CMDB/model"
 	"CMDB/service"
 	"log"
 	"time"
@@ -29,7 +30,7 @@ func (s *CronScheduler) Start() {
 		defer ticker.Stop()
 
 		// 启动时立即执行一次同步
-		if err := s.syncService.SyncAllResources(); err != nil {
+		if err := s.syncService.SyncAllResources(model.SyncTriggerScheduled); err != nil {
 			log.Printf("资源同步失败: %v", err)
 		} else {
 			log.Println("资源同步成功")
@@ -39,7 +40,7 @@ func (s *CronScheduler) Start() {
 			select {
 			case <-ticker.C:
 				// 定时执行同步
-				if err := s.syncService.SyncAllResources(); err != nil {
+				if err := s.syncService.SyncAllResources(model.SyncTriggerScheduled); err != nil {
 					log.Printf("资源同步失败: %v", err)
 				} else {
 					log.Println("资源同步成功")
@@ -0,0 +1,102 @@
+package search
+
+import (
+	"path"
+	"strings"
+)
+
+// Schema 查询编译所需的表结构，描述字段在某张表上对应的列
+type Schema struct {
+	// Kind 表对应的配置项类型，用于匹配kind字段
+	Kind string
+	// Columns 字段名 -> SQL列表达式，不在其中的字段在该表上视为不匹配
+	Columns map[string]string
+	// TagTable 标签表，TagItemColumn为标签表中关联配置项的列，ItemColumn为主表中被关联的列
+	TagTable      string
+	TagItemColumn string
+	ItemColumn    string
+}
+
+// Compile 将查询编译为参数化的SQL条件，返回的条件可以直接拼接在WHERE之后
+// 所有值都以参数传递，列名和表名只来自Schema
+func Compile(query *Query, schema Schema) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	for _, term := range query.Terms {
+		condition, termArgs := compileTerm(term, schema)
+		if term.Negate {
+			condition = "NOT (" + condition + ")"
+		}
+		conditions = append(conditions, condition)
+		args = append(args, termArgs...)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// compileTerm 编译单个条件（不含取反）
+func compileTerm(term Term, schema Schema) (string, []interface{}) {
+	switch term.Field {
+	case FieldKind:
+		// 配置项类型在编译时即可确定
+		if matchValue(term, schema.Kind) {
+			return "1 = 1", nil
+		}
+		return "1 = 0", nil
+
+	case FieldTag:
+		query := "EXISTS (SELECT 1 FROM " + schema.TagTable + " t WHERE t." + schema.TagItemColumn + " = " + schema.ItemColumn + " AND t.tag_key = ?"
+		args := []interface{}{term.TagKey}
+		if term.Match != MatchExists {
+			condition, valueArgs := compileMatch("t.tag_value", term)
+			query += " AND " + condition
+			args = append(args, valueArgs...)
+		}
+		return query + ")", args
+	}
+
+	column, ok := schema.Columns[term.Field]
+	if !ok {
+		return "1 = 0", nil
+	}
+	// 可为NULL的列按空字符串比较，保证取反条件的结果符合直觉
+	column = "COALESCE(" + column + ", '')"
+
+	// 类型字段按完整类型或最后一段匹配，如 virtualMachines 匹配 Microsoft.Compute/virtualMachines
+	if term.Field == FieldType && term.Match == MatchEquals {
+		return "(" + column + " = ? OR " + column + " LIKE ?)", []interface{}{term.Value, "%/" + EscapeLike(term.Value)}
+	}
+	return compileMatch(column, term)
+}
+
+// compileMatch 按匹配方式编译列与值的比较
+func compileMatch(column string, term Term) (string, []interface{}) {
+	switch term.Match {
+	case MatchContains:
+		return column + " LIKE ?", []interface{}{"%" + EscapeLike(term.Value) + "%"}
+	case MatchWildcard:
+		return column + " LIKE ?", []interface{}{strings.ReplaceAll(EscapeLike(term.Value), "*", "%")}
+	default:
+		return column + " = ?", []interface{}{term.Value}
+	}
+}
+
+// EscapeLike 转义LIKE模式中的特殊字符（反斜杠、%和_）
+func EscapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
+
+// matchValue 在内存中按条件的匹配方式比较值，忽略大小写
+func matchValue(term Term, value string) bool {
+	value = strings.ToLower(value)
+	pattern := strings.ToLower(term.Value)
+	switch term.Match {
+	case MatchContains:
+		return strings.Contains(value, pattern)
+	case MatchWildcard:
+		matched, _ := path.Match(strings.NewReplacer(`\`, `\\`, `?`, `\?`, `[`, `\[`).Replace(pattern), value)
+		return matched
+	default:
+		return value == pattern
+	}
+}
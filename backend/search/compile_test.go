@@ -0,0 +1,104 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testSchema = Schema{
+	Kind: "resource",
+	Columns: map[string]string{
+		FieldName:  "r.name",
+		FieldType:  "r.resource_type",
+		FieldOwner: "r.owner",
+	},
+	TagTable:      "resource_tags",
+	TagItemColumn: "resource_id",
+	ItemColumn:    "r.resource_id",
+}
+
+const testTagExists = "EXISTS (SELECT 1 FROM resource_tags t WHERE t.resource_id = r.resource_id AND t.tag_key = ?"
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		input string
+		where string
+		args  []interface{}
+	}{
+		{"name:web", "COALESCE(r.name, '') = ?", []interface{}{"web"}},
+		{"web", "COALESCE(r.name, '') LIKE ?", []interface{}{"%web%"}},
+		{"name:web*", "COALESCE(r.name, '') LIKE ?", []interface{}{"web%"}},
+		{"name:50%_off*", "COALESCE(r.name, '') LIKE ?", []interface{}{`50\%\_off%`}},
+		{"owner:~a_b", "COALESCE(r.owner, '') LIKE ?", []interface{}{`%a\_b%`}},
+		{`name:"web*"`, "COALESCE(r.name, '') = ?", []interface{}{"web*"}},
+
+		// 类型按完整类型或最后一段匹配
+		{"type:virtualMachines", "(COALESCE(r.resource_type, '') = ? OR COALESCE(r.resource_type, '') LIKE ?)",
+			[]interface{}{"virtualMachines", "%/virtualMachines"}},
+		{"type:a_b", "(COALESCE(r.resource_type, '') = ? OR COALESCE(r.resource_type, '') LIKE ?)",
+			[]interface{}{"a_b", `%/a\_b`}},
+		{"type:~virtual", "COALESCE(r.resource_type, '') LIKE ?", []interface{}{"%virtual%"}},
+
+		// 取反
+		{"-name:web", "NOT (COALESCE(r.name, '') = ?)", []interface{}{"web"}},
+		{"-web", "NOT (COALESCE(r.name, '') LIKE ?)", []interface{}{"%web%"}},
+
+		// 表上没有的字段不匹配
+		{"location:x", "1 = 0", nil},
+		{"-location:x", "NOT (1 = 0)", nil},
+
+		// kind在编译时确定
+		{"kind:resource", "1 = 1", nil},
+		{"kind:RESOURCE", "1 = 1", nil},
+		{"kind:vm", "1 = 0", nil},
+		{"kind:res*", "1 = 1", nil},
+		{"kind:~sour", "1 = 1", nil},
+		{"-kind:vm", "NOT (1 = 0)", nil},
+
+		// 标签
+		{"tag:env", testTagExists + ")", []interface{}{"env"}},
+		{"tag:env=prod", testTagExists + " AND t.tag_value = ?)", []interface{}{"env", "prod"}},
+		{"tag:env=~pr_d", testTagExists + " AND t.tag_value LIKE ?)", []interface{}{"env", `%pr\_d%`}},
+		{"tag:env=pr*", testTagExists + " AND t.tag_value LIKE ?)", []interface{}{"env", "pr%"}},
+		{"-tag:env=~prod", "NOT (" + testTagExists + " AND t.tag_value LIKE ?))", []interface{}{"env", "%prod%"}},
+
+		// 多个条件为AND关系，参数按条件顺序排列
+		{"name:web tag:env -owner:~bob", "COALESCE(r.name, '') = ? AND " + testTagExists + ") AND NOT (COALESCE(r.owner, '') LIKE ?)",
+			[]interface{}{"web", "env", "%bob%"}},
+	}
+
+	for _, tt := range tests {
+		query, err := Parse(tt.input)
+		if err != nil {
+			t.Fatalf("Parse(%q) 失败: %v", tt.input, err)
+		}
+		where, args := Compile(query, testSchema)
+		if where != tt.where {
+			t.Errorf("Compile(%q) 条件为 %q，期望 %q", tt.input, where, tt.where)
+		}
+		if len(args) != len(tt.args) || (len(args) > 0 && !reflect.DeepEqual(args, tt.args)) {
+			t.Errorf("Compile(%q) 参数为 %q，期望 %q", tt.input, args, tt.args)
+		}
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"abc", "abc"},
+		{"100%", `100\%`},
+		{"a_b", `a\_b`},
+		{`C:\dir`, `C:\\dir`},
+		{`\%_`, `\\\%\_`},
+		{"名称_1", `名称\_1`},
+	}
+
+	for _, tt := range tests {
+		if got := EscapeLike(tt.value); got != tt.want {
+			t.Errorf("EscapeLike(%q) = %q，期望 %q", tt.value, got, tt.want)
+		}
+	}
+}
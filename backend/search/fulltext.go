@@ -0,0 +1,83 @@
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+// 高亮标记，匹配片段两侧使用
+const (
+	HighlightPre  = "<em>"
+	HighlightPost = "</em>"
+)
+
+// TextTerms 将全文搜索输入拆分为检索词，去掉MySQL布尔模式中的引号
+func TextTerms(input string) []string {
+	var terms []string
+	for _, word := range strings.Fields(input) {
+		word = strings.ReplaceAll(word, `"`, "")
+		if word != "" {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// BooleanQuery 构造MySQL全文检索的布尔模式查询，每个检索词作为短语且必须出现
+// 配合ngram分词器时短语匹配相当于子串匹配，可以检索资源ID和标签值的片段
+func BooleanQuery(terms []string) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		parts = append(parts, `+"`+term+`"`)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Highlight 将text中出现的检索词（忽略大小写）用高亮标记包围，其余部分做HTML转义
+// 没有任何检索词出现时返回false
+func Highlight(text string, terms []string) (string, bool) {
+	// 大小写转换改变字节长度时（少数Unicode字符）无法与原文对齐，此时区分大小写匹配
+	haystack := strings.ToLower(text)
+	aligned := len(haystack) == len(text)
+	if !aligned {
+		haystack = text
+	}
+
+	// marked[i]为true表示text的第i个字节属于某个匹配片段
+	marked := make([]bool, len(text))
+	found := false
+	for _, term := range terms {
+		if aligned {
+			term = strings.ToLower(term)
+		}
+		for start := 0; term != ""; {
+			i := strings.Index(haystack[start:], term)
+			if i < 0 {
+				break
+			}
+			for j := start + i; j < start+i+len(term); j++ {
+				marked[j] = true
+			}
+			found = true
+			start += i + len(term)
+		}
+	}
+	if !found {
+		return html.EscapeString(text), false
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		j := i
+		for j < len(text) && marked[j] == marked[i] {
+			j++
+		}
+		if marked[i] {
+			b.WriteString(HighlightPre + html.EscapeString(text[i:j]) + HighlightPost)
+		} else {
+			b.WriteString(html.EscapeString(text[i:j]))
+		}
+		i = j
+	}
+	return b.String(), true
+}
@@ -0,0 +1,286 @@
+// Package search 实现资源搜索查询语言的解析和SQL编译
+//
+// 查询由空白分隔的条件组成，条件之间为AND关系：
+//
+//	type:virtualMachines location:chinanorth3 tag:env=prod owner:~alice name:web* -status:Stopped
+//
+// 条件的写法：
+//
+//	field:value    字段等于value，value中的*为通配符
+//	field:~value   字段包含value
+//	tag:key        存在标签key；tag:key=value、tag:key=~value 匹配标签值
+//	-条件          对条件取反
+//	不带字段的词   名称包含该词
+//
+// 支持的字段为 name、id、type、location、subscription、owner、status、provider、tag 和 kind。
+// 值可以用双引号包围以包含空格，引号内用 \" 和 \\ 转义。
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// 支持的字段
+const (
+	FieldName         = "name"
+	FieldID           = "id"
+	FieldType         = "type"
+	FieldLocation     = "location"
+	FieldSubscription = "subscription"
+	FieldOwner        = "owner"
+	FieldStatus       = "status"
+	FieldProvider     = "provider"
+	FieldTag          = "tag"
+	// FieldKind 配置项类型：resource、vm 或 database
+	FieldKind = "kind"
+)
+
+var knownFields = map[string]bool{
+	FieldName:         true,
+	FieldID:           true,
+	FieldType:         true,
+	FieldLocation:     true,
+	FieldSubscription: true,
+	FieldOwner:        true,
+	FieldStatus:       true,
+	FieldProvider:     true,
+	FieldTag:          true,
+	FieldKind:         true,
+}
+
+// 匹配方式
+const (
+	MatchEquals   = "equals"
+	MatchContains = "contains"
+	MatchWildcard = "wildcard"
+	// MatchExists 仅用于标签，表示存在该标签
+	MatchExists = "exists"
+)
+
+// Term 查询中的单个条件
+type Term struct {
+	Field  string
+	Negate bool
+	// TagKey 标签条件的标签键
+	TagKey string
+	Match  string
+	Value  string
+	// Pos 条件在查询中的位置（从1开始，按字符计）
+	Pos int
+}
+
+// Query 解析后的查询，各条件之间为AND关系
+type Query struct {
+	Terms []Term
+}
+
+// SyntaxError 查询语法错误，Pos为出错的位置（从1开始，按字符计）
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("第%d个字符: %s", e.Pos, e.Msg)
+}
+
+// Parse 解析查询字符串
+func Parse(input string) (*Query, error) {
+	p := &parser{input: []rune(input)}
+	query := &Query{}
+	for {
+		p.skipSpace()
+		if p.eof() {
+			break
+		}
+		term, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		query.Terms = append(query.Terms, term)
+	}
+	if len(query.Terms) == 0 {
+		return nil, &SyntaxError{Pos: 1, Msg: "查询为空"}
+	}
+	return query, nil
+}
+
+type parser struct {
+	input []rune
+	pos   int // 下一个待读取字符的下标（从0开始）
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) peek() rune {
+	return p.input[p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+// errorAt 构造位于下标index处的语法错误
+func (p *parser) errorAt(index int, format string, args ...interface{}) error {
+	return &SyntaxError{Pos: index + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+// parseTerm 解析一个条件: [-] [field ":"] value
+func (p *parser) parseTerm() (Term, error) {
+	term := Term{Pos: p.pos + 1}
+	if p.peek() == '-' {
+		term.Negate = true
+		p.pos++
+		if p.eof() || unicode.IsSpace(p.peek()) {
+			return term, p.errorAt(p.pos-1, "\"-\"后缺少条件")
+		}
+	}
+
+	start := p.pos
+	word, quoted, err := p.readValue(":")
+	if err != nil {
+		return term, err
+	}
+
+	// 不带字段的词按名称包含匹配
+	if quoted || p.eof() || p.peek() != ':' {
+		term.Field = FieldName
+		term.Match = MatchContains
+		term.Value = word
+		if word == "" {
+			return term, p.errorAt(start, "值不能为空")
+		}
+		return term, nil
+	}
+
+	field := strings.ToLower(word)
+	if field == "" {
+		return term, p.errorAt(start, "\":\"前缺少字段名")
+	}
+	if !knownFields[field] {
+		return term, p.errorAt(start, "未知的字段 %q", word)
+	}
+	term.Field = field
+	p.pos++ // 跳过 ':'
+
+	if field == FieldTag {
+		return p.parseTag(term)
+	}
+
+	valueStart := p.pos
+	term.Match, term.Value, err = p.parseMatch()
+	if err != nil {
+		return term, err
+	}
+	if term.Value == "" {
+		return term, p.errorAt(valueStart, "字段 %s 缺少值", field)
+	}
+	return term, nil
+}
+
+// parseTag 解析标签条件: key [ "=" ["~"] value ]
+func (p *parser) parseTag(term Term) (Term, error) {
+	keyStart := p.pos
+	key, _, err := p.readValue("=")
+	if err != nil {
+		return term, err
+	}
+	if key == "" {
+		return term, p.errorAt(keyStart, "tag 缺少标签键")
+	}
+	term.TagKey = key
+
+	if p.eof() || p.peek() != '=' {
+		term.Match = MatchExists
+		return term, nil
+	}
+	p.pos++ // 跳过 '='
+
+	valueStart := p.pos
+	term.Match, term.Value, err = p.parseMatch()
+	if err != nil {
+		return term, err
+	}
+	if term.Value == "" {
+		return term, p.errorAt(valueStart, "标签 %s 缺少值", key)
+	}
+	return term, nil
+}
+
+// parseMatch 解析值及其匹配方式: ["~"] value
+func (p *parser) parseMatch() (string, string, error) {
+	contains := false
+	if !p.eof() && p.peek() == '~' {
+		contains = true
+		p.pos++
+	}
+
+	value, quoted, err := p.readValue("")
+	if err != nil {
+		return "", "", err
+	}
+
+	switch {
+	case contains:
+		return MatchContains, value, nil
+	case !quoted && strings.Contains(value, "*"):
+		return MatchWildcard, value, nil
+	default:
+		return MatchEquals, value, nil
+	}
+}
+
+// readValue 读取一个值，遇到空白或stops中的字符时结束；以双引号开头时读取到匹配的引号
+func (p *parser) readValue(stops string) (string, bool, error) {
+	if !p.eof() && p.peek() == '"' {
+		return p.readQuoted(stops)
+	}
+
+	var b strings.Builder
+	for !p.eof() {
+		r := p.peek()
+		if unicode.IsSpace(r) || strings.ContainsRune(stops, r) {
+			break
+		}
+		if r == '"' {
+			return "", false, p.errorAt(p.pos, "引号只能出现在值的开头")
+		}
+		b.WriteRune(r)
+		p.pos++
+	}
+	return b.String(), false, nil
+}
+
+// readQuoted 读取双引号包围的值，结束引号后只能是空白或stops中的字符
+func (p *parser) readQuoted(stops string) (string, bool, error) {
+	start := p.pos
+	p.pos++ // 跳过开头的引号
+
+	var b strings.Builder
+	for !p.eof() {
+		r := p.peek()
+		p.pos++
+		switch r {
+		case '"':
+			if !p.eof() && !unicode.IsSpace(p.peek()) && !strings.ContainsRune(stops, p.peek()) {
+				return "", true, p.errorAt(p.pos, "引号后应为空白")
+			}
+			return b.String(), true, nil
+		case '\\':
+			if p.eof() {
+				return "", true, p.errorAt(p.pos-1, "转义符后缺少字符")
+			}
+			b.WriteRune(p.peek())
+			p.pos++
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return "", true, p.errorAt(start, "引号未闭合")
+}
@@ -0,0 +1,112 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []Term
+	}{
+		{"type:virtualMachines", []Term{{Field: FieldType, Match: MatchEquals, Value: "virtualMachines", Pos: 1}}},
+		{"web", []Term{{Field: FieldName, Match: MatchContains, Value: "web", Pos: 1}}},
+		{"NAME:web*", []Term{{Field: FieldName, Match: MatchWildcard, Value: "web*", Pos: 1}}},
+		{"owner:~alice", []Term{{Field: FieldOwner, Match: MatchContains, Value: "alice", Pos: 1}}},
+		{"kind:vm", []Term{{Field: FieldKind, Match: MatchEquals, Value: "vm", Pos: 1}}},
+
+		// 取反
+		{"-status:Stopped", []Term{{Field: FieldStatus, Negate: true, Match: MatchEquals, Value: "Stopped", Pos: 1}}},
+		{"-web", []Term{{Field: FieldName, Negate: true, Match: MatchContains, Value: "web", Pos: 1}}},
+		{"a  -b", []Term{
+			{Field: FieldName, Match: MatchContains, Value: "a", Pos: 1},
+			{Field: FieldName, Negate: true, Match: MatchContains, Value: "b", Pos: 4},
+		}},
+		{"-tag:env=~prod", []Term{{Field: FieldTag, Negate: true, TagKey: "env", Match: MatchContains, Value: "prod", Pos: 1}}},
+
+		// 标签
+		{"tag:env", []Term{{Field: FieldTag, TagKey: "env", Match: MatchExists, Pos: 1}}},
+		{"tag:env=prod", []Term{{Field: FieldTag, TagKey: "env", Match: MatchEquals, Value: "prod", Pos: 1}}},
+		{"tag:env=~pr", []Term{{Field: FieldTag, TagKey: "env", Match: MatchContains, Value: "pr", Pos: 1}}},
+		{"tag:env=pr*", []Term{{Field: FieldTag, TagKey: "env", Match: MatchWildcard, Value: "pr*", Pos: 1}}},
+		{"tag:env=~a=b", []Term{{Field: FieldTag, TagKey: "env", Match: MatchContains, Value: "a=b", Pos: 1}}},
+		{`tag:"cost center"=~"a b"`, []Term{{Field: FieldTag, TagKey: "cost center", Match: MatchContains, Value: "a b", Pos: 1}}},
+
+		// 引号和转义
+		{`"hello world"`, []Term{{Field: FieldName, Match: MatchContains, Value: "hello world", Pos: 1}}},
+		{`"a:b"`, []Term{{Field: FieldName, Match: MatchContains, Value: "a:b", Pos: 1}}},
+		{`name:"web*"`, []Term{{Field: FieldName, Match: MatchEquals, Value: "web*", Pos: 1}}},
+		{`location:"china north"`, []Term{{Field: FieldLocation, Match: MatchEquals, Value: "china north", Pos: 1}}},
+		{`name:"say \"hi\" \\ ok"`, []Term{{Field: FieldName, Match: MatchEquals, Value: `say "hi" \ ok`, Pos: 1}}},
+		{`owner:~"\a"`, []Term{{Field: FieldOwner, Match: MatchContains, Value: "a", Pos: 1}}},
+
+		// 多个条件，位置按字符计
+		{"type:vm  tag:env=prod", []Term{
+			{Field: FieldType, Match: MatchEquals, Value: "vm", Pos: 1},
+			{Field: FieldTag, TagKey: "env", Match: MatchEquals, Value: "prod", Pos: 10},
+		}},
+		{"名称 -x", []Term{
+			{Field: FieldName, Match: MatchContains, Value: "名称", Pos: 1},
+			{Field: FieldName, Negate: true, Match: MatchContains, Value: "x", Pos: 4},
+		}},
+	}
+
+	for _, tt := range tests {
+		query, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) 失败: %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(query.Terms, tt.want) {
+			t.Errorf("Parse(%q) = %+v，期望 %+v", tt.input, query.Terms, tt.want)
+		}
+	}
+}
+
+func TestParseSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+		msg   string
+	}{
+		{"", 1, "查询为空"},
+		{"   ", 1, "查询为空"},
+		{"-", 1, "\"-\"后缺少条件"},
+		{"a - b", 3, "\"-\"后缺少条件"},
+		{":x", 1, "缺少字段名"},
+		{"foo:bar", 1, "未知的字段"},
+		{"a foo:bar", 3, "未知的字段"},
+		{"name:", 6, "缺少值"},
+		{"name:~", 6, "缺少值"},
+		{"tag:", 5, "缺少标签键"},
+		{"tag:env=", 9, "缺少值"},
+		{`ab"c`, 3, "引号只能出现在值的开头"},
+		{`"abc`, 1, "引号未闭合"},
+		{`name:"abc`, 6, "引号未闭合"},
+		{`"ab"c`, 5, "引号后应为空白"},
+		{`"ab\`, 4, "转义符后缺少字符"},
+		{"名称 名:x", 4, "未知的字段"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.input)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Parse(%q) 应返回SyntaxError，实际为 %v", tt.input, err)
+			continue
+		}
+		if syntaxErr.Pos != tt.pos || !strings.Contains(syntaxErr.Msg, tt.msg) {
+			t.Errorf("Parse(%q) 错误为 %d: %s，期望 %d: %s", tt.input, syntaxErr.Pos, syntaxErr.Msg, tt.pos, tt.msg)
+		}
+	}
+}
+
+func TestSyntaxErrorMessage(t *testing.T) {
+	err := &SyntaxError{Pos: 7, Msg: "引号未闭合"}
+	if got := err.Error(); got != "第7个字符: 引号未闭合" {
+		t.Errorf("Error() = %q", got)
+	}
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"CMDB/model"
+	"CMDB/repository"
+	"fmt"
+	"strings"
+)
+
+// maxApplicationMembers 单次请求最多指定的手动成员数
+const maxApplicationMembers = 1000
+
+// ApplicationService 应用和业务服务管理服务，维护应用CI及其成员
+type ApplicationService struct {
+	applicationRepo *repository.ApplicationRepository
+	resourceRepo    *repository.ResourceRepository
+}
+
+// NewApplicationService 创建新的应用管理服务
+func NewApplicationService(applicationRepo *repository.ApplicationRepository, resourceRepo *repository.ResourceRepository) *ApplicationService {
+	return &ApplicationService{applicationRepo: applicationRepo, resourceRepo: resourceRepo}
+}
+
+// ListApplications 按条件列出应用
+func (s *ApplicationService) ListApplications(filter model.ApplicationFilter) ([]*model.Application, error) {
+	if filter.Kind != "" && !model.ApplicationKinds[filter.Kind] {
+		return nil, fmt.Errorf("%w: 无效的kind %s", ErrInvalidRequest, filter.Kind)
+	}
+	if filter.Criticality != "" && !model.Criticalities[filter.Criticality] {
+		return nil, fmt.Errorf("%w: 无效的criticality %s", ErrInvalidRequest, filter.Criticality)
+	}
+	return s.applicationRepo.ListApplications(filter)
+}
+
+// GetApplication 获取应用，不存在时返回nil
+func (s *ApplicationService) GetApplication(id int64) (*model.Application, error) {
+	return s.applicationRepo.GetApplication(id)
+}
+
+// CreateApplication 创建应用，kind默认为application，criticality默认为medium
+func (s *ApplicationService) CreateApplication(req *model.Application) (*model.Application, error) {
+	application := *req
+	application.ID = 0
+	if err := s.validateApplication(&application); err != nil {
+		return nil, err
+	}
+	if err := s.applicationRepo.CreateApplication(&application); err != nil {
+		return nil, err
+	}
+	return s.applicationRepo.GetApplication(application.ID)
+}
+
+// UpdateApplication 更新应用的属性；请求中tag_rules、members未指定时保持不变，指定时整体替换
+// 应用不存在时返回nil
+func (s *ApplicationService) UpdateApplication(id int64, req *model.Application) (*model.Application, error) {
+	existing, err := s.applicationRepo.GetApplication(id)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	application := *req
+	application.ID = id
+	if err := s.validateApplication(&application); err != nil {
+		return nil, err
+	}
+	if err := s.applicationRepo.UpdateApplication(&application, application.TagRules, application.Members); err != nil {
+		return nil, err
+	}
+	return s.applicationRepo.GetApplication(id)
+}
+
+// DeleteApplication 删除应用，应用不存在时返回false
+func (s *ApplicationService) DeleteApplication(id int64) (bool, error) {
+	return s.applicationRepo.DeleteApplication(id)
+}
+
+// AddMembers 添加应用的手动成员，资源必须在资源清单中；应用不存在时返回nil
+func (s *ApplicationService) AddMembers(id int64, req model.ApplicationMembersRequest) (*model.Application, error) {
+	resourceIDs, err := normalizeMembers(req.ResourceIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(resourceIDs) == 0 {
+		return nil, fmt.Errorf("%w: 必须指定resource_ids", ErrInvalidRequest)
+	}
+	application, err := s.applicationRepo.GetApplication(id)
+	if err != nil || application == nil {
+		return nil, err
+	}
+	if err := s.checkResourcesExist(resourceIDs); err != nil {
+		return nil, err
+	}
+	if err := s.applicationRepo.AddMembers(id, resourceIDs); err != nil {
+		return nil, err
+	}
+	return s.applicationRepo.GetApplication(id)
+}
+
+// RemoveMembers 移除应用的手动成员，按标签规则匹配的资源不受影响；应用不存在时返回nil
+func (s *ApplicationService) RemoveMembers(id int64, req model.ApplicationMembersRequest) (*model.Application, error) {
+	resourceIDs, err := normalizeMembers(req.ResourceIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(resourceIDs) == 0 {
+		return nil, fmt.Errorf("%w: 必须指定resource_ids", ErrInvalidRequest)
+	}
+	application, err := s.applicationRepo.GetApplication(id)
+	if err != nil || application == nil {
+		return nil, err
+	}
+	if err := s.applicationRepo.RemoveMembers(id, resourceIDs); err != nil {
+		return nil, err
+	}
+	return s.applicationRepo.GetApplication(id)
+}
+
+// ListResources 分页列出属于应用的未删除资源，包括手动指定和按标签规则匹配的资源
+func (s *ApplicationService) ListResources(id int64, filter model.ResourceFilter) (*model.ResourcePage, error) {
+	filter.ApplicationID = id
+	filter.IncludeDeleted = false
+	return s.resourceRepo.ListResources(filter)
+}
+
+// ListResourceKeys 列出应用的资源上出现过的全部标签键和属性键，条件与ListResources相同
+func (s *ApplicationService) ListResourceKeys(id int64, filter model.ResourceFilter) ([]string, []string, error) {
+	filter.ApplicationID = id
+	filter.IncludeDeleted = false
+	return s.resourceRepo.ListResourceKeys(filter)
+}
+
+// validateApplication 校验并规范化应用的属性、标签规则和手动成员
+func (s *ApplicationService) validateApplication(application *model.Application) error {
+	application.Name = strings.TrimSpace(application.Name)
+	if application.Name == "" {
+		return fmt.Errorf("%w: 必须指定name", ErrInvalidRequest)
+	}
+	if application.Kind == "" {
+		application.Kind = model.ApplicationKindApplication
+	}
+	if !model.ApplicationKinds[application.Kind] {
+		return fmt.Errorf("%w: 无效的kind %s，可选 application、business_service", ErrInvalidRequest, application.Kind)
+	}
+	if application.Criticality == "" {
+		application.Criticality = model.CriticalityMedium
+	}
+	if !model.Criticalities[application.Criticality] {
+		return fmt.Errorf("%w: 无效的criticality %s，可选 low、medium、high、critical", ErrInvalidRequest, application.Criticality)
+	}
+
+	for i, rule := range application.TagRules {
+		application.TagRules[i].Key = strings.TrimSpace(rule.Key)
+		if application.TagRules[i].Key == "" {
+			return fmt.Errorf("%w: 标签规则必须指定key", ErrInvalidRequest)
+		}
+	}
+
+	if application.Members != nil {
+		members, err := normalizeMembers(application.Members)
+		if err != nil {
+			return err
+		}
+		if err := s.checkResourcesExist(members); err != nil {
+			return err
+		}
+		application.Members = members
+	}
+
+	exists, err := s.applicationRepo.ApplicationNameExists(application.Name, application.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%w: 名称 %s 已被使用", ErrInvalidRequest, application.Name)
+	}
+	return nil
+}
+
+// normalizeMembers 去除空白和重复的资源ID，结果不为nil
+func normalizeMembers(resourceIDs []string) ([]string, error) {
+	seen := make(map[string]bool)
+	members := []string{}
+	for _, id := range resourceIDs {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[strings.ToLower(id)] {
+			continue
+		}
+		seen[strings.ToLower(id)] = true
+		members = append(members, id)
+	}
+	if len(members) > maxApplicationMembers {
+		return nil, fmt.Errorf("%w: 单次最多指定%d个资源", ErrInvalidRequest, maxApplicationMembers)
+	}
+	return members, nil
+}
+
+// checkResourcesExist 检查资源都在资源清单中
+func (s *ApplicationService) checkResourcesExist(resourceIDs []string) error {
+	missing, err := s.applicationRepo.MissingResourceIDs(resourceIDs)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: 资源不存在: %s", ErrInvalidRequest, strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"CMDB/model"
+	"CMDB/policy"
+	"CMDB/repository"
+	"log"
+	"time"
+)
+
+// ComplianceService 标签合规服务，按标签策略评估资源并生成合规报告
+type ComplianceService struct {
+	evaluator      *policy.Evaluator
+	resourceRepo   *repository.ResourceRepository
+	complianceRepo *repository.ComplianceRepository
+}
+
+// NewComplianceService 创建新的标签合规服务，evaluator为nil时表示未配置标签策略
+func NewComplianceService(
+	evaluator *policy.Evaluator,
+	resourceRepo *repository.ResourceRepository,
+	complianceRepo *repository.ComplianceRepository,
+) *ComplianceService {
+	return &ComplianceService{
+		evaluator:      evaluator,
+		resourceRepo:   resourceRepo,
+		complianceRepo: complianceRepo,
+	}
+}
+
+// Evaluate 按标签策略评估全部未删除的资源，保存各资源的结果并追加合规快照
+// 未配置标签策略时不做任何操作
+func (s *ComplianceService) Evaluate() error {
+	if s.evaluator == nil {
+		return nil
+	}
+
+	resources, err := s.resourceRepo.GetAllResources(false)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	snapshot := &model.ComplianceSnapshot{EvaluatedAt: now, TotalResources: len(resources)}
+	results := make([]*model.ResourceCompliance, 0, len(resources))
+	for _, resource := range resources {
+		violations := s.evaluator.Evaluate(resource)
+		results = append(results, &model.ResourceCompliance{
+			ResourceID:     resource.ResourceID,
+			Name:           resource.Name,
+			ResourceType:   resource.ResourceType,
+			SubscriptionID: resource.SubscriptionID,
+			Owner:          resource.Owner,
+			OwnerSource:    resource.OwnerSource,
+			Compliant:      len(violations) == 0,
+			Violations:     violations,
+			EvaluatedAt:    now,
+		})
+		if len(violations) == 0 {
+			snapshot.CompliantResources++
+		}
+		snapshot.ViolationCount += len(violations)
+	}
+	snapshot.ComplianceRate = model.ComplianceRate(snapshot.CompliantResources, snapshot.TotalResources)
+
+	if err := s.complianceRepo.SaveEvaluation(results, snapshot); err != nil {
+		return err
+	}
+	log.Printf("标签合规评估完成: 资源 %d, 合规 %d, 违规 %d", snapshot.TotalResources, snapshot.CompliantResources, snapshot.ViolationCount)
+	return nil
+}
+
+// Report 生成最近一次评估的合规报告，trendSince之后的合规快照作为趋势
+func (s *ComplianceService) Report(filter model.ComplianceFilter, trendSince time.Time) (*model.ComplianceReport, error) {
+	report := &model.ComplianceReport{Policies: []model.TagPolicy{}, Limit: filter.Limit, Offset: filter.Offset}
+	if s.evaluator != nil {
+		report.Policies = s.evaluator.Policies()
+	}
+
+	var err error
+	if report.Summary, err = s.complianceRepo.Summarize(filter); err != nil {
+		return nil, err
+	}
+	if report.ByResource, report.ResourceTotal, err = s.complianceRepo.ListNonCompliant(filter); err != nil {
+		return nil, err
+	}
+	if report.ByOwner, err = s.complianceRepo.SummarizeByOwner(filter); err != nil {
+		return nil, err
+	}
+	if report.ByOwnerSource, err = s.complianceRepo.SummarizeByOwnerSource(filter); err != nil {
+		return nil, err
+	}
+	if report.BySubscription, err = s.complianceRepo.SummarizeBySubscription(filter); err != nil {
+		return nil, err
+	}
+	if report.Trend, err = s.complianceRepo.ListSnapshots(trendSince); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
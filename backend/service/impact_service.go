@@ -0,0 +1,206 @@
+package service
+
+import (
+	"CMDB/model"
+	"CMDB/repository"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ImpactService 影响分析服务，从某个CI出发找出直接或间接依赖它的CI及其所有者
+type ImpactService struct {
+	impactRepo       *repository.ImpactRepository
+	relationshipRepo *repository.RelationshipRepository
+	ownerService     *OwnerService
+}
+
+// NewImpactService 创建新的影响分析服务
+func NewImpactService(impactRepo *repository.ImpactRepository, relationshipRepo *repository.RelationshipRepository, ownerService *OwnerService) *ImpactService {
+	return &ImpactService{impactRepo: impactRepo, relationshipRepo: relationshipRepo, ownerService: ownerService}
+}
+
+// impactCandidate 本层新发现的受影响CI及其依据
+type impactCandidate struct {
+	id           string
+	resourceType string
+	parent       *model.ImpactedItem
+	reason       string
+	relationship string
+}
+
+// Analyze 分析对资源做维护时受影响的CI，逐层查找依赖方，最多depth层：
+// 同步时发现的关系中以其为目标的来源、资源ID位于其之下的子资源、server字段为该数据库服务器的数据库
+// 资源不在清单中时返回nil
+func (s *ImpactService) Analyze(resourceID string, depth int) (*model.ImpactReport, error) {
+	if depth < 1 || depth > MaxGraphDepth {
+		return nil, fmt.Errorf("%w: depth必须是1到%d之间的整数", ErrInvalidRequest, MaxGraphDepth)
+	}
+
+	roots, err := s.impactRepo.GetImpactedItems([]string{resourceID})
+	if err != nil {
+		return nil, err
+	}
+	root := roots[strings.ToLower(resourceID)]
+	if root == nil {
+		return nil, nil
+	}
+
+	report := &model.ImpactReport{
+		Root:        root,
+		Depth:       depth,
+		GeneratedAt: time.Now(),
+		VMs:         []*model.ImpactedItem{},
+		Databases:   []*model.ImpactedItem{},
+		Others:      []*model.ImpactedItem{},
+		Owners:      []*model.ImpactedOwner{},
+	}
+	found := map[string]*model.ImpactedItem{strings.ToLower(root.ResourceID): root}
+	var impacted []*model.ImpactedItem
+	frontier := []*model.ImpactedItem{root}
+
+	for level := 1; level <= depth && len(frontier) > 0; level++ {
+		var candidates []*impactCandidate
+		seen := make(map[string]bool)
+		add := func(candidate *impactCandidate) {
+			key := strings.ToLower(candidate.id)
+			if found[key] != nil || seen[key] {
+				return
+			}
+			if len(found)+len(candidates) >= maxGraphNodes {
+				report.Truncated = true
+				return
+			}
+			seen[key] = true
+			candidates = append(candidates, candidate)
+		}
+
+		ids := make([]string, len(frontier))
+		for i, item := range frontier {
+			ids[i] = item.ResourceID
+		}
+		relationships, err := s.relationshipRepo.ListRelationships(ids, model.RelationshipFilter{Direction: model.DirectionIncoming})
+		if err != nil {
+			return nil, err
+		}
+		for _, relationship := range relationships {
+			if parent := found[strings.ToLower(relationship.TargetID)]; parent != nil {
+				add(&impactCandidate{
+					id:           relationship.SourceID,
+					resourceType: relationship.SourceType,
+					parent:       parent,
+					reason:       model.ImpactReasonRelationship,
+					relationship: relationship.Type,
+				})
+			}
+		}
+
+		for _, item := range frontier {
+			// 多取一个以判断子资源是否超过上限，超过上限的部分不再查询
+			children, err := s.impactRepo.ListChildResourceIDs(item.ResourceID, maxGraphNodes+1)
+			if err != nil {
+				return nil, err
+			}
+			if len(children) > maxGraphNodes {
+				report.Truncated = true
+			}
+			for _, child := range children {
+				add(&impactCandidate{id: child, parent: item, reason: model.ImpactReasonChild})
+			}
+
+			if !item.InInventory || !isDatabaseServer(item.Type) {
+				continue
+			}
+			databases, err := s.impactRepo.ListDatabaseIDsByServer(item.Name, item.SubscriptionID)
+			if err != nil {
+				return nil, err
+			}
+			for _, database := range databases {
+				add(&impactCandidate{id: database, parent: item, reason: model.ImpactReasonServer})
+			}
+		}
+
+		candidateIDs := make([]string, len(candidates))
+		for i, candidate := range candidates {
+			candidateIDs[i] = candidate.id
+		}
+		inventory, err := s.impactRepo.GetImpactedItems(candidateIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		frontier = nil
+		for _, candidate := range candidates {
+			item := inventory[strings.ToLower(candidate.id)]
+			if item == nil {
+				// 子网等不在清单中的CI同样会传递影响，以资源ID的最后一段作为名称
+				item = &model.ImpactedItem{
+					ResourceID: candidate.id,
+					Name:       candidate.id[strings.LastIndex(strings.TrimRight(candidate.id, "/"), "/")+1:],
+					Type:       candidate.resourceType,
+					Category:   model.ImpactCategoryOther,
+				}
+			}
+			item.Depth = level
+			item.Reason = candidate.reason
+			item.Relationship = candidate.relationship
+			item.DependsOn = candidate.parent.ResourceID
+			found[strings.ToLower(candidate.id)] = item
+			impacted = append(impacted, item)
+			frontier = append(frontier, item)
+		}
+	}
+
+	if err := s.ownerService.AttachToImpactedItems(append([]*model.ImpactedItem{root}, impacted...)); err != nil {
+		log.Printf("获取受影响CI所有者信息错误: %v", err)
+	}
+
+	owners := make(map[string]*model.ImpactedOwner)
+	for _, item := range impacted {
+		switch item.Category {
+		case model.ImpactCategoryVM:
+			report.VMs = append(report.VMs, item)
+		case model.ImpactCategoryDatabase:
+			report.Databases = append(report.Databases, item)
+		default:
+			report.Others = append(report.Others, item)
+		}
+
+		if item.Owner == "" {
+			continue
+		}
+		owner := owners[strings.ToLower(item.Owner)]
+		if owner == nil {
+			owner = &model.ImpactedOwner{Owner: item.Owner, OwnerInfo: item.OwnerInfo}
+			owners[strings.ToLower(item.Owner)] = owner
+			report.Owners = append(report.Owners, owner)
+		}
+		owner.ItemCount++
+		switch item.Category {
+		case model.ImpactCategoryVM:
+			owner.VMCount++
+		case model.ImpactCategoryDatabase:
+			owner.DatabaseCount++
+		}
+	}
+	sort.SliceStable(report.Owners, func(i, j int) bool {
+		return report.Owners[i].ItemCount > report.Owners[j].ItemCount
+	})
+
+	report.Summary = model.ImpactSummary{
+		Total:     len(impacted),
+		VMs:       len(report.VMs),
+		Databases: len(report.Databases),
+		Others:    len(report.Others),
+		Owners:    len(report.Owners),
+	}
+	return report, nil
+}
+
+// isDatabaseServer 资源类型是否为数据库服务器，如 Microsoft.Sql/servers、Microsoft.DBforMySQL/flexibleServers
+func isDatabaseServer(resourceType string) bool {
+	resourceType = strings.ToLower(resourceType)
+	return strings.HasSuffix(resourceType, "/servers") || strings.HasSuffix(resourceType, "/flexibleservers")
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"CMDB/ciclass"
+	"CMDB/model"
+	"CMDB/repository"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// 手动CI字段的最大长度，与resources表及标签表的列一致
+const (
+	maxManualCIFieldLength  = 255
+	maxManualCIStatusLength = 50
+)
+
+// reservedManualCINames 与资源视图路径 /api/resources/{id}/{view} 的后缀同名，不能用作CI名称
+var reservedManualCINames = []string{"history", "relationships", "graph", "impact"}
+
+// ManualCIService 手动维护的CI管理服务
+// 手动CI与同步得到的资源一起保存在资源清单中，平台和来源均为manual，资源类型为其类名，ID为 /manual/{类名}/{名称}
+type ManualCIService struct {
+	registry     *ciclass.Registry
+	resourceRepo *repository.ResourceRepository
+}
+
+// NewManualCIService 创建新的手动CI管理服务
+func NewManualCIService(registry *ciclass.Registry, resourceRepo *repository.ResourceRepository) *ManualCIService {
+	return &ManualCIService{registry: registry, resourceRepo: resourceRepo}
+}
+
+// Classes 返回全部CI类定义
+func (s *ManualCIService) Classes() []model.CIClass {
+	return s.registry.Classes()
+}
+
+// ListManualCIs 按条件分页列出手动CI，条件中的平台和来源被忽略
+func (s *ManualCIService) ListManualCIs(filter model.ResourceFilter) (*model.ResourcePage, error) {
+	filter.Provider = model.ProviderManual
+	filter.Source = model.ResourceSourceManual
+	return s.resourceRepo.ListResources(filter)
+}
+
+// ListManualCIKeys 列出满足条件的手动CI上出现过的全部标签键和属性键，条件与ListManualCIs相同
+func (s *ManualCIService) ListManualCIKeys(filter model.ResourceFilter) ([]string, []string, error) {
+	filter.Provider = model.ProviderManual
+	filter.Source = model.ResourceSourceManual
+	return s.resourceRepo.ListResourceKeys(filter)
+}
+
+// GetManualCI 获取未删除的手动CI，不存在或不是手动CI时返回nil
+func (s *ManualCIService) GetManualCI(id string) (*model.Resource, error) {
+	resource, err := s.resourceRepo.GetResourceByID(id)
+	if err != nil || resource == nil {
+		return nil, err
+	}
+	if resource.Source != model.ResourceSourceManual || resource.DeletedAt != nil {
+		return nil, nil
+	}
+	return resource, nil
+}
+
+// CreateManualCI 创建手动CI，同类中名称已被未删除的CI使用时失败；已删除的同名CI会被恢复
+func (s *ManualCIService) CreateManualCI(req *model.ManualCIRequest) (*model.Resource, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: 必须指定name", ErrInvalidRequest)
+	}
+	if strings.Contains(name, "/") {
+		return nil, fmt.Errorf("%w: name不能包含/", ErrInvalidRequest)
+	}
+	for _, reserved := range reservedManualCINames {
+		if strings.EqualFold(name, reserved) {
+			return nil, fmt.Errorf("%w: name不能为保留名称 %s", ErrInvalidRequest, reserved)
+		}
+	}
+	if req.Class == "" {
+		return nil, fmt.Errorf("%w: 必须指定class", ErrInvalidRequest)
+	}
+	if utf8.RuneCountInString(req.Class) > model.MaxCIClassNameLength {
+		return nil, fmt.Errorf("%w: class超过%d个字符", ErrInvalidRequest, model.MaxCIClassNameLength)
+	}
+	if !s.registry.Has(req.Class) {
+		return nil, fmt.Errorf("%w: 未定义的CI类 %s", ErrInvalidRequest, req.Class)
+	}
+
+	id := "/" + model.ProviderManual + "/" + req.Class + "/" + name
+	if utf8.RuneCountInString(id) > model.MaxResourceIDLength {
+		return nil, fmt.Errorf("%w: name过长，CI的资源ID %s 超过%d个字符", ErrInvalidRequest, id, model.MaxResourceIDLength)
+	}
+	existing, err := s.resourceRepo.GetResourceByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.DeletedAt == nil {
+		return nil, fmt.Errorf("%w: CI类 %s 中已存在名为 %s 的CI", ErrInvalidRequest, req.Class, name)
+	}
+
+	resource := &model.Resource{
+		Provider:     model.ProviderManual,
+		ResourceID:   id,
+		Name:         name,
+		ResourceType: req.Class,
+	}
+	if err := s.save(resource, req); err != nil {
+		return nil, err
+	}
+	return s.resourceRepo.GetResourceByID(id)
+}
+
+// UpdateManualCI 更新手动CI的位置、所有者、状态、属性和标签，类和名称不能修改
+// CI不存在或不是手动CI时返回nil
+func (s *ManualCIService) UpdateManualCI(id string, req *model.ManualCIRequest) (*model.Resource, error) {
+	existing, err := s.GetManualCI(id)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+	if req.Class != "" && req.Class != existing.ResourceType {
+		return nil, fmt.Errorf("%w: 不能修改CI的类", ErrInvalidRequest)
+	}
+	if name := strings.TrimSpace(req.Name); name != "" && name != existing.Name {
+		return nil, fmt.Errorf("%w: 不能修改CI的名称，请删除后重新创建", ErrInvalidRequest)
+	}
+
+	resource := &model.Resource{
+		Provider:     model.ProviderManual,
+		ResourceID:   existing.ResourceID,
+		Name:         existing.Name,
+		ResourceType: existing.ResourceType,
+	}
+	if err := s.save(resource, req); err != nil {
+		return nil, err
+	}
+	return s.resourceRepo.GetResourceByID(existing.ResourceID)
+}
+
+// DeleteManualCI 将手动CI标记为已删除，之后与同步删除的资源一样在保留期后永久删除
+// CI不存在或不是手动CI时返回false
+func (s *ManualCIService) DeleteManualCI(id string) (bool, error) {
+	existing, err := s.GetManualCI(id)
+	if err != nil || existing == nil {
+		return false, err
+	}
+	return s.resourceRepo.MarkResourceDeleted(existing.ResourceID)
+}
+
+// save 按类定义校验请求中的属性和标签，填充资源后保存，状态默认为active
+func (s *ManualCIService) save(resource *model.Resource, req *model.ManualCIRequest) error {
+	if err := s.registry.Validate(resource.ResourceType, req.Attributes, req.Tags); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+
+	if err := checkManualCILengths(req); err != nil {
+		return err
+	}
+
+	resource.Source = model.ResourceSourceManual
+	resource.Location = strings.TrimSpace(req.Location)
+	resource.Owner = strings.TrimSpace(req.Owner)
+	if resource.Owner != "" {
+		resource.OwnerSource = model.OwnerSourceManual
+	}
+	resource.Status = strings.TrimSpace(req.Status)
+	if resource.Status == "" {
+		resource.Status = "active"
+	}
+	resource.Attributes = req.Attributes
+	resource.Tags = req.Tags
+	if resource.Tags == nil {
+		resource.Tags = map[string]string{}
+	}
+	return s.resourceRepo.SaveResource(resource, 0)
+}
+
+// checkManualCILengths 检查位置、所有者、状态和标签不超过数据库列的长度
+func checkManualCILengths(req *model.ManualCIRequest) error {
+	fields := []struct {
+		name  string
+		value string
+		max   int
+	}{
+		{"location", strings.TrimSpace(req.Location), maxManualCIFieldLength},
+		{"owner", strings.TrimSpace(req.Owner), maxManualCIFieldLength},
+		{"status", strings.TrimSpace(req.Status), maxManualCIStatusLength},
+	}
+	for _, field := range fields {
+		if utf8.RuneCountInString(field.value) > field.max {
+			return fmt.Errorf("%w: %s超过%d个字符", ErrInvalidRequest, field.name, field.max)
+		}
+	}
+	for key, value := range req.Tags {
+		if utf8.RuneCountInString(key) > maxManualCIFieldLength || utf8.RuneCountInString(value) > maxManualCIFieldLength {
+			return fmt.Errorf("%w: 标签 %s 的键或值超过%d个字符", ErrInvalidRequest, key, maxManualCIFieldLength)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"CMDB/model"
+	"CMDB/provider"
+	"CMDB/repository"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// OwnerService 所有者目录服务，将资源上的所有者解析为目录中的用户或组并缓存
+type OwnerService struct {
+	registry  *provider.Registry
+	ownerRepo *repository.OwnerRepository
+	// ttl 解析结果的缓存时长
+	ttl time.Duration
+}
+
+// NewOwnerService 创建新的所有者目录服务
+func NewOwnerService(registry *provider.Registry, ownerRepo *repository.OwnerRepository, ttl time.Duration) *OwnerService {
+	return &OwnerService{
+		registry:  registry,
+		ownerRepo: ownerRepo,
+		ttl:       ttl,
+	}
+}
+
+// RefreshOwners 解析资源上出现的、尚未缓存或缓存已过期的所有者
+// 依次询问实现了provider.OwnerResolver的Provider，找到即停止；全部查询成功但都未找到时记为not_found
+// 查询出错时保留原有的缓存结果，下次同步时重试；没有Provider支持解析时不做任何操作
+func (s *OwnerService) RefreshOwners() error {
+	resolvers := s.resolvers()
+	if len(resolvers) == 0 {
+		return nil
+	}
+
+	values, err := s.ownerRepo.ListDistinctOwners()
+	if err != nil {
+		return err
+	}
+	cached, err := s.ownerRepo.GetOwners(values)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var resolved, failed int
+	for _, value := range values {
+		if owner, ok := cached[strings.ToLower(value)]; ok && owner.ExpiresAt.After(now) {
+			continue
+		}
+		owner, err := resolveOwner(resolvers, value)
+		if err != nil {
+			log.Printf("解析所有者 %s 失败: %v", value, err)
+			failed++
+			continue
+		}
+		owner.Value = value
+		owner.ResolvedAt = now
+		owner.ExpiresAt = now.Add(s.ttl)
+		if err := s.ownerRepo.SaveOwner(owner); err != nil {
+			return fmt.Errorf("保存所有者 %s 失败: %v", value, err)
+		}
+		resolved++
+	}
+
+	log.Printf("所有者解析完成: 共 %d 个, 本次解析 %d 个, 失败 %d 个", len(values), resolved, failed)
+	return nil
+}
+
+// resolvers 返回支持解析所有者的Provider
+func (s *OwnerService) resolvers() []provider.OwnerResolver {
+	var resolvers []provider.OwnerResolver
+	for _, p := range s.registry.Providers() {
+		if resolver, ok := p.(provider.OwnerResolver); ok {
+			resolvers = append(resolvers, resolver)
+		}
+	}
+	return resolvers
+}
+
+// resolveOwner 依次用各Provider解析所有者，优先采用在目录中找到的结果
+// 只有全部Provider都未出错时才能断定所有者不存在或无法解析，否则返回最后一个错误
+func resolveOwner(resolvers []provider.OwnerResolver, value string) (*model.OwnerInfo, error) {
+	var lastErr error
+	unresolvable := true
+	for _, resolver := range resolvers {
+		owner, err := resolver.ResolveOwner(value)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if owner == nil {
+			unresolvable = false
+			continue
+		}
+		if owner.Status != model.OwnerStatusUnresolvable {
+			return owner, nil
+		}
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	if unresolvable {
+		return &model.OwnerInfo{Status: model.OwnerStatusUnresolvable}, nil
+	}
+	return &model.OwnerInfo{Status: model.OwnerStatusNotFound}, nil
+}
+
+// ListOwners 列出已解析的所有者，status为空时列出全部
+func (s *OwnerService) ListOwners(status string) ([]*model.OwnerInfo, error) {
+	switch status {
+	case "", model.OwnerStatusActive, model.OwnerStatusDisabled, model.OwnerStatusNotFound, model.OwnerStatusUnresolvable:
+	default:
+		return nil, fmt.Errorf("%w: 无效的status %s", ErrInvalidRequest, status)
+	}
+	return s.ownerRepo.ListOwners(status)
+}
+
+// lookup 批量获取所有者的缓存结果，以小写的所有者取值为键
+func (s *OwnerService) lookup(values []string) (map[string]*model.OwnerInfo, error) {
+	seen := make(map[string]bool)
+	var distinct []string
+	for _, value := range values {
+		if value != "" && !seen[value] {
+			seen[value] = true
+			distinct = append(distinct, value)
+		}
+	}
+	if len(distinct) == 0 {
+		return nil, nil
+	}
+	return s.ownerRepo.GetOwners(distinct)
+}
+
+// AttachToResources 为资源填充所有者的目录信息，尚未解析的所有者不填充
+func (s *OwnerService) AttachToResources(resources []*model.Resource) error {
+	values := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		values = append(values, resource.Owner)
+	}
+	owners, err := s.lookup(values)
+	if err != nil {
+		return err
+	}
+	for _, resource := range resources {
+		resource.OwnerInfo = owners[strings.ToLower(resource.Owner)]
+	}
+	return nil
+}
+
+// AttachToVMs 为虚拟机填充所有者的目录信息
+func (s *OwnerService) AttachToVMs(vms []*model.VM) error {
+	values := make([]string, 0, len(vms))
+	for _, vm := range vms {
+		values = append(values, vm.Owner)
+	}
+	owners, err := s.lookup(values)
+	if err != nil {
+		return err
+	}
+	for _, vm := range vms {
+		vm.OwnerInfo = owners[strings.ToLower(vm.Owner)]
+	}
+	return nil
+}
+
+// AttachToDatabases 为数据库填充所有者的目录信息
+func (s *OwnerService) AttachToDatabases(databases []*model.Database) error {
+	values := make([]string, 0, len(databases))
+	for _, database := range databases {
+		values = append(values, database.Owner)
+	}
+	owners, err := s.lookup(values)
+	if err != nil {
+		return err
+	}
+	for _, database := range databases {
+		database.OwnerInfo = owners[strings.ToLower(database.Owner)]
+	}
+	return nil
+}
+
+// AttachToImpactedItems 为影响分析中受影响的CI填充所有者的目录信息
+func (s *OwnerService) AttachToImpactedItems(items []*model.ImpactedItem) error {
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		values = append(values, item.Owner)
+	}
+	owners, err := s.lookup(values)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		item.OwnerInfo = owners[strings.ToLower(item.Owner)]
+	}
+	return nil
+}
+
+// AttachToApplications 为应用填充所有者的目录信息
+func (s *OwnerService) AttachToApplications(applications []*model.Application) error {
+	values := make([]string, 0, len(applications))
+	for _, application := range applications {
+		values = append(values, application.Owner)
+	}
+	owners, err := s.lookup(values)
+	if err != nil {
+		return err
+	}
+	for _, application := range applications {
+		application.OwnerInfo = owners[strings.ToLower(application.Owner)]
+	}
+	return nil
+}
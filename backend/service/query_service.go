@@ -27,7 +27,7 @@ func NewQueryService(
 
 // GetAllVMs 获取所有虚拟机
 func (s *QueryService) GetAllVMs() ([]*model.VM, error) {
-	return s.vmRepo.ListVMs()
+	return s.vmRepo.ListVMs(false)
 }
 
 // GetVMByID 根据ID获取虚拟机
@@ -42,10 +42,10 @@ func (s *QueryService) GetVMByResourceID(resourceID string) (*model.VM, error) {
 
 // GetAllDatabases 获取所有数据库
 func (s *QueryService) GetAllDatabases() ([]*model.Database, error) {
-	return s.databaseRepo.GetAllDatabases()
+	return s.databaseRepo.GetAllDatabases(false)
 }
 
 // GetAllResources 获取所有资源
 func (s *QueryService) GetAllResources() ([]*model.Resource, error) {
-	return s.resourceRepo.GetAllResources()
+	return s.resourceRepo.GetAllResources(false)
 }
\ No newline at end of file
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"CMDB/model"
+	"CMDB/repository"
+	"fmt"
+	"strings"
+)
+
+// 关系图遍历的限制
+const (
+	// MaxGraphDepth 最大遍历深度
+	MaxGraphDepth = 5
+	// maxGraphNodes 关系图的最大节点数，超过后停止扩展并标记为截断
+	maxGraphNodes = 1000
+)
+
+// RelationshipService 资源关系服务，查询资源的直接关系并遍历关系图
+type RelationshipService struct {
+	relationshipRepo *repository.RelationshipRepository
+}
+
+// NewRelationshipService 创建新的资源关系服务
+func NewRelationshipService(relationshipRepo *repository.RelationshipRepository) *RelationshipService {
+	return &RelationshipService{relationshipRepo: relationshipRepo}
+}
+
+// validateRelationshipFilter 校验关系方向和类型，方向为空时设为both
+func validateRelationshipFilter(filter *model.RelationshipFilter) error {
+	switch filter.Direction {
+	case "":
+		filter.Direction = model.DirectionBoth
+	case model.DirectionOutgoing, model.DirectionIncoming, model.DirectionBoth:
+	default:
+		return fmt.Errorf("%w: 无效的direction %s", ErrInvalidRequest, filter.Direction)
+	}
+	for _, relationshipType := range filter.Types {
+		if !model.RelationshipTypes[relationshipType] {
+			return fmt.Errorf("%w: 无效的关系类型 %s", ErrInvalidRequest, relationshipType)
+		}
+	}
+	return nil
+}
+
+// ListRelationships 查询资源的直接关系
+func (s *RelationshipService) ListRelationships(resourceID string, filter model.RelationshipFilter) ([]*model.Relationship, error) {
+	if err := validateRelationshipFilter(&filter); err != nil {
+		return nil, err
+	}
+	relationships, err := s.relationshipRepo.ListRelationships([]string{resourceID}, filter)
+	if err != nil {
+		return nil, err
+	}
+	if relationships == nil {
+		relationships = []*model.Relationship{}
+	}
+	return relationships, nil
+}
+
+// Traverse 从资源出发按广度优先遍历关系图，最多depth层
+// direction为outgoing时沿依赖方向（来源到目标）遍历，incoming时沿被依赖方向遍历，both时两个方向都遍历
+// 节点数达到上限时停止扩展，结果标记为截断；不在资源清单中的节点以资源ID的最后一段作为名称
+func (s *RelationshipService) Traverse(resourceID string, depth int, filter model.RelationshipFilter) (*model.ResourceGraph, error) {
+	if depth < 1 || depth > MaxGraphDepth {
+		return nil, fmt.Errorf("%w: depth必须是1到%d之间的整数", ErrInvalidRequest, MaxGraphDepth)
+	}
+	if err := validateRelationshipFilter(&filter); err != nil {
+		return nil, err
+	}
+	graph, err := s.traverse([]string{resourceID}, depth, filter)
+	if err != nil {
+		return nil, err
+	}
+	graph.Root = resourceID
+	return graph, nil
+}
+
+// Neighborhood 返回给定的一组资源以及与它们直接相连的节点和关系，用于按范围绘制拓扑
+func (s *RelationshipService) Neighborhood(resourceIDs []string, filter model.RelationshipFilter) (*model.ResourceGraph, error) {
+	if err := validateRelationshipFilter(&filter); err != nil {
+		return nil, err
+	}
+	return s.traverse(resourceIDs, 1, filter)
+}
+
+// traverse 从一组起点按广度优先遍历关系图，起点的深度为0
+func (s *RelationshipService) traverse(roots []string, depth int, filter model.RelationshipFilter) (*model.ResourceGraph, error) {
+	graph := &model.ResourceGraph{Depth: depth, Edges: []*model.Relationship{}}
+	nodes := make(map[string]*model.GraphNode)
+	var order []*model.GraphNode
+	var frontier []string
+	for _, root := range roots {
+		if _, ok := nodes[strings.ToLower(root)]; ok {
+			continue
+		}
+		node := &model.GraphNode{ID: root}
+		nodes[strings.ToLower(root)] = node
+		order = append(order, node)
+		frontier = append(frontier, root)
+	}
+	edges := make(map[string]bool)
+
+	for level := 1; level <= depth && len(frontier) > 0; level++ {
+		relationships, err := s.relationshipRepo.ListRelationships(frontier, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		var next []string
+		for _, relationship := range relationships {
+			key := strings.ToLower(relationship.SourceID + "|" + relationship.TargetID + "|" + relationship.Type)
+			if edges[key] {
+				continue
+			}
+
+			// 两端中尚未访问的一端为新节点，两端都已访问时只补充关系
+			for _, end := range []struct{ id, resourceType string }{
+				{relationship.SourceID, relationship.SourceType},
+				{relationship.TargetID, relationship.TargetType},
+			} {
+				node, ok := nodes[strings.ToLower(end.id)]
+				if !ok {
+					if len(nodes) >= maxGraphNodes {
+						graph.Truncated = true
+						continue
+					}
+					node = &model.GraphNode{ID: end.id, Depth: level}
+					nodes[strings.ToLower(end.id)] = node
+					order = append(order, node)
+					next = append(next, end.id)
+				}
+				if node.Type == "" {
+					node.Type = end.resourceType
+				}
+			}
+			if nodes[strings.ToLower(relationship.SourceID)] == nil || nodes[strings.ToLower(relationship.TargetID)] == nil {
+				continue
+			}
+			edges[key] = true
+			graph.Edges = append(graph.Edges, relationship)
+		}
+		frontier = next
+	}
+
+	if err := s.fillNodes(nodes); err != nil {
+		return nil, err
+	}
+	graph.Nodes = order
+	return graph, nil
+}
+
+// fillNodes 用资源清单中的名称和类型填充节点，不在清单中的节点以资源ID的最后一段作为名称
+func (s *RelationshipService) fillNodes(nodes map[string]*model.GraphNode) error {
+	ids := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		ids = append(ids, node.ID)
+	}
+	inventory, err := s.relationshipRepo.GetNodes(ids)
+	if err != nil {
+		return err
+	}
+	for key, node := range nodes {
+		if resource, ok := inventory[key]; ok {
+			node.Name, node.Type, node.InInventory = resource.Name, resource.Type, true
+			continue
+		}
+		node.Name = node.ID[strings.LastIndex(strings.TrimRight(node.ID, "/"), "/")+1:]
+	}
+	return nil
+}
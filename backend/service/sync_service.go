@@ -1,49 +1,508 @@
 package service
 
 import (
-	"CMDB/azure"
+	"CMDB/model"
+	"CMDB/provider"
 	"CMDB/repository"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
 	"time"
 )
 
+// creatorRetryInterval 在活动日志中未找到资源创建者后，再次查找前的间隔
+// 未找到多是因为资源创建于日志保留期之前，每次同步都重新拉取整个资源组的活动日志代价过高
+const creatorRetryInterval = 7 * 24 * time.Hour
+
+// ScopeSyncResult 单个同步范围（如Azure订阅）的同步结果
+type ScopeSyncResult struct {
+	Provider          string    `json:"provider"`
+	Account           string    `json:"account"`
+	ScopeID           string    `json:"scope_id"`
+	ScopeName         string    `json:"scope_name,omitempty"`
+	TenantID          string    `json:"tenant_id,omitempty"`
+	Success           bool      `json:"success"`
+	ResourceCount     int       `json:"resource_count"`
+	VMCount           int       `json:"vm_count"`
+	DatabaseCount     int       `json:"database_count"`
+	RelationshipCount int       `json:"relationship_count,omitempty"`
+	DeletedCount      int       `json:"deleted_count"`
+	Errors            []string  `json:"errors,omitempty"`
+	StartTime         time.Time `json:"start_time"`
+	EndTime           time.Time `json:"end_time"`
+}
+
+// ProviderDiagnostics Provider的诊断信息
+type ProviderDiagnostics struct {
+	Provider string      `json:"provider"`
+	Account  string      `json:"account"`
+	Details  interface{} `json:"details"`
+}
+
 // SyncService 资源同步服务
 type SyncService struct {
-	azureService     *azure.AzureService
-	resourceRepo     *repository.ResourceRepository
-	vmRepo           *repository.VMRepository
-	databaseRepo     *repository.DatabaseRepository
+	registry     *provider.Registry
+	resourceRepo *repository.ResourceRepository
+	vmRepo       *repository.VMRepository
+	databaseRepo *repository.DatabaseRepository
+	syncTaskRepo *repository.SyncTaskRepository
+	// relationshipRepo 保存同步时发现的资源关系
+	relationshipRepo *repository.RelationshipRepository
+	// ownerService 同步完成后解析资源所有者，为nil时不解析
+	ownerService *OwnerService
+	// complianceService 同步完成后评估标签合规，为nil时不评估
+	complianceService *ComplianceService
+	// deletedRetention 已删除记录的保留时长，为0时不清理
+	deletedRetention time.Duration
+
+	mu          sync.RWMutex
+	lastResults map[string][]ScopeSyncResult // provider/account -> 各范围的结果
+}
+
+// scopeSyncStep 针对单个同步范围执行的同步步骤，每个步骤对应一种同步任务类型
+type scopeSyncStep struct {
+	taskType string
+	// sync 同步单个范围，返回发现的条目数，taskID用于关联变更历史
+	sync func(p provider.Provider, scope provider.Scope, taskID int64, result *ScopeSyncResult) (int, error)
+	// supports 判断Provider是否支持该步骤，为nil时所有Provider都支持
+	supports func(p provider.Provider) bool
 }
 
 // NewSyncService 创建新的同步服务
 func NewSyncService(
-	azureService *azure.AzureService,
+	registry *provider.Registry,
 	resourceRepo *repository.ResourceRepository,
 	vmRepo *repository.VMRepository,
 	databaseRepo *repository.DatabaseRepository,
+	syncTaskRepo *repository.SyncTaskRepository,
+	relationshipRepo *repository.RelationshipRepository,
+	ownerService *OwnerService,
+	complianceService *ComplianceService,
+	deletedRetention time.Duration,
 ) *SyncService {
 	return &SyncService{
-		azureService: azureService,
-		resourceRepo: resourceRepo,
-		vmRepo:       vmRepo,
-		databaseRepo: databaseRepo,
+		registry:          registry,
+		resourceRepo:      resourceRepo,
+		vmRepo:            vmRepo,
+		databaseRepo:      databaseRepo,
+		syncTaskRepo:      syncTaskRepo,
+		relationshipRepo:  relationshipRepo,
+		ownerService:      ownerService,
+		complianceService: complianceService,
+		deletedRetention:  deletedRetention,
+		lastResults:       make(map[string][]ScopeSyncResult),
 	}
 }
 
-// SyncAllResources 同步所有资源
-func (s *SyncService) SyncAllResources() error {
-	// 使用Azure服务同步所有资源
-	return s.azureService.SyncAllResources()
+// SyncAllResources 同步所有资源，trigger为触发方式（定时或手动）
+// 同步完成后清理超过保留期限的已删除记录，解析新出现或缓存过期的所有者，并按标签策略重新评估资源合规情况
+func (s *SyncService) SyncAllResources(trigger string) error {
+	err := s.syncProviders(trigger,
+		scopeSyncStep{taskType: model.SyncTaskTypeResources, sync: s.syncResources},
+		scopeSyncStep{taskType: model.SyncTaskTypeVMs, sync: s.syncVirtualMachines},
+		scopeSyncStep{taskType: model.SyncTaskTypeDatabases, sync: s.syncDatabases},
+		scopeSyncStep{taskType: model.SyncTaskTypeRelationships, sync: s.syncRelationships, supports: func(p provider.Provider) bool {
+			_, ok := p.(provider.RelationshipDiscoverer)
+			return ok
+		}},
+	)
+	if purgeErr := s.PurgeDeleted(); purgeErr != nil {
+		log.Printf("清理已删除记录失败: %v", purgeErr)
+	}
+	if s.ownerService != nil {
+		if ownerErr := s.ownerService.RefreshOwners(); ownerErr != nil {
+			log.Printf("解析资源所有者失败: %v", ownerErr)
+		}
+	}
+	if s.complianceService != nil {
+		if evalErr := s.complianceService.Evaluate(); evalErr != nil {
+			log.Printf("标签合规评估失败: %v", evalErr)
+		}
+	}
+	return err
 }
 
 // SyncVirtualMachines 同步虚拟机资源
-func (s *SyncService) SyncVirtualMachines() error {
-	// 使用Azure服务同步虚拟机资源
-	return s.azureService.SyncVirtualMachines()
+func (s *SyncService) SyncVirtualMachines(trigger string) error {
+	return s.syncProviders(trigger, scopeSyncStep{taskType: model.SyncTaskTypeVMs, sync: s.syncVirtualMachines})
+}
+
+// syncProviders 依次同步每个Provider，单个Provider失败不影响其他Provider
+func (s *SyncService) syncProviders(trigger string, steps ...scopeSyncStep) error {
+	var errs []error
+	for _, p := range s.registry.Providers() {
+		if err := s.syncProvider(p, trigger, steps...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// providerTask 单个Provider账号某种资源的同步任务在各范围上的累计结果
+type providerTask struct {
+	id           int64
+	itemCount    int
+	deletedCount int
+	errors       []string
+}
+
+// syncProvider 对Provider的每个同步范围依次执行同步步骤，每个步骤记录一条同步任务
+// 单个范围或单个步骤失败只记录在该范围的同步结果和对应任务中，不会中断其他范围；列举失败的范围（Err不为空）直接记为失败
+// 步骤成功后，该范围内本次未同步到的记录会被标记为已删除；失败的步骤不做标记，避免误删
+// 实现了provider.ScopeCacher的Provider可以在同一范围的各步骤之间共享清单查询结果
+func (s *SyncService) syncProvider(p provider.Provider, trigger string, steps ...scopeSyncStep) error {
+	steps = supportedSteps(p, steps)
+	if len(steps) == 0 {
+		return nil
+	}
+	tasks := make([]*providerTask, len(steps))
+	for i, step := range steps {
+		tasks[i] = &providerTask{}
+		id, err := s.syncTaskRepo.StartTask(p.Name(), p.Account(), step.taskType, trigger)
+		if err != nil {
+			log.Printf("创建同步任务失败 (%s/%s %s): %v", p.Name(), p.Account(), step.taskType, err)
+			continue
+		}
+		tasks[i].id = id
+	}
+	defer s.finishTasks(tasks)
+
+	scopes, err := p.Scopes()
+	if err != nil {
+		for _, task := range tasks {
+			task.errors = append(task.errors, err.Error())
+		}
+		return fmt.Errorf("%s账号 %s: %v", p.Name(), p.Account(), err)
+	}
+
+	results := make([]ScopeSyncResult, 0, len(scopes))
+	failed := 0
+	for _, scope := range scopes {
+		result := ScopeSyncResult{
+			Provider:  p.Name(),
+			Account:   p.Account(),
+			ScopeID:   scope.ID,
+			ScopeName: scope.Name,
+			TenantID:  scope.TenantID,
+			StartTime: time.Now(),
+		}
+
+		if scope.Err != nil {
+			log.Printf("%s账号 %s 范围 %s 列举失败: %v", p.Name(), p.Account(), scope.ID, scope.Err)
+			result.Errors = append(result.Errors, scope.Err.Error())
+			for _, task := range tasks {
+				task.errors = append(task.errors, fmt.Sprintf("%s: %v", scope.ID, scope.Err))
+			}
+		} else {
+			s.syncScope(p, scope, steps, tasks, &result)
+		}
+
+		result.Success = len(result.Errors) == 0
+		result.EndTime = time.Now()
+		if !result.Success {
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	s.mu.Lock()
+	s.lastResults[p.Name()+"/"+p.Account()] = results
+	s.mu.Unlock()
+
+	if failed > 0 {
+		return fmt.Errorf("%s账号 %s: %d/%d个范围同步失败", p.Name(), p.Account(), failed, len(scopes))
+	}
+	return nil
+}
+
+// syncScope 在单个范围上依次执行同步步骤，成功的步骤之后标记该范围内已删除的记录
+func (s *SyncService) syncScope(p provider.Provider, scope provider.Scope, steps []scopeSyncStep, tasks []*providerTask, result *ScopeSyncResult) {
+	if cacher, ok := p.(provider.ScopeCacher); ok {
+		cacher.BeginScope(scope)
+		defer cacher.EndScope(scope)
+	}
+	for i, step := range steps {
+		stepStart := time.Now()
+		count, err := step.sync(p, scope, tasks[i].id, result)
+		tasks[i].itemCount += count
+		if err == nil {
+			var deleted int
+			deleted, err = s.markDeleted(step.taskType, p, scope, stepStart, tasks[i].id)
+			tasks[i].deletedCount += deleted
+			result.DeletedCount += deleted
+		}
+		if err != nil {
+			log.Printf("%s账号 %s 范围 %s 同步失败: %v", p.Name(), p.Account(), scope.ID, err)
+			result.Errors = append(result.Errors, err.Error())
+			tasks[i].errors = append(tasks[i].errors, fmt.Sprintf("%s: %v", scope.ID, err))
+		}
+	}
+}
+
+// finishTasks 记录各同步任务的条目数和错误
+func (s *SyncService) finishTasks(tasks []*providerTask) {
+	for _, task := range tasks {
+		if task.id == 0 {
+			continue
+		}
+		if err := s.syncTaskRepo.FinishTask(task.id, task.itemCount, task.deletedCount, strings.Join(task.errors, "\n")); err != nil {
+			log.Printf("更新同步任务 %d 失败: %v", task.id, err)
+		}
+	}
+}
+
+// markDeleted 将范围内syncedBefore之后未再同步到的记录标记为已删除，返回标记的数量
+func (s *SyncService) markDeleted(taskType string, p provider.Provider, scope provider.Scope, syncedBefore time.Time, taskID int64) (int, error) {
+	// last_sync_at按秒存储，截断到秒以免把本次同步的记录误判为未同步
+	syncedBefore = syncedBefore.Truncate(time.Second)
+
+	var deleted int64
+	var err error
+	switch taskType {
+	case model.SyncTaskTypeResources:
+		deleted, err = s.resourceRepo.MarkDeleted(p.Name(), scope.ID, syncedBefore, taskID)
+	case model.SyncTaskTypeVMs:
+		deleted, err = s.vmRepo.MarkDeleted(p.Name(), scope.ID, syncedBefore, taskID)
+	case model.SyncTaskTypeDatabases:
+		deleted, err = s.databaseRepo.MarkDeleted(p.Name(), scope.ID, syncedBefore, taskID)
+	case model.SyncTaskTypeRelationships:
+		// 关系由资源推导而来，不保留历史，未再发现的直接删除
+		deleted, err = s.relationshipRepo.DeleteStale(p.Name(), scope.ID, syncedBefore)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("标记已删除的%s失败: %v", taskType, err)
+	}
+	if deleted > 0 {
+		log.Printf("%s账号 %s 范围 %s: %d个%s已在平台删除", p.Name(), p.Account(), scope.ID, deleted, taskType)
+	}
+	return int(deleted), nil
+}
+
+// PurgeDeleted 永久删除超过保留期限的已删除记录
+// 虚拟机和数据库引用资源记录，因此先清理虚拟机和数据库再清理资源
+func (s *SyncService) PurgeDeleted() error {
+	if s.deletedRetention <= 0 {
+		return nil
+	}
+	deletedBefore := time.Now().Add(-s.deletedRetention)
+
+	vms, err := s.vmRepo.PurgeDeleted(deletedBefore)
+	if err != nil {
+		return err
+	}
+	databases, err := s.databaseRepo.PurgeDeleted(deletedBefore)
+	if err != nil {
+		return err
+	}
+	resources, err := s.resourceRepo.PurgeDeleted(deletedBefore)
+	if err != nil {
+		return err
+	}
+
+	if vms+databases+resources > 0 {
+		log.Printf("已清理超过保留期限的记录: 虚拟机 %d, 数据库 %d, 资源 %d", vms, databases, resources)
+	}
+	return nil
 }
 
-// GetLastSyncTime 获取最后同步时间
+// syncResources 同步单个范围的通用资源
+func (s *SyncService) syncResources(p provider.Provider, scope provider.Scope, taskID int64, result *ScopeSyncResult) (int, error) {
+	resources, err := p.DiscoverResources(scope)
+	if err != nil {
+		return 0, err
+	}
+	result.ResourceCount = len(resources)
+	notFound := s.inferOwners(p, scope, resources)
+	err = s.resourceRepo.BatchSaveResources(resources, taskID)
+	if len(notFound) > 0 {
+		// 新资源保存后才有记录，因此在保存之后再记录未找到创建者
+		if markErr := s.resourceRepo.MarkCreatorChecked(notFound, time.Now()); markErr != nil {
+			log.Printf("记录未找到创建者的资源失败: %v", markErr)
+		}
+	}
+	return len(resources), err
+}
+
+// inferOwners 确定资源的所有者及其来源，依次使用owner标签、资源或资源组上的角色分配和活动日志中的创建者
+// 活动日志只保留有限天数，之前已从中推断出创建者的资源沿用原结果；上次未找到创建者的资源在creatorRetryInterval内不再查找
+// 返回本次在活动日志中未找到创建者的资源ID，由调用方在资源保存后记录；推断失败只记录日志，不影响同步
+func (s *SyncService) inferOwners(p provider.Provider, scope provider.Scope, resources []*model.Resource) []string {
+	var missing []*model.Resource
+	for _, resource := range resources {
+		if resource.Owner != "" {
+			resource.OwnerSource = model.OwnerSourceTag
+		} else {
+			resource.OwnerSource = ""
+			missing = append(missing, resource)
+		}
+	}
+	inferrer, ok := p.(provider.OwnerInferrer)
+	if !ok || len(missing) == 0 {
+		return nil
+	}
+
+	missing = applyInferredOwners(missing, model.OwnerSourceRoleAssignment, func(resourceIDs []string) (map[string]string, error) {
+		return inferrer.OwnersFromRoleAssignments(scope, resourceIDs)
+	})
+	if len(missing) == 0 {
+		return nil
+	}
+
+	resourceIDs := make([]string, 0, len(missing))
+	for _, resource := range missing {
+		resourceIDs = append(resourceIDs, resource.ResourceID)
+	}
+	lookups, err := s.resourceRepo.GetOwnerLookups(resourceIDs)
+	if err != nil {
+		log.Printf("获取资源已保存的所有者失败: %v", err)
+	}
+
+	now := time.Now()
+	var unknown []*model.Resource
+	skipped := 0
+	for _, resource := range missing {
+		stored := lookups[resource.ResourceID]
+		if stored != nil && stored.OwnerSource == model.OwnerSourceCreator && stored.Owner != "" {
+			resource.Owner, resource.OwnerSource = stored.Owner, model.OwnerSourceCreator
+			continue
+		}
+		if stored != nil && stored.CreatorCheckedAt != nil && now.Sub(*stored.CreatorCheckedAt) < creatorRetryInterval {
+			skipped++
+			continue
+		}
+		unknown = append(unknown, resource)
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	var lookupErr error
+	unknown = applyInferredOwners(unknown, model.OwnerSourceCreator, func(resourceIDs []string) (map[string]string, error) {
+		creators, err := inferrer.CreatorsFromActivityLog(scope, resourceIDs)
+		lookupErr = err
+		return creators, err
+	})
+	log.Printf("%s账号 %s 范围 %s 所有者推断完成: 无owner标签 %d 个, 近期未找到创建者而跳过 %d 个, 仍无法确定 %d 个",
+		p.Name(), p.Account(), scope.ID, len(missing), skipped, len(unknown))
+
+	// 查询出错时部分资源组可能未查到，不记录为未找到，下次同步重试
+	if lookupErr != nil {
+		return nil
+	}
+	notFound := make([]string, 0, len(unknown))
+	for _, resource := range unknown {
+		notFound = append(notFound, resource.ResourceID)
+	}
+	return notFound
+}
+
+// applyInferredOwners 用infer推断出的所有者填充资源并记录来源，返回仍未确定所有者的资源
+// infer出错时仍使用其返回的部分结果
+func applyInferredOwners(resources []*model.Resource, source string, infer func(resourceIDs []string) (map[string]string, error)) []*model.Resource {
+	resourceIDs := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		resourceIDs = append(resourceIDs, resource.ResourceID)
+	}
+	owners, err := infer(resourceIDs)
+	if err != nil {
+		log.Printf("按%s推断资源所有者失败: %v", source, err)
+	}
+
+	var remaining []*model.Resource
+	for _, resource := range resources {
+		if owner := owners[resource.ResourceID]; owner != "" {
+			resource.Owner, resource.OwnerSource = owner, source
+		} else {
+			remaining = append(remaining, resource)
+		}
+	}
+	return remaining
+}
+
+// syncRelationships 发现并保存单个范围内资源之间的关系
+func (s *SyncService) syncRelationships(p provider.Provider, scope provider.Scope, taskID int64, result *ScopeSyncResult) (int, error) {
+	relationships, err := p.(provider.RelationshipDiscoverer).DiscoverRelationships(scope)
+	if err != nil {
+		return 0, err
+	}
+	result.RelationshipCount = len(relationships)
+	return len(relationships), s.relationshipRepo.SaveRelationships(relationships)
+}
+
+// supportedSteps 过滤出Provider支持的同步步骤
+func supportedSteps(p provider.Provider, steps []scopeSyncStep) []scopeSyncStep {
+	var supported []scopeSyncStep
+	for _, step := range steps {
+		if step.supports == nil || step.supports(p) {
+			supported = append(supported, step)
+		}
+	}
+	return supported
+}
+
+// syncVirtualMachines 同步单个范围的虚拟机
+func (s *SyncService) syncVirtualMachines(p provider.Provider, scope provider.Scope, taskID int64, result *ScopeSyncResult) (int, error) {
+	vms, err := p.DiscoverVMs(scope)
+	if err != nil {
+		return 0, err
+	}
+	result.VMCount = len(vms)
+	return len(vms), s.vmRepo.BatchSaveVMs(vms, taskID)
+}
+
+// syncDatabases 同步单个范围的数据库
+func (s *SyncService) syncDatabases(p provider.Provider, scope provider.Scope, taskID int64, result *ScopeSyncResult) (int, error) {
+	databases, err := p.DiscoverDatabases(scope)
+	if err != nil {
+		return 0, err
+	}
+	result.DatabaseCount = len(databases)
+	return len(databases), s.databaseRepo.BatchSaveDatabases(databases, taskID)
+}
+
+// GetLastSyncResults 获取最近一次同步中各范围的结果
+func (s *SyncService) GetLastSyncResults() []ScopeSyncResult {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := []ScopeSyncResult{}
+	for _, p := range s.registry.Providers() {
+		results = append(results, s.lastResults[p.Name()+"/"+p.Account()]...)
+	}
+	return results
+}
+
+// GetDiagnostics 获取支持诊断的Provider的诊断信息，name为空时返回全部
+func (s *SyncService) GetDiagnostics(name string) []ProviderDiagnostics {
+	diagnostics := []ProviderDiagnostics{}
+	for _, p := range s.registry.Providers() {
+		if name != "" && p.Name() != name {
+			continue
+		}
+		if d, ok := p.(provider.Diagnosable); ok {
+			diagnostics = append(diagnostics, ProviderDiagnostics{
+				Provider: p.Name(),
+				Account:  p.Account(),
+				Details:  d.Diagnostics(),
+			})
+		}
+	}
+	return diagnostics
+}
+
+// GetLastSyncTime 获取最近一次成功同步的时间，从未成功同步时返回零值
 func (s *SyncService) GetLastSyncTime() (time.Time, error) {
-	// 这里需要实现获取最后同步时间的逻辑
-	// 可能需要在数据库中添加一个表来记录同步状态
-	return time.Now(), nil
-}
\ No newline at end of file
+	return s.syncTaskRepo.GetLastSuccessTime()
+}
+
+// ListSyncTasks 按条件列出同步任务
+func (s *SyncService) ListSyncTasks(filter model.SyncTaskFilter) ([]*model.SyncTask, error) {
+	return s.syncTaskRepo.ListTasks(filter)
+}
+
+// GetSyncTask 根据ID获取同步任务，不存在时返回nil
+func (s *SyncService) GetSyncTask(taskID int64) (*model.SyncTask, error) {
+	return s.syncTaskRepo.GetTaskByID(taskID)
+}
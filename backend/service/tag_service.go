@@ -0,0 +1,316 @@
+package service
+
+import (
+	"CMDB/model"
+	"CMDB/provider"
+	"CMDB/repository"
+	"CMDB/search"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// maxBulkTagResources 单次批量编辑标签最多涉及的资源数
+const maxBulkTagResources = 500
+
+// ErrInvalidRequest 请求参数无效，批量编辑标签返回的此类错误均包装该错误
+var ErrInvalidRequest = errors.New("无效的请求")
+
+// TagService 标签管理服务，将标签变更写回云平台后刷新本地标签
+type TagService struct {
+	registry     *provider.Registry
+	resourceRepo *repository.ResourceRepository
+	vmRepo       *repository.VMRepository
+	databaseRepo *repository.DatabaseRepository
+	searchRepo   *repository.SearchRepository
+}
+
+// NewTagService 创建新的标签管理服务
+func NewTagService(
+	registry *provider.Registry,
+	resourceRepo *repository.ResourceRepository,
+	vmRepo *repository.VMRepository,
+	databaseRepo *repository.DatabaseRepository,
+	searchRepo *repository.SearchRepository,
+) *TagService {
+	return &TagService{
+		registry:     registry,
+		resourceRepo: resourceRepo,
+		vmRepo:       vmRepo,
+		databaseRepo: databaseRepo,
+		searchRepo:   searchRepo,
+	}
+}
+
+// BulkUpdateTags 批量编辑标签：选出资源并计算修改后的标签，DryRun时只返回预览
+// 否则逐个资源通过Provider写回平台，成功后以平台返回的标签刷新本地的资源、虚拟机和数据库记录
+// 单个资源失败只记录在该资源的结果中；请求本身无效时返回包装ErrInvalidRequest的错误
+func (s *TagService) BulkUpdateTags(req model.BulkTagRequest) (*model.BulkTagResult, error) {
+	if err := validateBulkTagRequest(req); err != nil {
+		return nil, err
+	}
+
+	resourceIDs, err := s.selectResourceIDs(req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.BulkTagResult{DryRun: req.DryRun, Total: len(resourceIDs)}
+	scopes := make(map[provider.Provider][]provider.Scope)
+	for _, resourceID := range resourceIDs {
+		item := s.updateResourceTags(resourceID, req, scopes)
+		switch item.Status {
+		case model.BulkTagStatusPending, model.BulkTagStatusUpdated:
+			result.Changed++
+		case model.BulkTagStatusPartial:
+			result.Changed++
+			result.Failed++
+		case model.BulkTagStatusFailed:
+			result.Failed++
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	if !req.DryRun {
+		log.Printf("批量编辑标签完成: 总数 %d, 修改 %d, 失败 %d", result.Total, result.Changed, result.Failed)
+	}
+	return result, nil
+}
+
+// validateBulkTagRequest 检查选择方式和标签变更是否有效
+func validateBulkTagRequest(req model.BulkTagRequest) error {
+	hasIDs, hasQuery := len(req.ResourceIDs) > 0, strings.TrimSpace(req.Query) != ""
+	if hasIDs == hasQuery {
+		return fmt.Errorf("%w: resource_ids和query必须且只能指定一个", ErrInvalidRequest)
+	}
+	if len(req.ResourceIDs) > maxBulkTagResources {
+		return fmt.Errorf("%w: 单次最多修改%d个资源", ErrInvalidRequest, maxBulkTagResources)
+	}
+	if len(req.Set) == 0 && len(req.Remove) == 0 {
+		return fmt.Errorf("%w: set和remove不能同时为空", ErrInvalidRequest)
+	}
+	for key := range req.Set {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("%w: 标签键不能为空", ErrInvalidRequest)
+		}
+	}
+	for _, key := range req.Remove {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("%w: 标签键不能为空", ErrInvalidRequest)
+		}
+		if _, ok := req.Set[key]; ok {
+			return fmt.Errorf("%w: 标签 %s 不能同时出现在set和remove中", ErrInvalidRequest, key)
+		}
+	}
+	return nil
+}
+
+// selectResourceIDs 按请求中的资源ID或搜索查询选出要修改的资源ID，去重后保持原有顺序
+// 搜索命中的虚拟机和数据库按其资源ID归并到对应资源
+func (s *TagService) selectResourceIDs(req model.BulkTagRequest) ([]string, error) {
+	seen := make(map[string]bool)
+	var resourceIDs []string
+	add := func(resourceID string) {
+		if resourceID != "" && !seen[resourceID] {
+			seen[resourceID] = true
+			resourceIDs = append(resourceIDs, resourceID)
+		}
+	}
+
+	if len(req.ResourceIDs) > 0 {
+		for _, resourceID := range req.ResourceIDs {
+			add(strings.TrimSpace(resourceID))
+		}
+		return resourceIDs, nil
+	}
+
+	query, err := search.Parse(req.Query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: query %v", ErrInvalidRequest, err)
+	}
+	const pageSize = 1000
+	for offset := 0; ; offset += pageSize {
+		page, err := s.searchRepo.Search(query, false, pageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			add(item.ResourceID)
+		}
+		if len(resourceIDs) > maxBulkTagResources {
+			return nil, fmt.Errorf("%w: 查询匹配的资源超过%d个，请缩小范围", ErrInvalidRequest, maxBulkTagResources)
+		}
+		if len(page.Items) < pageSize || offset+len(page.Items) >= page.Total {
+			return resourceIDs, nil
+		}
+	}
+}
+
+// updateResourceTags 处理单个资源：计算修改后的标签，非预览时写回平台并刷新本地记录
+// scopes缓存各Provider的同步范围，用于确定资源所属账号
+func (s *TagService) updateResourceTags(resourceID string, req model.BulkTagRequest, scopes map[provider.Provider][]provider.Scope) *model.BulkTagItem {
+	item := &model.BulkTagItem{ResourceID: resourceID}
+	fail := func(format string, args ...interface{}) *model.BulkTagItem {
+		item.Status = model.BulkTagStatusFailed
+		item.Error = fmt.Sprintf(format, args...)
+		return item
+	}
+
+	resource, err := s.resourceRepo.GetResourceByID(resourceID)
+	if err != nil {
+		log.Printf("获取资源 %s 失败: %v", resourceID, err)
+		return fail("获取资源失败: %v", err)
+	}
+	if resource == nil || resource.DeletedAt != nil {
+		return fail("资源不存在或已删除")
+	}
+	item.Name = resource.Name
+	item.Provider = resource.Provider
+	item.OldTags = resource.Tags
+	item.NewTags = applyTagChanges(resource.Tags, req.Set, req.Remove)
+
+	if tagsEqual(item.OldTags, item.NewTags) {
+		item.Status = model.BulkTagStatusUnchanged
+		return item
+	}
+	if req.DryRun {
+		item.Status = model.BulkTagStatusPending
+		return item
+	}
+
+	writer, err := s.tagWriter(resource, scopes)
+	if err != nil {
+		return fail("%v", err)
+	}
+	tags, err := writer.UpdateTags(resource.SubscriptionID, resource.ResourceID, req.Set, req.Remove)
+	if err != nil && tags == nil {
+		log.Printf("写回资源 %s 标签失败: %v", resourceID, err)
+		return fail("%v", err)
+	}
+	item.Status = model.BulkTagStatusUpdated
+	item.NewTags = tags
+	if err != nil {
+		// 平台上的标签已部分修改，同样刷新本地标签，避免与平台不一致
+		log.Printf("写回资源 %s 标签部分失败: %v", resourceID, err)
+		item.Status = model.BulkTagStatusPartial
+		item.Error = fmt.Sprintf("部分写回平台: %v", err)
+	}
+
+	// 平台上已修改成功，本地刷新失败时下次同步会补齐，只在结果中提示
+	if err := s.refreshLocalTags(resource, tags); err != nil {
+		log.Printf("刷新资源 %s 本地标签失败: %v", resourceID, err)
+		if item.Error != "" {
+			item.Error += "; "
+		}
+		item.Error += fmt.Sprintf("已写回平台，刷新本地标签失败: %v", err)
+	}
+	return item
+}
+
+// tagWriter 找到资源所属的Provider账号，同一平台有多个账号时按资源所在的同步范围区分
+func (s *TagService) tagWriter(resource *model.Resource, scopes map[provider.Provider][]provider.Scope) (provider.TagWriter, error) {
+	var candidates []provider.Provider
+	for _, p := range s.registry.Providers() {
+		if _, ok := p.(provider.TagWriter); ok && p.Name() == resource.Provider {
+			candidates = append(candidates, p)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("平台 %s 不支持写回标签", resource.Provider)
+	}
+	if len(candidates) == 1 {
+		return candidates[0].(provider.TagWriter), nil
+	}
+
+	for _, p := range candidates {
+		pScopes, ok := scopes[p]
+		if !ok {
+			var err error
+			pScopes, err = p.Scopes()
+			if err != nil {
+				log.Printf("获取 %s/%s 同步范围失败: %v", p.Name(), p.Account(), err)
+			}
+			scopes[p] = pScopes
+		}
+		for _, scope := range pScopes {
+			if scope.ID == resource.SubscriptionID {
+				return p.(provider.TagWriter), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("找不到范围 %s 所属的 %s 账号", resource.SubscriptionID, resource.Provider)
+}
+
+// refreshLocalTags 以平台返回的标签更新本地资源及同一资源ID下的虚拟机和数据库，所有者与同步时一样取自owner标签
+// 资源没有owner标签时保留之前推断出的所有者；保存时与已存储的记录比较并追加变更历史，变更记录不关联同步任务
+func (s *TagService) refreshLocalTags(resource *model.Resource, tags map[string]string) error {
+	updated := *resource
+	updated.Tags = tags
+	if owner := tags["owner"]; owner != "" {
+		updated.Owner, updated.OwnerSource = owner, model.OwnerSourceTag
+	} else if updated.OwnerSource == model.OwnerSourceTag {
+		updated.Owner, updated.OwnerSource = "", ""
+	}
+	if err := s.resourceRepo.SaveResource(&updated, 0); err != nil {
+		return err
+	}
+
+	vm, err := s.vmRepo.GetVMByID(resource.ResourceID)
+	if err != nil {
+		return err
+	}
+	if vm != nil && vm.DeletedAt == nil {
+		vm.Tags = tags
+		vm.Owner = tags["owner"]
+		if err := s.vmRepo.SaveVM(vm, 0); err != nil {
+			return err
+		}
+	}
+
+	database, err := s.databaseRepo.GetDatabaseByResourceID(resource.ResourceID)
+	if err != nil {
+		return err
+	}
+	if database != nil && database.DeletedAt == nil {
+		database.Tags = tags
+		database.Owner = tags["owner"]
+		if err := s.databaseRepo.SaveDatabase(database, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyTagChanges 返回在tags上添加或修改set、删除remove后的新标签，不修改tags
+// 与Azure一致，删除时忽略标签键的大小写
+func applyTagChanges(tags map[string]string, set map[string]string, remove []string) map[string]string {
+	result := make(map[string]string, len(tags)+len(set))
+	for key, value := range tags {
+		result[key] = value
+	}
+	for key, value := range set {
+		result[key] = value
+	}
+	for _, key := range remove {
+		for existing := range result {
+			if strings.EqualFold(existing, key) {
+				delete(result, existing)
+			}
+		}
+	}
+	return result
+}
+
+// tagsEqual 比较两组标签是否相同
+func tagsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"CMDB/model"
+	"CMDB/repository"
+	"fmt"
+	"strings"
+)
+
+// maxTopologyResources 按范围查询拓扑时作为起点的最大资源数，超过后结果标记为截断
+const maxTopologyResources = 500
+
+// TopologyService 拓扑服务，按起点资源或范围条件查出用于绘制架构图的节点和关系
+type TopologyService struct {
+	resourceRepo        *repository.ResourceRepository
+	relationshipService *RelationshipService
+}
+
+// NewTopologyService 创建新的拓扑服务
+func NewTopologyService(resourceRepo *repository.ResourceRepository, relationshipService *RelationshipService) *TopologyService {
+	return &TopologyService{resourceRepo: resourceRepo, relationshipService: relationshipService}
+}
+
+// Topology 查询拓扑图：指定Root时从起点遍历Depth层；否则以满足范围条件的资源为起点，包含与其直接相连的节点
+// 需要分组时补充节点到资源组、子网到虚拟网络的归属关系
+func (s *TopologyService) Topology(filter model.TopologyFilter) (*model.ResourceGraph, error) {
+	if filter.TagValue != "" && filter.TagKey == "" {
+		return nil, fmt.Errorf("%w: 指定tag_value时必须同时指定tag_key", ErrInvalidRequest)
+	}
+
+	var graph *model.ResourceGraph
+	var err error
+	switch {
+	case filter.Root != "":
+		graph, err = s.relationshipService.Traverse(filter.Root, filter.Depth, filter.Relationship)
+	case filter.HasScope():
+		graph, err = s.scopeGraph(filter)
+	default:
+		return nil, fmt.Errorf("%w: 必须指定root或subscription、resource_group、provider、type、tag_key中的至少一项", ErrInvalidRequest)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var groupingTypes []string
+	if filter.GroupByResourceGroup {
+		groupingTypes = append(groupingTypes, model.RelationshipInResourceGroup)
+	}
+	if filter.GroupByVNet {
+		groupingTypes = append(groupingTypes, model.RelationshipInVNet)
+	}
+	if len(groupingTypes) == 0 || len(graph.Nodes) == 0 {
+		return graph, nil
+	}
+
+	// 子网归属虚拟网络、虚拟网络再归属资源组，沿归属方向两层即可覆盖
+	ids := make([]string, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		ids[i] = node.ID
+	}
+	grouping, err := s.relationshipService.traverse(ids, 2, model.RelationshipFilter{Direction: model.DirectionOutgoing, Types: groupingTypes})
+	if err != nil {
+		return nil, err
+	}
+	mergeGraph(graph, grouping)
+	return graph, nil
+}
+
+// scopeGraph 以满足范围条件的资源为起点查询拓扑图
+func (s *TopologyService) scopeGraph(filter model.TopologyFilter) (*model.ResourceGraph, error) {
+	page, err := s.resourceRepo.ListResources(model.ResourceFilter{
+		Provider:       filter.Provider,
+		SubscriptionID: filter.SubscriptionID,
+		ResourceGroup:  filter.ResourceGroup,
+		ResourceType:   filter.ResourceType,
+		TagKey:         filter.TagKey,
+		TagValue:       filter.TagValue,
+		Limit:          maxTopologyResources,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(page.Items))
+	for i, resource := range page.Items {
+		ids[i] = resource.ResourceID
+	}
+	graph, err := s.relationshipService.Neighborhood(ids, filter.Relationship)
+	if err != nil {
+		return nil, err
+	}
+	if page.Total > len(page.Items) {
+		graph.Truncated = true
+	}
+	return graph, nil
+}
+
+// mergeGraph 将src中dst尚未包含的节点和关系合并到dst，资源ID不区分大小写
+func mergeGraph(dst, src *model.ResourceGraph) {
+	nodes := make(map[string]bool, len(dst.Nodes))
+	for _, node := range dst.Nodes {
+		nodes[strings.ToLower(node.ID)] = true
+	}
+	edges := make(map[string]bool, len(dst.Edges))
+	for _, edge := range dst.Edges {
+		edges[strings.ToLower(edge.SourceID+"|"+edge.TargetID+"|"+edge.Type)] = true
+	}
+
+	for _, node := range src.Nodes {
+		if !nodes[strings.ToLower(node.ID)] {
+			nodes[strings.ToLower(node.ID)] = true
+			dst.Nodes = append(dst.Nodes, node)
+		}
+	}
+	for _, edge := range src.Edges {
+		key := strings.ToLower(edge.SourceID + "|" + edge.TargetID + "|" + edge.Type)
+		if !edges[key] {
+			edges[key] = true
+			dst.Edges = append(dst.Edges, edge)
+		}
+	}
+	dst.Truncated = dst.Truncated || src.Truncated
+}
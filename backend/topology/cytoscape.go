@@ -0,0 +1,109 @@
+package topology
+
+// CytoscapeGraph Cytoscape.js可直接加载的拓扑，elements可传给cy.add，layout可传给cy.layout
+type CytoscapeGraph struct {
+	Elements  CytoscapeElements `json:"elements"`
+	Layout    CytoscapeLayout   `json:"layout"`
+	Root      string            `json:"root,omitempty"`
+	Truncated bool              `json:"truncated"`
+}
+
+// CytoscapeElements 节点和边
+type CytoscapeElements struct {
+	Nodes []CytoscapeNode `json:"nodes"`
+	Edges []CytoscapeEdge `json:"edges"`
+}
+
+// CytoscapeNode Cytoscape节点，分组节点没有position，由其成员的位置决定
+type CytoscapeNode struct {
+	Data     CytoscapeNodeData  `json:"data"`
+	Position *CytoscapePosition `json:"position,omitempty"`
+	Classes  string             `json:"classes"`
+}
+
+// CytoscapeNodeData 节点数据，parent为所在分组的节点ID
+type CytoscapeNodeData struct {
+	ID          string `json:"id"`
+	Label       string `json:"label"`
+	Type        string `json:"type"`
+	Tier        string `json:"tier,omitempty"`
+	Parent      string `json:"parent,omitempty"`
+	Group       string `json:"group,omitempty"`
+	InInventory bool   `json:"in_inventory"`
+}
+
+// CytoscapePosition 节点坐标
+type CytoscapePosition struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// CytoscapeEdge Cytoscape边
+type CytoscapeEdge struct {
+	Data CytoscapeEdgeData `json:"data"`
+}
+
+// CytoscapeEdgeData 边数据
+type CytoscapeEdgeData struct {
+	ID     string `json:"id"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"`
+}
+
+// CytoscapeLayout 布局提示：默认使用服务端计算的坐标（preset），
+// 改用dagre等分层布局时可参考rankDir和tiers（各列从左到右对应的层）
+type CytoscapeLayout struct {
+	Name    string   `json:"name"`
+	Fit     bool     `json:"fit"`
+	Padding int      `json:"padding"`
+	RankDir string   `json:"rankDir"`
+	Tiers   []string `json:"tiers"`
+}
+
+// Cytoscape 将拓扑转换为Cytoscape JSON
+func (g *Graph) Cytoscape() *CytoscapeGraph {
+	result := &CytoscapeGraph{
+		Elements: CytoscapeElements{
+			Nodes: make([]CytoscapeNode, 0, len(g.Nodes)),
+			Edges: make([]CytoscapeEdge, 0, len(g.Edges)),
+		},
+		Layout:    CytoscapeLayout{Name: "preset", Fit: true, Padding: 30, RankDir: "LR", Tiers: TierNames},
+		Root:      g.Root,
+		Truncated: g.Truncated,
+	}
+
+	for _, node := range g.Nodes {
+		element := CytoscapeNode{
+			Data: CytoscapeNodeData{
+				ID:          node.ID,
+				Label:       node.Label,
+				Type:        node.Type,
+				Parent:      node.Parent,
+				Group:       node.Group,
+				InInventory: node.InInventory,
+			},
+		}
+		if node.Group != "" {
+			element.Classes = "group " + node.Group
+		} else {
+			element.Data.Tier = TierNames[node.Tier]
+			element.Position = &CytoscapePosition{X: node.X, Y: node.Y}
+			element.Classes = TierNames[node.Tier]
+		}
+		if !node.InInventory {
+			element.Classes += " external"
+		}
+		if node.ID == g.Root {
+			element.Classes += " root"
+		}
+		result.Elements.Nodes = append(result.Elements.Nodes, element)
+	}
+
+	for _, edge := range g.Edges {
+		result.Elements.Edges = append(result.Elements.Edges, CytoscapeEdge{
+			Data: CytoscapeEdgeData{ID: edge.ID, Source: edge.Source, Target: edge.Target, Type: edge.Type},
+		})
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+package topology
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ContentTypeDOT Graphviz DOT的Content-Type
+const ContentTypeDOT = "text/vnd.graphviz; charset=utf-8"
+
+// tierColors 各层节点的填充色，下标为层号
+var tierColors = []string{"#dae8fc", "#d5e8d4", "#ffe6cc", "#f5f5f5"}
+
+// WriteDOT 将拓扑输出为Graphviz DOT：分组输出为嵌套的cluster子图，节点按层着色，
+// pos属性为服务端计算的坐标（单位为点，可用 neato -n 直接按坐标渲染），dot布局时从左到右排列
+func (g *Graph) WriteDOT(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	children := make(map[string][]*Node)
+	groups := make(map[string]bool)
+	for _, node := range g.Nodes {
+		if node.Group != "" {
+			groups[strings.ToLower(node.ID)] = true
+		}
+	}
+	for _, node := range g.Nodes {
+		parent := strings.ToLower(node.Parent)
+		if !groups[parent] {
+			parent = ""
+		}
+		children[parent] = append(children[parent], node)
+	}
+
+	// 分组本身是子图，只有被边引用时才在子图中输出同名节点作为连接点
+	referenced := make(map[string]bool)
+	for _, edge := range g.Edges {
+		referenced[strings.ToLower(edge.Source)] = true
+		referenced[strings.ToLower(edge.Target)] = true
+	}
+
+	fmt.Fprintln(out, "digraph topology {")
+	fmt.Fprintln(out, "  rankdir=LR;")
+	fmt.Fprintln(out, "  compound=true;")
+	fmt.Fprintln(out, `  node [shape=box, style="rounded,filled", fontname="Helvetica"];`)
+	fmt.Fprintln(out, `  edge [fontname="Helvetica", fontsize=10];`)
+
+	clusters := 0
+	var writeNodes func(parent string, indent string)
+	writeNodes = func(parent string, indent string) {
+		for _, node := range children[parent] {
+			if node.Group == "" {
+				fmt.Fprintf(out, "%s%s [label=%s, fillcolor=%s, pos=%s];\n", indent,
+					quote(node.ID), quote(node.Label), quote(tierColors[node.Tier]), quote(fmt.Sprintf("%d,%d", node.X, -node.Y)))
+				continue
+			}
+			clusters++
+			fmt.Fprintf(out, "%ssubgraph %s {\n", indent, quote(fmt.Sprintf("cluster_%d", clusters)))
+			fmt.Fprintf(out, "%s  label=%s;\n", indent, quote(node.Label))
+			fmt.Fprintf(out, "%s  style=%s;\n", indent, quote("rounded,dashed"))
+			if referenced[strings.ToLower(node.ID)] {
+				fmt.Fprintf(out, "%s  %s [label=%s, shape=folder, fillcolor=%s];\n", indent,
+					quote(node.ID), quote(node.Label), quote("#ffffff"))
+			}
+			writeNodes(strings.ToLower(node.ID), indent+"  ")
+			fmt.Fprintf(out, "%s}\n", indent)
+		}
+	}
+	writeNodes("", "  ")
+
+	for _, edge := range g.Edges {
+		fmt.Fprintf(out, "  %s -> %s [label=%s];\n", quote(edge.Source), quote(edge.Target), quote(edge.Type))
+	}
+	fmt.Fprintln(out, "}")
+	return out.Flush()
+}
+
+// quote 将字符串转为DOT的双引号字符串
+func quote(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "")
+	return `"` + replacer.Replace(value) + `"`
+}
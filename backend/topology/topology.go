@@ -0,0 +1,214 @@
+// Package topology 将资源关系图转换为用于绘制架构图的拓扑，支持输出Cytoscape JSON和Graphviz DOT
+//
+// 资源组和虚拟网络作为复合节点（分组），其成员节点的Parent指向它们，对应的归属关系不再作为边输出。
+// 节点按类型分为网络、计算、数据和其他四层，从左到右排列；每个顶层分组内按层堆叠，分组之间上下排列，
+// 布局结果作为节点坐标（Cytoscape的preset布局、Graphviz的pos属性）返回，前端可直接使用或改用自己的布局。
+package topology
+
+import (
+	"CMDB/model"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 节点分层，决定节点所在的列
+const (
+	TierNetwork = iota
+	TierCompute
+	TierData
+	TierOther
+)
+
+// TierNames 各层的名称，下标为层号
+var TierNames = []string{"network", "compute", "data", "other"}
+
+// 布局参数，单位为像素
+const (
+	columnWidth = 220
+	rowHeight   = 80
+	// groupGap 顶层分组之间空出的行数
+	groupGap = 1
+)
+
+// 分组类型
+const (
+	GroupResourceGroup = "resource_group"
+	GroupVNet          = "vnet"
+)
+
+// Options 拓扑的分组选项
+type Options struct {
+	GroupByResourceGroup bool
+	GroupByVNet          bool
+}
+
+// Node 拓扑节点，Group不为空时为分组（复合节点），分组没有坐标
+type Node struct {
+	ID          string
+	Label       string
+	Type        string
+	Tier        int
+	Parent      string
+	Group       string
+	InInventory bool
+	X, Y        int
+}
+
+// Edge 拓扑中的边，由依赖方指向被依赖方
+type Edge struct {
+	ID     string
+	Source string
+	Target string
+	Type   string
+}
+
+// Graph 带分组和布局的拓扑
+type Graph struct {
+	Root      string
+	Nodes     []*Node
+	Edges     []*Edge
+	Truncated bool
+}
+
+// Build 将关系图转换为拓扑：按选项建立分组、计算节点分层和坐标
+// 按资源组分组时订阅节点及资源组到订阅的关系不再输出
+func Build(graph *model.ResourceGraph, options Options) *Graph {
+	topology := &Graph{Root: graph.Root, Truncated: graph.Truncated}
+	nodes := make(map[string]*Node, len(graph.Nodes))
+	for _, graphNode := range graph.Nodes {
+		node := &Node{
+			ID:          graphNode.ID,
+			Label:       graphNode.Name,
+			Type:        graphNode.Type,
+			Tier:        tierOf(graphNode.Type),
+			InInventory: graphNode.InInventory,
+		}
+		nodes[strings.ToLower(node.ID)] = node
+		topology.Nodes = append(topology.Nodes, node)
+	}
+
+	linked := make(map[*Node]bool)
+	for _, relationship := range graph.Edges {
+		source, target := nodes[strings.ToLower(relationship.SourceID)], nodes[strings.ToLower(relationship.TargetID)]
+		if source == nil || target == nil {
+			continue
+		}
+		switch {
+		case relationship.Type == model.RelationshipInResourceGroup && options.GroupByResourceGroup:
+			source.Parent, target.Group = target.ID, GroupResourceGroup
+			continue
+		case relationship.Type == model.RelationshipInVNet && options.GroupByVNet:
+			source.Parent, target.Group = target.ID, GroupVNet
+			continue
+		case relationship.Type == model.RelationshipInSubscription && options.GroupByResourceGroup:
+			continue
+		}
+		linked[source], linked[target] = true, true
+		topology.Edges = append(topology.Edges, &Edge{
+			ID:     fmt.Sprintf("e%d", len(topology.Edges)),
+			Source: source.ID,
+			Target: target.ID,
+			Type:   relationship.Type,
+		})
+	}
+
+	if options.GroupByResourceGroup {
+		kept := topology.Nodes[:0]
+		for _, node := range topology.Nodes {
+			if node.Group == "" && !linked[node] && strings.EqualFold(node.Type, "Microsoft.Resources/subscriptions") {
+				continue
+			}
+			kept = append(kept, node)
+		}
+		topology.Nodes = kept
+	}
+
+	layout(topology.Nodes, nodes)
+	return topology
+}
+
+// tierOf 按资源类型确定节点分层
+func tierOf(resourceType string) int {
+	resourceType = strings.ToLower(resourceType)
+	switch {
+	case strings.HasPrefix(resourceType, "microsoft.network/"):
+		return TierNetwork
+	case strings.HasPrefix(resourceType, "microsoft.compute/"),
+		strings.HasPrefix(resourceType, "microsoft.web/"),
+		strings.HasPrefix(resourceType, "microsoft.containerservice/"),
+		strings.HasPrefix(resourceType, "microsoft.containerinstance/"):
+		return TierCompute
+	case strings.HasPrefix(resourceType, "microsoft.sql/"),
+		strings.HasPrefix(resourceType, "microsoft.dbformysql/"),
+		strings.HasPrefix(resourceType, "microsoft.dbforpostgresql/"),
+		strings.HasPrefix(resourceType, "microsoft.documentdb/"),
+		strings.HasPrefix(resourceType, "microsoft.cache/"),
+		strings.HasPrefix(resourceType, "microsoft.storage/"):
+		return TierData
+	}
+	return TierOther
+}
+
+// layout 计算非分组节点的坐标：x由分层决定，同一顶层分组内的节点按层从上到下排列，顶层分组按名称依次向下排列
+func layout(ordered []*Node, nodes map[string]*Node) {
+	// topGroup 节点所在的最外层分组，不在分组中时为空
+	topGroup := func(node *Node) *Node {
+		var top *Node
+		for i := 0; node.Parent != "" && i < len(nodes); i++ {
+			parent := nodes[strings.ToLower(node.Parent)]
+			if parent == nil {
+				break
+			}
+			top, node = parent, parent
+		}
+		return top
+	}
+
+	members := make(map[*Node][]*Node)
+	var groups []*Node
+	for _, node := range ordered {
+		if node.Group != "" {
+			continue
+		}
+		top := topGroup(node)
+		if _, ok := members[top]; !ok {
+			groups = append(groups, top)
+		}
+		members[top] = append(members[top], node)
+	}
+
+	// 未分组的节点排在最后，其余分组按名称排序
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i] == nil || groups[j] == nil {
+			return groups[j] == nil && groups[i] != nil
+		}
+		return strings.ToLower(groups[i].Label) < strings.ToLower(groups[j].Label)
+	})
+
+	offset := 0
+	for _, group := range groups {
+		items := members[group]
+		sort.SliceStable(items, func(i, j int) bool {
+			if items[i].Tier != items[j].Tier {
+				return items[i].Tier < items[j].Tier
+			}
+			if items[i].Parent != items[j].Parent {
+				return strings.ToLower(items[i].Parent) < strings.ToLower(items[j].Parent)
+			}
+			return strings.ToLower(items[i].Label) < strings.ToLower(items[j].Label)
+		})
+
+		rows := make([]int, len(TierNames))
+		height := 0
+		for _, node := range items {
+			node.X = node.Tier * columnWidth
+			node.Y = (offset + rows[node.Tier]) * rowHeight
+			rows[node.Tier]++
+			if rows[node.Tier] > height {
+				height = rows[node.Tier]
+			}
+		}
+		offset += height + groupGap
+	}
+}
@@ -0,0 +1,182 @@
+package vsphere
+
+import (
+	"CMDB/config"
+	"CMDB/model"
+	"CMDB/provider"
+	"fmt"
+
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ProviderName vSphere平台名称
+const ProviderName = "vsphere"
+
+func init() {
+	provider.RegisterFactory(ProviderName, newProviders)
+}
+
+// newProviders 为每个配置的vCenter创建Provider
+func newProviders(cfg *config.Config) ([]provider.Provider, error) {
+	var providers []provider.Provider
+	for _, account := range cfg.VSphereAccounts {
+		providers = append(providers, NewVSphereProvider(NewVSphereHelper(account)))
+	}
+	return providers, nil
+}
+
+// VSphereProvider vSphere资源发现，同步虚拟机、主机、集群和数据存储
+// 同一范围同步期间，数据中心清单在发现资源和虚拟机之间共享
+type VSphereProvider struct {
+	provider.ScopeCache
+	vsphereHelper *VSphereHelper
+}
+
+// NewVSphereProvider 创建新的vSphere Provider
+func NewVSphereProvider(vsphereHelper *VSphereHelper) *VSphereProvider {
+	return &VSphereProvider{vsphereHelper: vsphereHelper}
+}
+
+// Name 平台名称
+func (p *VSphereProvider) Name() string {
+	return ProviderName
+}
+
+// Account 账号名称
+func (p *VSphereProvider) Account() string {
+	return p.vsphereHelper.AccountName()
+}
+
+// Scopes vCenter中需要同步的数据中心，范围ID为 vCenter主机名/数据中心名
+func (p *VSphereProvider) Scopes() ([]provider.Scope, error) {
+	datacenters, err := p.vsphereHelper.ListDatacenters()
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := make([]provider.Scope, 0, len(datacenters))
+	for _, datacenter := range datacenters {
+		scopes = append(scopes, provider.Scope{
+			ID:   p.vsphereHelper.Host() + "/" + datacenter,
+			Name: datacenter,
+		})
+	}
+	return scopes, nil
+}
+
+// DiscoverVMs 发现数据中心内的虚拟机
+func (p *VSphereProvider) DiscoverVMs(scope provider.Scope) ([]*model.VM, error) {
+	inventory, err := p.inventory(scope)
+	if err != nil {
+		return nil, err
+	}
+	return p.convertVMs(inventory, scope), nil
+}
+
+// DiscoverDatabases vSphere没有托管数据库服务
+func (p *VSphereProvider) DiscoverDatabases(scope provider.Scope) ([]*model.Database, error) {
+	return nil, nil
+}
+
+// DiscoverResources 发现数据中心内的虚拟机、主机、集群和数据存储
+func (p *VSphereProvider) DiscoverResources(scope provider.Scope) ([]*model.Resource, error) {
+	inventory, err := p.inventory(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []*model.Resource
+	for _, vm := range p.convertVMs(inventory, scope) {
+		resources = append(resources, &model.Resource{
+			Provider:       ProviderName,
+			ResourceID:     vm.ResourceID,
+			Name:           vm.Name,
+			Location:       vm.Location,
+			ResourceType:   "VirtualMachine",
+			Owner:          vm.Owner,
+			Status:         vm.Status,
+			SubscriptionID: vm.SubscriptionID,
+			Tags:           vm.Tags,
+		})
+	}
+
+	for _, host := range inventory.Hosts {
+		status := string(host.Summary.OverallStatus)
+		if host.Summary.Runtime != nil {
+			status = string(host.Summary.Runtime.ConnectionState)
+		}
+		resources = append(resources, p.newResource(inventory, scope, host.ManagedEntity, status))
+	}
+
+	for _, cluster := range inventory.Clusters {
+		resources = append(resources, p.newResource(inventory, scope, cluster.ManagedEntity, string(cluster.OverallStatus)))
+	}
+
+	for _, datastore := range inventory.Datastores {
+		status := "inaccessible"
+		if datastore.Summary.Accessible {
+			status = "accessible"
+		}
+		resources = append(resources, p.newResource(inventory, scope, datastore.ManagedEntity, status))
+	}
+
+	return resources, nil
+}
+
+// inventory 获取数据中心的清单，范围同步期间只登录vCenter查询一次
+func (p *VSphereProvider) inventory(scope provider.Scope) (*Inventory, error) {
+	value, err := p.Load(scope.ID, "inventory", func() (interface{}, error) {
+		return p.vsphereHelper.GetInventory(scope.Name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*Inventory), nil
+}
+
+// convertVMs 将清单中的虚拟机转换为VM模型
+func (p *VSphereProvider) convertVMs(inventory *Inventory, scope provider.Scope) []*model.VM {
+	vms := make([]*model.VM, 0, len(inventory.VMs))
+	for _, vm := range inventory.VMs {
+		tags := inventory.Tags(vm.CustomValue)
+		resourceID := p.resourceID(vm.Self)
+		summary := vm.Summary.Config
+
+		vms = append(vms, &model.VM{
+			Provider:       ProviderName,
+			VMID:           resourceID,
+			ResourceID:     resourceID,
+			Name:           vm.Name,
+			Location:       inventory.Datacenter,
+			Type:           fmt.Sprintf("%dvCPU/%dMB", summary.NumCpu, summary.MemorySizeMB),
+			Status:         string(vm.Summary.Runtime.PowerState),
+			Owner:          tags["owner"],
+			SubscriptionID: scope.ID,
+			Tags:           tags,
+		})
+	}
+	return vms
+}
+
+// newResource 将主机、集群、数据存储等清单对象转换为通用资源
+func (p *VSphereProvider) newResource(inventory *Inventory, scope provider.Scope, entity mo.ManagedEntity, status string) *model.Resource {
+	tags := inventory.Tags(entity.CustomValue)
+	return &model.Resource{
+		Provider:       ProviderName,
+		ResourceID:     p.resourceID(entity.Self),
+		Name:           entity.Name,
+		Location:       inventory.Datacenter,
+		ResourceType:   entity.Self.Type,
+		Owner:          tags["owner"],
+		Status:         status,
+		SubscriptionID: scope.ID,
+		Tags:           tags,
+	}
+}
+
+// resourceID 构造资源ID，格式为 vsphere://<vCenter主机名>/<对象类型>/<MoRef>
+// MoRef在同一vCenter内唯一且不随重命名变化
+func (p *VSphereProvider) resourceID(ref types.ManagedObjectReference) string {
+	return fmt.Sprintf("vsphere://%s/%s/%s", p.vsphereHelper.Host(), ref.Type, ref.Value)
+}
@@ -0,0 +1,127 @@
+package vsphere
+
+import (
+	"CMDB/config"
+	"CMDB/model"
+	"strings"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+)
+
+// newSimulatedProvider 启动vcsim模拟的vCenter（一个数据中心，含独立主机、集群、数据存储和虚拟机）
+func newSimulatedProvider(t *testing.T) (*VSphereProvider, simulator.Model, *simulator.Server) {
+	vpx := simulator.VPX()
+	if err := vpx.Create(); err != nil {
+		t.Fatalf("创建vcsim模型失败: %v", err)
+	}
+	t.Cleanup(vpx.Remove)
+
+	server := vpx.Service.NewServer()
+	t.Cleanup(server.Close)
+
+	password, _ := server.URL.User.Password()
+	u := *server.URL
+	u.User = nil
+	p := NewVSphereProvider(NewVSphereHelper(config.VSphereConfig{
+		Name:     "test",
+		URL:      u.String(),
+		Username: server.URL.User.Username(),
+		Password: password,
+		Insecure: true,
+	}))
+	return p, vpx.Count(), server
+}
+
+func TestDiscoverFromSimulator(t *testing.T) {
+	p, count, _ := newSimulatedProvider(t)
+
+	scopes, err := p.Scopes()
+	if err != nil {
+		t.Fatalf("Scopes: %v", err)
+	}
+	if len(scopes) != 1 || scopes[0].Name != "DC0" || !strings.HasSuffix(scopes[0].ID, "/DC0") {
+		t.Fatalf("Scopes = %+v，期望数据中心DC0", scopes)
+	}
+	scope := scopes[0]
+
+	vms, err := p.DiscoverVMs(scope)
+	if err != nil {
+		t.Fatalf("DiscoverVMs: %v", err)
+	}
+	if len(vms) != count.Machine {
+		t.Errorf("得到%d台虚拟机，期望%d台", len(vms), count.Machine)
+	}
+	for _, vm := range vms {
+		if !strings.HasPrefix(vm.VMID, "vsphere://") || !strings.Contains(vm.VMID, "/VirtualMachine/vm-") {
+			t.Errorf("虚拟机ID格式错误: %s", vm.VMID)
+		}
+		if vm.Location != "DC0" || vm.SubscriptionID != scope.ID || vm.Status == "" || !strings.HasSuffix(vm.Type, "MB") {
+			t.Errorf("虚拟机 %s = %+v", vm.Name, vm)
+		}
+	}
+
+	resources, err := p.DiscoverResources(scope)
+	if err != nil {
+		t.Fatalf("DiscoverResources: %v", err)
+	}
+	byType := make(map[string][]*model.Resource)
+	for _, resource := range resources {
+		byType[resource.ResourceType] = append(byType[resource.ResourceType], resource)
+		if resource.Provider != ProviderName || resource.Location != "DC0" || resource.Status == "" {
+			t.Errorf("资源 %s = %+v", resource.ResourceID, resource)
+		}
+	}
+	want := map[string]int{
+		"VirtualMachine":         count.Machine,
+		"HostSystem":             count.Host + count.ClusterHost,
+		"ClusterComputeResource": count.Cluster,
+		"Datastore":              count.Datastore,
+	}
+	for resourceType, n := range want {
+		if len(byType[resourceType]) != n {
+			t.Errorf("%s 资源%d个，期望%d个", resourceType, len(byType[resourceType]), n)
+		}
+	}
+	if len(byType) != len(want) {
+		t.Errorf("资源类型 %v，期望只有 %v", byType, want)
+	}
+	for _, datastore := range byType["Datastore"] {
+		if datastore.Status != "accessible" {
+			t.Errorf("数据存储 %s 状态为 %s", datastore.Name, datastore.Status)
+		}
+	}
+	for _, host := range byType["HostSystem"] {
+		if host.Status != "connected" {
+			t.Errorf("主机 %s 状态为 %s", host.Name, host.Status)
+		}
+	}
+}
+
+func TestInventoryCachedWithinScope(t *testing.T) {
+	p, count, server := newSimulatedProvider(t)
+	scopes, err := p.Scopes()
+	if err != nil {
+		t.Fatalf("Scopes: %v", err)
+	}
+	scope := scopes[0]
+
+	p.BeginScope(scope)
+	if _, err := p.DiscoverResources(scope); err != nil {
+		t.Fatalf("DiscoverResources: %v", err)
+	}
+	// 关闭vCenter后，同一范围内的后续步骤仍使用缓存的清单
+	server.Close()
+	vms, err := p.DiscoverVMs(scope)
+	if err != nil {
+		t.Fatalf("范围同步期间应复用清单: %v", err)
+	}
+	if len(vms) != count.Machine {
+		t.Errorf("得到%d台虚拟机，期望%d台", len(vms), count.Machine)
+	}
+	p.EndScope(scope)
+
+	if _, err := p.DiscoverVMs(scope); err == nil {
+		t.Errorf("范围同步结束后应重新查询vCenter")
+	}
+}
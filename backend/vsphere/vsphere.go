@@ -0,0 +1,181 @@
+package vsphere
+
+import (
+	"CMDB/config"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Inventory 数据中心内的清单对象
+type Inventory struct {
+	Datacenter string
+	VMs        []mo.VirtualMachine
+	Hosts      []mo.HostSystem
+	Clusters   []mo.ClusterComputeResource
+	Datastores []mo.Datastore
+	// fields 自定义属性定义，key -> 属性名
+	fields map[int32]string
+}
+
+// VSphereHelper 封装vCenter连接和清单查询
+type VSphereHelper struct {
+	config config.VSphereConfig
+}
+
+// NewVSphereHelper 创建新的VSphereHelper实例
+func NewVSphereHelper(cfg config.VSphereConfig) *VSphereHelper {
+	return &VSphereHelper{config: cfg}
+}
+
+// AccountName 账号名称
+func (h *VSphereHelper) AccountName() string {
+	return h.config.Name
+}
+
+// Host vCenter主机名，用于构造资源ID
+func (h *VSphereHelper) Host() string {
+	u, err := url.Parse(h.config.URL)
+	if err != nil {
+		return h.config.URL
+	}
+	return u.Hostname()
+}
+
+// connect 登录vCenter，调用方负责Logout
+func (h *VSphereHelper) connect(ctx context.Context) (*govmomi.Client, error) {
+	u, err := url.Parse(h.config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("无效的vCenter地址: %v", err)
+	}
+	if h.config.Username != "" {
+		u.User = url.UserPassword(h.config.Username, h.config.Password)
+	}
+
+	client, err := govmomi.NewClient(ctx, u, h.config.Insecure)
+	if err != nil {
+		return nil, fmt.Errorf("连接vCenter失败: %v", err)
+	}
+	return client, nil
+}
+
+// ListDatacenters 列出需要同步的数据中心名称
+func (h *VSphereHelper) ListDatacenters() ([]string, error) {
+	ctx := context.Background()
+	client, err := h.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Logout(ctx)
+
+	datacenters, err := find.NewFinder(client.Client).DatacenterList(ctx, "*")
+	if err != nil {
+		return nil, fmt.Errorf("列举数据中心失败: %v", err)
+	}
+
+	allowed := make(map[string]bool)
+	for _, name := range h.config.Datacenters {
+		allowed[name] = true
+	}
+
+	var names []string
+	for _, dc := range datacenters {
+		if len(allowed) > 0 && !allowed[dc.Name()] {
+			continue
+		}
+		names = append(names, dc.Name())
+	}
+	return names, nil
+}
+
+// GetInventory 获取数据中心内的虚拟机、主机、集群和数据存储
+func (h *VSphereHelper) GetInventory(datacenter string) (*Inventory, error) {
+	ctx := context.Background()
+	client, err := h.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Logout(ctx)
+
+	dc, err := find.NewFinder(client.Client).Datacenter(ctx, datacenter)
+	if err != nil {
+		return nil, fmt.Errorf("查找数据中心 %s 失败: %v", datacenter, err)
+	}
+
+	manager := view.NewManager(client.Client)
+	containerView, err := manager.CreateContainerView(ctx, dc.Reference(), nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("创建清单视图失败: %v", err)
+	}
+	defer containerView.Destroy(ctx)
+
+	inventory := &Inventory{Datacenter: datacenter}
+	if err := containerView.Retrieve(ctx, []string{"VirtualMachine"},
+		[]string{"name", "summary", "customValue"}, &inventory.VMs); err != nil {
+		return nil, fmt.Errorf("获取虚拟机失败: %v", err)
+	}
+	if err := containerView.Retrieve(ctx, []string{"HostSystem"},
+		[]string{"name", "summary", "customValue"}, &inventory.Hosts); err != nil {
+		return nil, fmt.Errorf("获取主机失败: %v", err)
+	}
+	if err := containerView.Retrieve(ctx, []string{"ClusterComputeResource"},
+		[]string{"name", "overallStatus", "customValue"}, &inventory.Clusters); err != nil {
+		return nil, fmt.Errorf("获取集群失败: %v", err)
+	}
+	if err := containerView.Retrieve(ctx, []string{"Datastore"},
+		[]string{"name", "summary", "customValue"}, &inventory.Datastores); err != nil {
+		return nil, fmt.Errorf("获取数据存储失败: %v", err)
+	}
+
+	inventory.fields, err = customFieldNames(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return inventory, nil
+}
+
+// customFieldNames 获取自定义属性定义，直连ESXi主机时不支持自定义属性
+func customFieldNames(ctx context.Context, client *govmomi.Client) (map[int32]string, error) {
+	names := make(map[int32]string)
+
+	manager, err := object.GetCustomFieldsManager(client.Client)
+	if errors.Is(err, object.ErrNotSupported) {
+		return names, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := manager.Field(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取自定义属性定义失败: %v", err)
+	}
+	for _, field := range fields {
+		names[field.Key] = field.Name
+	}
+	return names, nil
+}
+
+// Tags 将对象的自定义属性转换为标签
+func (inv *Inventory) Tags(values []types.BaseCustomFieldValue) map[string]string {
+	tags := make(map[string]string)
+	for _, value := range values {
+		stringValue, ok := value.(*types.CustomFieldStringValue)
+		if !ok {
+			continue
+		}
+		if name, ok := inv.fields[stringValue.Key]; ok {
+			tags[name] = stringValue.Value
+		}
+	}
+	return tags
+}
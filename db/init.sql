@@ -9,19 +9,29 @@ USE cmdb;
 -- 创建资源表
 CREATE TABLE resources (
     id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL DEFAULT 'azure',
     resource_id VARCHAR(255) NOT NULL UNIQUE,
     name VARCHAR(255) NOT NULL,
     location VARCHAR(255) NOT NULL,
     resource_type VARCHAR(50) NOT NULL,
     owner VARCHAR(255),
+    owner_source VARCHAR(20) NOT NULL DEFAULT '',
+    creator_checked_at DATETIME NULL,
+    source VARCHAR(20) NOT NULL DEFAULT 'sync',
+    attributes JSON NULL,
     status VARCHAR(50) NOT NULL,
     subscription_id VARCHAR(255) NOT NULL,
     last_sync_at DATETIME NOT NULL,
+    deleted_at DATETIME NULL,
+    deleted_by_task_id BIGINT NULL,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     INDEX idx_resource_id (resource_id),
     INDEX idx_resource_type (resource_type),
-    INDEX idx_subscription_id (subscription_id)
+    INDEX idx_subscription_id (subscription_id),
+    INDEX idx_provider (provider),
+    INDEX idx_source (source),
+    INDEX idx_deleted_at (deleted_at)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
 -- 创建资源标签表
@@ -38,20 +48,24 @@ CREATE TABLE resource_tags (
 -- 创建虚拟机表
 CREATE TABLE vms (
     id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL DEFAULT 'azure',
     vm_id VARCHAR(255) NOT NULL UNIQUE,
     resource_id VARCHAR(255) NOT NULL,
     name VARCHAR(255) NOT NULL,
     location VARCHAR(255) NOT NULL,
-    size VARCHAR(100) NOT NULL,
+    type VARCHAR(100) NOT NULL,
     status VARCHAR(50) NOT NULL,
     owner VARCHAR(255),
     subscription_id VARCHAR(255) NOT NULL,
     last_sync_at DATETIME NOT NULL,
+    deleted_at DATETIME NULL,
+    deleted_by_task_id BIGINT NULL,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     FOREIGN KEY (resource_id) REFERENCES resources(resource_id),
     INDEX idx_vm_id (vm_id),
-    INDEX idx_resource_id (resource_id)
+    INDEX idx_resource_id (resource_id),
+    INDEX idx_deleted_at (deleted_at)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
 -- 创建虚拟机标签表
@@ -68,6 +82,7 @@ CREATE TABLE vm_tags (
 -- 创建数据库资源表
 CREATE TABLE cmdb_databases (
     id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL DEFAULT 'azure',
     database_id VARCHAR(255) NOT NULL UNIQUE,
     resource_id VARCHAR(255) NOT NULL,
     name VARCHAR(255) NOT NULL,
@@ -79,12 +94,15 @@ CREATE TABLE cmdb_databases (
     owner VARCHAR(255),
     subscription_id VARCHAR(255) NOT NULL,
     last_sync_at DATETIME NOT NULL,
+    deleted_at DATETIME NULL,
+    deleted_by_task_id BIGINT NULL,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     FOREIGN KEY (resource_id) REFERENCES resources(resource_id),
     INDEX idx_database_id (database_id),
     INDEX idx_resource_id (resource_id),
-    INDEX idx_db_type (db_type)
+    INDEX idx_db_type (db_type),
+    INDEX idx_deleted_at (deleted_at)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
 -- 创建数据库标签表
@@ -96,4 +114,171 @@ CREATE TABLE cmdb_database_tags (
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (database_id) REFERENCES cmdb_databases(database_id) ON DELETE CASCADE,
     UNIQUE KEY uk_database_tag (database_id, tag_key)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
\ No newline at end of file
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 创建同步任务表，每次同步中每个Provider账号的每种资源对应一条记录
+CREATE TABLE sync_tasks (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL,
+    account VARCHAR(100) NOT NULL,
+    task_type VARCHAR(50) NOT NULL,
+    sync_trigger VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    start_time DATETIME NOT NULL,
+    end_time DATETIME NULL,
+    item_count INT NOT NULL DEFAULT 0,
+    deleted_count INT NOT NULL DEFAULT 0,
+    error_msg TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_provider_account (provider, account),
+    INDEX idx_task_type (task_type),
+    INDEX idx_status_end_time (status, end_time)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 创建变更历史表，只追加不修改；资源被永久删除后仍保留其历史
+CREATE TABLE change_history (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    item_type VARCHAR(20) NOT NULL,
+    item_id VARCHAR(255) NOT NULL,
+    resource_id VARCHAR(255) NOT NULL,
+    change_type VARCHAR(20) NOT NULL,
+    field VARCHAR(300) NOT NULL DEFAULT '',
+    old_value TEXT NULL,
+    new_value TEXT NULL,
+    sync_task_id BIGINT NULL,
+    changed_at DATETIME NOT NULL,
+    INDEX idx_resource_changed_at (resource_id, changed_at),
+    INDEX idx_item (item_type, item_id),
+    INDEX idx_sync_task_id (sync_task_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 创建配置项版本表，每个版本保存配置项在 [valid_from, valid_to) 内的完整快照，valid_to为NULL表示当前版本
+CREATE TABLE item_versions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    item_type VARCHAR(20) NOT NULL,
+    item_id VARCHAR(255) NOT NULL,
+    resource_id VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    snapshot JSON NOT NULL,
+    valid_from DATETIME NOT NULL,
+    valid_to DATETIME NULL,
+    INDEX idx_item (item_type, item_id, valid_to),
+    INDEX idx_type_valid (item_type, valid_from, valid_to)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 创建全文检索文档表，每个资源、虚拟机和数据库对应一条，随同步更新
+-- 使用ngram分词器，支持中文名称以及资源ID、标签值片段的检索
+CREATE TABLE search_documents (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    item_type VARCHAR(20) NOT NULL,
+    item_id VARCHAR(255) NOT NULL,
+    resource_id VARCHAR(255) NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    owner VARCHAR(255) NOT NULL DEFAULT '',
+    tags_text TEXT NOT NULL,
+    deleted TINYINT(1) NOT NULL DEFAULT 0,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_item (item_type, item_id),
+    FULLTEXT INDEX ft_name (name) WITH PARSER ngram,
+    FULLTEXT INDEX ft_all (name, item_id, owner, tags_text) WITH PARSER ngram
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 创建资源合规表，保存最近一次标签策略评估中每个资源的结果，每次评估整体替换
+CREATE TABLE resource_compliance (
+    resource_id VARCHAR(255) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    resource_type VARCHAR(255) NOT NULL,
+    subscription_id VARCHAR(255) NOT NULL,
+    owner VARCHAR(255) NOT NULL DEFAULT '',
+    owner_source VARCHAR(20) NOT NULL DEFAULT '',
+    compliant TINYINT(1) NOT NULL,
+    violation_count INT NOT NULL DEFAULT 0,
+    violations JSON NOT NULL,
+    evaluated_at DATETIME NOT NULL,
+    INDEX idx_compliant (compliant),
+    INDEX idx_owner (owner),
+    INDEX idx_subscription_id (subscription_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 创建合规快照表，每次评估追加一条总体结果，用于合规趋势
+CREATE TABLE compliance_snapshots (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    evaluated_at DATETIME NOT NULL,
+    total_resources INT NOT NULL,
+    compliant_resources INT NOT NULL,
+    violation_count INT NOT NULL,
+    INDEX idx_evaluated_at (evaluated_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 创建所有者目录缓存表，保存所有者取值在Entra ID中解析到的用户或组，过期后重新解析
+CREATE TABLE owner_directory (
+    owner_value VARCHAR(255) PRIMARY KEY,
+    status VARCHAR(20) NOT NULL,
+    kind VARCHAR(20) NOT NULL DEFAULT '',
+    object_id VARCHAR(64) NOT NULL DEFAULT '',
+    display_name VARCHAR(255) NOT NULL DEFAULT '',
+    user_principal_name VARCHAR(255) NOT NULL DEFAULT '',
+    mail VARCHAR(255) NOT NULL DEFAULT '',
+    department VARCHAR(255) NOT NULL DEFAULT '',
+    manager_id VARCHAR(64) NOT NULL DEFAULT '',
+    manager_name VARCHAR(255) NOT NULL DEFAULT '',
+    resolved_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    INDEX idx_status (status),
+    INDEX idx_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 创建资源关系表，保存同步时发现的资源之间的有类型关系（依赖方 -> 被依赖方）
+CREATE TABLE resource_relationships (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL,
+    subscription_id VARCHAR(255) NOT NULL,
+    source_id VARCHAR(255) NOT NULL,
+    source_type VARCHAR(255) NOT NULL DEFAULT '',
+    target_id VARCHAR(255) NOT NULL,
+    target_type VARCHAR(255) NOT NULL DEFAULT '',
+    relationship_type VARCHAR(50) NOT NULL,
+    last_sync_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_relationship (source_id, target_id, relationship_type),
+    INDEX idx_target_id (target_id),
+    INDEX idx_scope (provider, subscription_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 创建应用表，保存应用和业务服务CI，成员由手动指定的资源和标签规则共同确定
+CREATE TABLE applications (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    kind VARCHAR(30) NOT NULL,
+    description VARCHAR(1000) NOT NULL DEFAULT '',
+    owner VARCHAR(255) NOT NULL DEFAULT '',
+    criticality VARCHAR(20) NOT NULL,
+    support_group VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_kind (kind),
+    INDEX idx_owner (owner)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 创建应用标签规则表，带有匹配标签的资源属于应用，tag_value为空时只要求存在该标签
+CREATE TABLE application_tag_rules (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    application_id BIGINT NOT NULL,
+    tag_key VARCHAR(255) NOT NULL,
+    tag_value VARCHAR(255) NOT NULL DEFAULT '',
+    FOREIGN KEY (application_id) REFERENCES applications(id) ON DELETE CASCADE,
+    UNIQUE KEY uk_application_tag_rule (application_id, tag_key, tag_value),
+    INDEX idx_tag (tag_key, tag_value)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 创建应用成员表，保存手动指定属于应用的资源
+CREATE TABLE application_members (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    application_id BIGINT NOT NULL,
+    resource_id VARCHAR(255) NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (application_id) REFERENCES applications(id) ON DELETE CASCADE,
+    UNIQUE KEY uk_application_member (application_id, resource_id),
+    INDEX idx_resource_id (resource_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;